@@ -0,0 +1,37 @@
+package rdb
+
+import (
+	"context"
+)
+
+// SETBIT key offset value, 设置 key 对应字符串 offset 位置的 bit, 如: Params: "{{offset}} {{value}}"
+// return 该位置原来的 bit 值, 使用 Int() 获取
+func (b builder) SetBit(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, SETBIT, args, includeArgs...)
+}
+
+// GETBIT key offset, 获取 offset 位置的 bit, offset 超出字符串长度或 key 不存在时返回 0
+// return 使用 Int() 获取
+func (b builder) GetBit(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, GETBIT, args, includeArgs...)
+}
+
+// BITCOUNT key [start end [BYTE | BIT]], 统计被设置为 1 的 bit 数量
+// 不写范围时统计整个字符串, 范围默认按字节计算, redis7 开始可以用 BIT 按位计算, 如: Params: "{{start}} {{end}} BIT"
+// return 使用 Int() 获取
+func (b builder) BitCount(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BITCOUNT, args, includeArgs...)
+}
+
+// BITPOS key bit [start [end [BYTE | BIT]]], 返回第一个值为 bit 的位置, 范围同 BITCOUNT, 如: Params: "1 {{start}}"
+// return 找不到时返回 -1, 使用 Int() 获取
+func (b builder) BitPos(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BITPOS, args, includeArgs...)
+}
+
+// BITOP AND | OR | XOR | NOT destkey key [key ...], 对多个 key 做位运算并把结果保存到 destkey, NOT 只接受一个 key
+// 多个 key 使用 NoUseKey 加 slice 展开, 如: {Params: "{{op}} {{destination}} {{keys}}", NoUseKey: true}
+// return 保存到 destkey 的字符串长度, 使用 Int() 获取
+func (b builder) BitOp(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BITOP, args, includeArgs...)
+}
@@ -0,0 +1,77 @@
+package rdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// BitmapCmd bitmap 命令测试使用的命令定义
+var BitmapCmd = RdCmd{
+	Key: "active:{{day}}",
+	CMD: map[Command]RdSubCmd{
+		SETBIT:   {Params: "{{uid}} 1"},
+		GETBIT:   {Params: "{{uid}}"},
+		BITCOUNT: {Params: "{{start}} {{end}} BIT"},
+		BITPOS:   {Params: "{{bit}}"},
+		BITOP:    {Params: "{{op}} {{destination}} {{keys}}", NoUseKey: true},
+	},
+}
+
+// TestRedisClient_Bitmaps 测试 bitmap 命令的参数构建, 包括 BITCOUNT 的 BIT 范围和 BITOP 的多个 key
+func TestRedisClient_Bitmaps(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SETBIT", "GETBIT":
+			return 0
+		case "BITCOUNT":
+			return 3
+		case "BITPOS":
+			return 7
+		case "BITOP":
+			return 16
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func() (int64, error)
+		want int64
+		cmd  []string
+	}{
+		{"SetBit", func() (int64, error) {
+			return client.SetBit(ctx, BitmapCmd, map[string]any{"day": "20240101", "uid": 42}).Int().Result()
+		}, 0, []string{"SETBIT", "active:20240101", "42", "1"}},
+		{"GetBit", func() (int64, error) {
+			return client.GetBit(ctx, BitmapCmd, map[string]any{"day": "20240101", "uid": 42}).Int().Result()
+		}, 0, []string{"GETBIT", "active:20240101", "42"}},
+		{"BitCount", func() (int64, error) {
+			return client.BitCount(ctx, BitmapCmd, map[string]any{"day": "20240101", "start": 0, "end": 63}).Int().Result()
+		}, 3, []string{"BITCOUNT", "active:20240101", "0", "63", "BIT"}},
+		{"BitPos", func() (int64, error) {
+			return client.BitPos(ctx, BitmapCmd, map[string]any{"day": "20240101", "bit": 1}).Int().Result()
+		}, 7, []string{"BITPOS", "active:20240101", "1"}},
+		{"BitOp", func() (int64, error) {
+			return client.BitOp(ctx, BitmapCmd, map[string]any{
+				"op": "AND", "destination": "active:both", "keys": []string{"active:20240101", "active:20240102"},
+			}).Int().Result()
+		}, 16, []string{"BITOP", "AND", "active:both", "active:20240101", "active:20240102"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.Reset()
+			got, err := tt.run()
+			if err != nil {
+				t.Fatalf("%s failed: %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+			if cmds := fake.Commands(); !reflect.DeepEqual(cmds, [][]string{tt.cmd}) {
+				t.Errorf("Expected %v, got %v", [][]string{tt.cmd}, cmds)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package rdb
+
+import (
+	"context"
+)
+
+// RedisBloom 模块的布隆过滤器命令, 需要服务端加载了 RedisBloom(redis8 已经内置), 可以先用 HasModule(ctx, ModuleBloom) 检查
+
+// BF.RESERVE key error_rate capacity [EXPANSION expansion] [NONSCALING], 创建一个指定误判率和容量的布隆过滤器
+// 如: Params: "{{errorRate}} {{capacity}}", key 已经存在时返回错误, 使用 Status() 获取结果
+func (b builder) BFReserve(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BFRESERVE, args, includeArgs...)
+}
+
+// BF.ADD key item, 添加一个元素, 过滤器不存在时使用默认参数自动创建
+// return 使用 Bool() 获取, true 新添加， false 元素可能已经存在
+func (b builder) BFAdd(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BFADD, args, includeArgs...)
+}
+
+// BF.MADD key item [item ...], 添加多个元素, 多个元素使用 slice 展开, 如: Params: "{{items}}"
+// return 使用 BoolSlice() 获取, 和元素一一对应
+func (b builder) BFMAdd(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BFMADD, args, includeArgs...)
+}
+
+// BF.EXISTS key item, 判断元素是否可能存在
+// return 使用 Bool() 获取, true 可能存在， false 一定不存在
+func (b builder) BFExists(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BFEXISTS, args, includeArgs...)
+}
+
+// BF.MEXISTS key item [item ...], 判断多个元素是否可能存在, 多个元素同 BFMAdd 传入
+// return 使用 BoolSlice() 获取, 和元素一一对应
+func (b builder) BFMExists(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BFMEXISTS, args, includeArgs...)
+}
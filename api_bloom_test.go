@@ -0,0 +1,104 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// 测试布隆过滤器的 RdCmd 定义
+var BloomCmd = RdCmd{
+	Key: "bloom:{{name}}",
+	CMD: map[Command]RdSubCmd{
+		BFRESERVE: {Params: "{{errorRate}} {{capacity}}"},
+		BFADD:     {Params: "{{item}}"},
+		BFMADD:    {Params: "{{items}}"},
+		BFEXISTS:  {Params: "{{item}}"},
+		BFMEXISTS: {Params: "{{items}}"},
+	},
+}
+
+// TestRedisClient_Bloom 测试创建布隆过滤器、添加元素以及判断元素是否存在
+func TestRedisClient_Bloom(t *testing.T) {
+	var mu sync.Mutex
+	filters := map[string]map[string]bool{}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "MODULE":
+			return []any{[]any{"name", "bf", "ver", 20612, "path", "/usr/lib/redisbloom.so", "args", []any{}}}
+		case "BF.RESERVE":
+			if _, ok := filters[args[1]]; ok {
+				return errors.New("ERR item exists")
+			}
+			filters[args[1]] = map[string]bool{}
+			return fakeStatus("OK")
+		case "BF.ADD", "BF.MADD":
+			res := []any{}
+			for _, item := range args[2:] {
+				added := 0
+				if !filters[args[1]][item] {
+					filters[args[1]][item] = true
+					added = 1
+				}
+				res = append(res, added)
+			}
+			if args[0] == "BF.ADD" {
+				return res[0]
+			}
+			return res
+		case "BF.EXISTS", "BF.MEXISTS":
+			res := []any{}
+			for _, item := range args[2:] {
+				exists := 0
+				if filters[args[1]][item] {
+					exists = 1
+				}
+				res = append(res, exists)
+			}
+			if args[0] == "BF.EXISTS" {
+				return res[0]
+			}
+			return res
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if ok, err := client.HasModule(ctx, ModuleBloom); err != nil || !ok {
+		t.Fatalf("Expected bloom module to be available: %v %v", ok, err)
+	}
+	if ok, _ := client.HasModule(ctx, ModuleJSON); ok {
+		t.Errorf("Expected json module to be unavailable")
+	}
+
+	err := client.BFReserve(ctx, BloomCmd, map[string]any{"name": "users", "errorRate": 0.01, "capacity": 1000}).Status().Err()
+	if err != nil {
+		t.Fatalf("BFReserve failed: %v", err)
+	}
+	if added, err := client.BFAdd(ctx, BloomCmd, map[string]any{"name": "users", "item": "alice"}).Bool().Result(); err != nil || !added {
+		t.Errorf("BFAdd failed: %v %v", added, err)
+	}
+	added, err := client.BFMAdd(ctx, BloomCmd, map[string]any{"name": "users", "items": []string{"alice", "bob"}}).BoolSlice().Result()
+	if err != nil || !reflect.DeepEqual(added, []bool{false, true}) {
+		t.Errorf("BFMAdd failed: %v %v", added, err)
+	}
+	if exists, err := client.BFExists(ctx, BloomCmd, map[string]any{"name": "users", "item": "bob"}).Bool().Result(); err != nil || !exists {
+		t.Errorf("BFExists failed: %v %v", exists, err)
+	}
+	exists, err := client.BFMExists(ctx, BloomCmd, map[string]any{"name": "users", "items": []string{"alice", "carol"}}).BoolSlice().Result()
+	if err != nil || !reflect.DeepEqual(exists, []bool{true, false}) {
+		t.Errorf("BFMExists failed: %v %v", exists, err)
+	}
+
+	cmds := fake.Commands()
+	if len(cmds) != 6 {
+		t.Fatalf("Expected MODULE LIST to be cached, got %v", cmds)
+	}
+	if want := []string{"BF.RESERVE", "bloom:users", "0.01", "1000"}; !reflect.DeepEqual(cmds[1], want) {
+		t.Errorf("Expected %v, got %v", want, cmds[1])
+	}
+}
@@ -0,0 +1,145 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+)
+
+// GEOADD key [NX | XX] [CH] longitude latitude member [longitude latitude member ...], 添加地理位置
+// locations 按 经度 纬度 名称 依次追加在 Params 之后, NX/XX/CH 之类的选项写在 Params 中
+// return 新添加的成员数量, 使用 Int() 获取
+func (b builder) GeoAdd(ctx context.Context, cmd RdCmd, args map[string]any, locations ...*redis.GeoLocation) *CommandBuilder {
+	includeArgs := make([]any, 0, 3*len(locations))
+	for _, loc := range locations {
+		includeArgs = append(includeArgs, loc.Longitude, loc.Latitude, loc.Name)
+	}
+	return b(ctx, cmd, GEOADD, args, includeArgs...)
+}
+
+// GEODIST key member1 member2 [M | KM | FT | MI], 返回两个成员之间的距离
+// 如: Params: "{{member1}} {{member2}} km"
+// return 距离, 成员不存在时返回 redis.Nil, 使用 Float() 获取
+func (b builder) GeoDist(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, GEODIST, args, includeArgs...)
+}
+
+// GEOPOS key [member [member ...]], 返回成员的经纬度
+// 多个成员使用 slice 展开, 如: Params: "{{members}}"
+// return 和成员顺序一致的坐标, 成员不存在时对应位置为 nil, 使用 GeoPos() 获取
+func (b builder) GeoPos(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, GEOPOS, args, includeArgs...)
+}
+
+// GEOSEARCH key FROMMEMBER member | FROMLONLAT longitude latitude BYRADIUS radius unit | BYBOX width height unit
+// [ASC | DESC] [COUNT count [ANY]] [WITHCOORD] [WITHDIST] [WITHHASH], 搜索指定范围内的成员, 从redis6.2开始支持
+// query 中的条件按顺序追加在 Params 之后, Radius 大于 0 时按半径搜索, 否则按矩形搜索, 单位默认 km
+// return 带 WITHCOORD/WITHDIST/WITHHASH 时使用 GeoSearchLocation() 获取, 否则使用 StringSlice() 获取成员名
+func (b builder) GeoSearch(ctx context.Context, cmd RdCmd, args map[string]any, query *redis.GeoSearchLocationQuery) *CommandBuilder {
+	return b(ctx, cmd, GEOSEARCH, args, geoSearchArgs(query)...)
+}
+
+// geoSearchArgs 把 query 转成 GEOSEARCH 的参数, 和 go-redis 的生成规则一致
+func geoSearchArgs(q *redis.GeoSearchLocationQuery) []any {
+	var args []any
+	if q.Member != "" {
+		args = append(args, "frommember", q.Member)
+	} else {
+		args = append(args, "fromlonlat", q.Longitude, q.Latitude)
+	}
+	if q.Radius > 0 {
+		args = append(args, "byradius", q.Radius, geoUnit(q.RadiusUnit))
+	} else {
+		args = append(args, "bybox", q.BoxWidth, q.BoxHeight, geoUnit(q.BoxUnit))
+	}
+	if q.Sort != "" {
+		args = append(args, q.Sort)
+	}
+	if q.Count > 0 {
+		args = append(args, "count", q.Count)
+		if q.CountAny {
+			args = append(args, "any")
+		}
+	}
+	if q.WithCoord {
+		args = append(args, "withcoord")
+	}
+	if q.WithDist {
+		args = append(args, "withdist")
+	}
+	if q.WithHash {
+		args = append(args, "withhash")
+	}
+	return args
+}
+
+// geoUnit 没有指定单位时使用 km
+func geoUnit(unit string) string {
+	if unit == "" {
+		return "km"
+	}
+	return unit
+}
+
+// geoSearchLocationQuery 从已经构建好的 GEOSEARCH 参数中找出 WITHCOORD/WITHDIST/WITHHASH,
+// GeoSearchLocationCmd 依赖这些选项解析回复
+func geoSearchLocationQuery(cmdList []any) *redis.GeoSearchLocationQuery {
+	q := &redis.GeoSearchLocationQuery{}
+	for _, arg := range cmdList {
+		switch strings.ToUpper(fmt.Sprint(arg)) {
+		case "WITHCOORD":
+			q.WithCoord = true
+		case "WITHDIST":
+			q.WithDist = true
+		case "WITHHASH":
+			q.WithHash = true
+		}
+	}
+	return q
+}
+
+// newGeoLocationCmd 用已经构建好的 GEORADIUS/GEORADIUSBYMEMBER 参数创建 GeoLocationCmd
+// go-redis 会把半径和选项重新追加到参数后面, 所以这里把它们解析成 GeoRadiusQuery, 只把半径之前的参数交给 go-redis
+func newGeoLocationCmd(ctx context.Context, cmdList []any) *redis.GeoLocationCmd {
+	radiusAt := 4 // GEORADIUS key longitude latitude radius unit
+	if len(cmdList) > 0 && strings.Contains(strings.ToUpper(fmt.Sprint(cmdList[0])), "BYMEMBER") {
+		radiusAt = 3 // GEORADIUSBYMEMBER key member radius unit
+	}
+	q := &redis.GeoRadiusQuery{}
+	if len(cmdList) < radiusAt+2 {
+		// 缺少半径或单位, go-redis 追加的默认半径会让参数个数不对, 由 redis 返回语法错误
+		return redis.NewGeoLocationCmd(ctx, q, cmdList...)
+	}
+	q.Radius, _ = strconv.ParseFloat(fmt.Sprint(cmdList[radiusAt]), 64)
+	q.Unit = fmt.Sprint(cmdList[radiusAt+1])
+	rest := cmdList[radiusAt+2:]
+	for i := 0; i < len(rest); i++ {
+		switch opt := strings.ToUpper(fmt.Sprint(rest[i])); opt {
+		case "WITHCOORD":
+			q.WithCoord = true
+		case "WITHDIST":
+			q.WithDist = true
+		case "WITHHASH":
+			q.WithGeoHash = true
+		case "ASC", "DESC":
+			q.Sort = opt
+		case "COUNT", "STORE", "STOREDIST":
+			if i+1 >= len(rest) {
+				break
+			}
+			i++
+			val := fmt.Sprint(rest[i])
+			switch opt {
+			case "COUNT":
+				q.Count, _ = strconv.Atoi(val)
+			case "STORE":
+				q.Store = val
+			case "STOREDIST":
+				q.StoreDist = val
+			}
+		}
+	}
+	return redis.NewGeoLocationCmd(ctx, q, cmdList[:radiusAt]...)
+}
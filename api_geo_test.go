@@ -0,0 +1,117 @@
+package rdb
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"testing"
+)
+
+// TestRedisClient_GeoAdd 测试 GeoAdd 把位置按 经度 纬度 名称 追加在 Params 之后
+func TestRedisClient_GeoAdd(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "GEOADD" {
+			return 2
+		}
+		return nil
+	})
+	cmd := RdCmd{Key: "city:{{country}}", CMD: map[Command]RdSubCmd{GEOADD: {Params: "NX"}}}
+
+	n, err := client.GeoAdd(context.Background(), cmd, map[string]any{"country": "it"},
+		&redis.GeoLocation{Name: "Palermo", Longitude: 13.361389, Latitude: 38.115556},
+		&redis.GeoLocation{Name: "Catania", Longitude: 15.087269, Latitude: 37.502669},
+	).Int().Result()
+	if err != nil {
+		t.Fatalf("GeoAdd failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2, got %d", n)
+	}
+	want := [][]string{{"GEOADD", "city:it", "NX", "13.361389", "38.115556", "Palermo", "15.087269", "37.502669", "Catania"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_GeoSearch 测试 GeoSearch 的参数生成以及 GeoSearchLocation 根据 WITH 选项解析回复
+func TestRedisClient_GeoSearch(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "GEOSEARCH" {
+			return []any{
+				[]any{"Palermo", "190.4424", []any{"13.361389", "38.115556"}},
+				[]any{"Catania", "56.4413", []any{"15.087269", "37.502669"}},
+			}
+		}
+		return nil
+	})
+	cmd := RdCmd{Key: "city:{{country}}", CMD: map[Command]RdSubCmd{GEOSEARCH: {}}}
+	query := &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{Longitude: 15, Latitude: 37, Radius: 200, Sort: "ASC", Count: 2},
+		WithCoord:      true,
+		WithDist:       true,
+	}
+
+	locations, err := client.GeoSearch(context.Background(), cmd, map[string]any{"country": "it"}, query).GeoSearchLocation().Result()
+	if err != nil {
+		t.Fatalf("GeoSearch failed: %v", err)
+	}
+	want := []redis.GeoLocation{
+		{Name: "Palermo", Longitude: 13.361389, Latitude: 38.115556, Dist: 190.4424},
+		{Name: "Catania", Longitude: 15.087269, Latitude: 37.502669, Dist: 56.4413},
+	}
+	if !reflect.DeepEqual(locations, want) {
+		t.Errorf("Expected %v, got %v", want, locations)
+	}
+	wantCmds := [][]string{{"GEOSEARCH", "city:it", "fromlonlat", "15", "37", "byradius", "200", "km", "ASC", "count", "2", "withcoord", "withdist"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, wantCmds) {
+		t.Errorf("Expected %v, got %v", wantCmds, got)
+	}
+}
+
+// TestRedisClient_GeoPos 测试 GeoPos 解析坐标, 不存在的成员对应 nil
+func TestRedisClient_GeoPos(t *testing.T) {
+	client, _ := newFakeClient(t, func(args []string) any {
+		if args[0] == "GEOPOS" {
+			return []any{[]any{"13.361389", "38.115556"}, nil}
+		}
+		return nil
+	})
+	cmd := RdCmd{Key: "city:it", CMD: map[Command]RdSubCmd{GEOPOS: {Params: "{{members}}"}}}
+
+	positions, err := client.GeoPos(context.Background(), cmd, map[string]any{"members": []string{"Palermo", "Rome"}}).GeoPos().Result()
+	if err != nil {
+		t.Fatalf("GeoPos failed: %v", err)
+	}
+	if len(positions) != 2 || positions[1] != nil {
+		t.Fatalf("Expected 2 positions with a nil second entry, got %v", positions)
+	}
+	if *positions[0] != (redis.GeoPos{Longitude: 13.361389, Latitude: 38.115556}) {
+		t.Errorf("Unexpected position: %v", *positions[0])
+	}
+}
+
+// TestExecuteCmd_GeoLocation 测试 GEORADIUS 模板构建的参数不会被 GeoLocationCmd 重复追加, 并按 WITHDIST 解析回复
+func TestExecuteCmd_GeoLocation(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "GEORADIUS" {
+			return []any{[]any{"Catania", "56.4413"}}
+		}
+		return nil
+	})
+	cmd := RdCmd{Key: "city:it", CMD: map[Command]RdSubCmd{
+		GEORADIUS: {Params: "{{lon}} {{lat}} {{radius}} km WITHDIST COUNT 1 ASC"},
+	}}
+
+	locations, err := client.builder(context.Background(), cmd, GEORADIUS, map[string]any{"lon": 15, "lat": 37, "radius": 200}).GeoLocation().Result()
+	if err != nil {
+		t.Fatalf("GEORADIUS failed: %v", err)
+	}
+	want := []redis.GeoLocation{{Name: "Catania", Dist: 56.4413}}
+	if !reflect.DeepEqual(locations, want) {
+		t.Errorf("Expected %v, got %v", want, locations)
+	}
+	wantCmds := [][]string{{"GEORADIUS", "city:it", "15", "37", "200", "km", "withdist", "count", "1", "ASC"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, wantCmds) {
+		t.Errorf("Expected %v, got %v", wantCmds, got)
+	}
+}
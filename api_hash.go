@@ -2,24 +2,29 @@ package rdb
 
 import (
 	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
 )
 
-// HSET key field value
+// HSET key field value [field value ...], 多个字段可以使用 map 展开, 如: Params: "{{fields}}", args: {"fields": map[string]any{"name": "a", "age": 1}}
+// map 按字段名排序后展开, 返回新增的字段数量, 使用 Int() 获取
 func (b builder) HSet(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HSET, args, includeArgs...)
 }
 
-// HGET key field
+// HGET key field, 使用 String() 获取
 func (b builder) HGet(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HGET, args, includeArgs...)
 }
 
-// HDEL key field [field2 ...], 删除字段，可以同时删除多个
+// HDEL key field [field2 ...], 删除字段，可以同时删除多个, 如: Params: "{{fields}}", args: {"fields": []string{"a", "b"}}
+// 返回删除的字段数量, 使用 Int() 获取
 func (b builder) HDel(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HDEL, args, includeArgs...)
 }
 
-// HGETALL key
+// HGETALL key, 使用 MapStringString() 获取
 func (b builder) HGetAll(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HGETALL, args, includeArgs...)
 }
@@ -29,42 +34,178 @@ func (b builder) HMSet(ctx context.Context, cmd RdCmd, args map[string]any, incl
 	return b(ctx, cmd, HMSET, args, includeArgs...)
 }
 
-// HMGET key field1  field2
+// HMGET key field1  field2, 多个字段使用 slice 展开, 如: Params: "{{fields}}", args: {"fields": []string{"a", "b"}}
+// 返回和字段顺序一致的值, 字段不存在时为 nil, 使用 Slice() 获取
 func (b builder) HMGet(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HMGET, args, includeArgs...)
 }
 
-// HSETNX key field value , 设置键下字段的值，存在则不操作返回0，不存在并创建成功则返回1
+// HSETNX key field value , 设置键下字段的值，存在则不操作返回0，不存在并创建成功则返回1, 使用 Bool() 获取
 func (b builder) HSetNx(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HSETNX, args, includeArgs...)
 }
 
-// HINCRBY key field1  value   , 指定键指定字段自增指定的整数
+// HINCRBY key field1  value   , 指定键指定字段自增指定的整数, 如: Params: "{{field}} {{by}}", 使用 Int() 获取结果 *redis.IntCmd
 func (b builder) HIncrBy(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HINCRBY, args, includeArgs...)
 }
 
-// HINCRBYFLOAT key field1  value   , 指定键指定字段自增指定的浮点数
+// HINCRBYFLOAT key field1  value   , 指定键指定字段自增指定的浮点数, 如: Params: "{{field}} {{by}}", 使用 Float() 获取结果 *redis.FloatCmd
 func (b builder) HIncrByFloat(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HINCRBYFLOAT, args, includeArgs...)
 }
 
-// HKEYS key  , 获取键下的所有字段列表
+// HKEYS key  , 获取键下的所有字段列表, 使用 StringSlice() 获取
 func (b builder) HKeys(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HKEYS, args, includeArgs...)
 }
 
-// HLEN key  , 获取键下字段的数量
+// HLEN key  , 获取键下字段的数量, 使用 Int() 获取
 func (b builder) HLen(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HLEN, args, includeArgs...)
 }
 
-// HVALS key  , 返回哈希表所有的值
+// HVALS key  , 返回哈希表所有的值, 使用 StringSlice() 获取
 func (b builder) HVals(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HVALS, args, includeArgs...)
 }
 
-// HEXISTS key field, 键下是否存在指定的字段
+// HEXISTS key field, 键下是否存在指定的字段, 使用 Bool() 获取
 func (b builder) HExists(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, HEXISTS, args, includeArgs...)
 }
+
+// 字段过期相关的命令都需要 redis7.4 及以上的版本(HGETEX/HGETDEL 需要 8.0), 使用前可以通过
+// client.AtLeastVersion(ctx, VersionHashFieldTTL) 检测, 返回的都是每个字段一个结果, 使用 IntSlice() 获取
+
+// HEXPIRE key seconds [NX|XX|GT|LT] FIELDS numfields field [field ...], 给哈希表中的字段设置过期时间, 从redis7.4开始支持
+// 如: Params: "{{seconds}} FIELDS {{numfields}} {{field}}"
+// return []int, 每个字段的结果: -2 字段不存在, 0 条件不满足, 1 设置成功, 2 过期时间为0或者已经过去, 字段被删除
+func (b builder) HExpire(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, HEXPIRE, args, includeArgs...)
+}
+
+// HPEXPIRE key milliseconds [NX|XX|GT|LT] FIELDS numfields field [field ...], 和 HEXPIRE 一样, 过期时间的单位是毫秒, 从redis7.4开始支持
+// return []int, 每个字段的结果, 同 HEXPIRE
+func (b builder) HPExpire(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, HPEXPIRE, args, includeArgs...)
+}
+
+// HTTL key FIELDS numfields field [field ...], 查询哈希表中字段的剩余过期时间(秒), 从redis7.4开始支持
+// return []int, 每个字段的结果: -2 字段不存在, -1 字段存在且没有过期时间, >=0 剩余秒数
+func (b builder) HTTL(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, HTTL, args, includeArgs...)
+}
+
+// HPTTL key FIELDS numfields field [field ...], 和 HTTL 一样, 剩余时间的单位是毫秒, 从redis7.4开始支持
+// return []int, 每个字段的结果, 同 HTTL
+func (b builder) HPTTL(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, HPTTL, args, includeArgs...)
+}
+
+// HPERSIST key FIELDS numfields field [field ...], 移除哈希表中字段的过期时间, 从redis7.4开始支持
+// return []int, 每个字段的结果: -2 字段不存在, -1 字段没有过期时间, 1 移除成功
+func (b builder) HPersist(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, HPERSIST, args, includeArgs...)
+}
+
+// HGETEX key [EX seconds|PX milliseconds|EXAT unix-time-seconds|PXAT unix-time-milliseconds|PERSIST] FIELDS numfields field [field ...]
+// 获取哈希表中字段的值并同时设置或者移除这些字段的过期时间, 从redis8.0开始支持
+// return []any, 每个字段的值, 字段不存在时为 nil, 使用 Slice() 获取
+func (b builder) HGetEx(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, HGETEX, args, includeArgs...)
+}
+
+// HGETDEL key FIELDS numfields field [field ...], 获取哈希表中字段的值并删除这些字段, 从redis8.0开始支持
+// return []any, 每个字段的值, 字段不存在时为 nil, 使用 Slice() 获取
+func (b builder) HGetDel(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, HGETDEL, args, includeArgs...)
+}
+
+// HIncrByWithFieldTTL 在一个事务(MULTI/EXEC)中对哈希表的字段执行 HINCRBY 并给这个字段设置过期时间, 从redis7.4开始支持
+// key 使用 cmd 的 key 模板和 args 构造, 和其他命令一样加上缓存版本前缀和 KeyPrefix, cmd 中不需要定义 HINCRBY
+// ttl 是整秒时使用 HEXPIRE, 否则使用 HPEXPIRE; ttl 需要至少 1 毫秒, HEXPIRE 0 会直接删除字段
+// 过期时间没有设置成功(HEXPIRE 的结果不是 1)时返回错误, 这时自增已经执行
+// return 字段自增后的值
+func (rdm *RedisClient) HIncrByWithFieldTTL(ctx context.Context, cmd RdCmd, args map[string]any, field string, n int64, ttl time.Duration) (int64, error) {
+	if ttl < time.Millisecond {
+		return 0, fmt.Errorf("rdb: HIncrByWithFieldTTL ttl must be at least 1ms, got %s", ttl)
+	}
+	cmdList, key, _, err := TryBuild(ctx, cmd.withCmd(HINCRBY, RdSubCmd{}), HINCRBY, args, field, n)
+	if err != nil {
+		return 0, err
+	}
+	if key == "" {
+		return 0, fmt.Errorf("rdb: HIncrByWithFieldTTL needs a key")
+	}
+	cmdList, key = rdm.finalizeCmd(HINCRBY, cmdList, key)
+	incrCmd := redis.NewIntCmd(ctx, cmdList...)
+	var expireCmd *redis.IntSliceCmd
+	expireName := HEXPIRE
+	if ttl%time.Second != 0 {
+		expireName = HPEXPIRE
+	}
+	_, err = rdm.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Process(ctx, incrCmd)
+		if expireName == HEXPIRE {
+			expireCmd = pipe.HExpire(ctx, key, ttl, field)
+		} else {
+			expireCmd = pipe.HPExpire(ctx, key, ttl, field)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	// 1 设置成功; -2 字段不存在, 0 条件不满足, 2 过期时间已经过去字段被删除
+	if res := expireCmd.Val(); len(res) != 1 || res[0] != 1 {
+		return incrCmd.Val(), fmt.Errorf("rdb: %s %s %s returned %v", expireName, key, field, res)
+	}
+	return incrCmd.Val(), nil
+}
+
+// HashEntry HScanIterator 返回的字段和值
+type HashEntry struct {
+	Field string
+	Value string
+}
+
+// HScanIterator 哈希表的字段迭代器, 不是并发安全的
+//
+//	iter := client.HScan(ctx, cmd, map[string]any{"id": 1}, "name*", 100)
+//	for iter.Next() {
+//		entry := iter.Val()
+//	}
+//	if err := iter.Err(); err != nil {
+//		// 处理错误
+//	}
+type HScanIterator struct {
+	pager *scanPager
+	val   HashEntry
+}
+
+// HScan 使用 HSCAN key cursor MATCH match COUNT count 遍历哈希表的字段, key 使用 cmd 的 key 模板和 args 构造, cmd 中不需要定义 HSCAN
+// match 为空时不带 MATCH, count <= 0 时不带 COUNT; 重复返回的字段会被去掉
+func (rdm *RedisClient) HScan(ctx context.Context, cmd RdCmd, args map[string]any, match string, count int64) *HScanIterator {
+	return &HScanIterator{pager: rdm.newScanPager(ctx, cmd, HSCAN, args, match, count)}
+}
+
+// Next 移动到下一个字段, 没有更多的字段或者出错时返回 false
+func (it *HScanIterator) Next() bool {
+	pair := it.pager.next(2)
+	if pair == nil {
+		return false
+	}
+	it.val = HashEntry{Field: pair[0], Value: pair[1]}
+	return true
+}
+
+// Val 返回当前的字段和值
+func (it *HScanIterator) Val() HashEntry {
+	return it.val
+}
+
+// Err 返回遍历过程中出现的错误
+func (it *HScanIterator) Err() error {
+	return it.pager.err
+}
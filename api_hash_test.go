@@ -3,6 +3,9 @@ package rdb
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -457,3 +460,325 @@ func TestRedisClient_Hash_Integration(t *testing.T) {
 	})
 	fmt.Printf("5. HEXISTS name: %d\n", existsCmd.Val())
 }
+
+// 测试哈希字段过期时间的 RdCmd 定义
+var HashFieldTTLCmd = RdCmd{
+	Key: "hash:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		HEXPIRE:  {Params: "{{seconds}} FIELDS {{numfields}}"},
+		HTTL:     {Params: "FIELDS {{numfields}}"},
+		HPERSIST: {Params: "FIELDS {{numfields}}"},
+		HPEXPIRE: {Params: "{{milliseconds}} FIELDS {{numfields}}"},
+		HPTTL:    {Params: "FIELDS {{numfields}}"},
+		HGETEX:   {Params: "EX {{seconds}} FIELDS {{numfields}}"},
+		HGETDEL:  {Params: "FIELDS {{numfields}}"},
+	},
+}
+
+// TestRedisClient_HIncrByWithFieldTTL 测试字段自增并设置字段过期时间, 之后可以通过 HTTL 读回
+func TestRedisClient_HIncrByWithFieldTTL(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]int64{}
+	ttls := map[string]int64{}
+	failExpire := false
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "HINCRBY":
+			n, _ := strconv.ParseInt(args[3], 10, 64)
+			values[args[2]] += n
+			return values[args[2]]
+		case "HEXPIRE":
+			if failExpire {
+				return []any{0}
+			}
+			ttl, _ := strconv.ParseInt(args[2], 10, 64)
+			res := []any{}
+			for _, field := range args[5:] {
+				if _, ok := values[field]; !ok {
+					res = append(res, -2)
+					continue
+				}
+				ttls[field] = ttl
+				res = append(res, 1)
+			}
+			return res
+		case "HTTL", "HPERSIST":
+			res := []any{}
+			for _, field := range args[4:] {
+				ttl, ok := ttls[field]
+				switch {
+				case !ok && values[field] == 0:
+					res = append(res, -2)
+				case !ok:
+					res = append(res, -1)
+				case args[0] == "HPERSIST":
+					delete(ttls, field)
+					res = append(res, 1)
+				default:
+					res = append(res, ttl)
+				}
+			}
+			return res
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	val, err := client.HIncrByWithFieldTTL(ctx, HashFieldTTLCmd, map[string]any{"keyName": "counter"}, "clicks", 5, 30*time.Second)
+	if err != nil || val != 5 {
+		t.Fatalf("HIncrByWithFieldTTL failed: %d %v", val, err)
+	}
+	want := [][]string{
+		{"HINCRBY", "hash:counter", "clicks", "5"},
+		{"HEXPIRE", "hash:counter", "30", "FIELDS", "1", "clicks"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	ttl := client.HTTL(ctx, HashFieldTTLCmd, map[string]any{"keyName": "counter", "numfields": 2}, "clicks", "views").IntSlice()
+	if ttl.Err() != nil || !reflect.DeepEqual(ttl.Val(), []int64{30, -2}) {
+		t.Errorf("unexpected HTTL result: %v %v", ttl.Val(), ttl.Err())
+	}
+
+	persist := client.HPersist(ctx, HashFieldTTLCmd, map[string]any{"keyName": "counter", "numfields": 1}, "clicks").IntSlice()
+	if persist.Err() != nil || !reflect.DeepEqual(persist.Val(), []int64{1}) {
+		t.Errorf("unexpected HPERSIST result: %v %v", persist.Val(), persist.Err())
+	}
+	expire := client.HExpire(ctx, HashFieldTTLCmd, map[string]any{"keyName": "counter", "seconds": 60, "numfields": 1}, "clicks").IntSlice()
+	if expire.Err() != nil || !reflect.DeepEqual(expire.Val(), []int64{1}) {
+		t.Errorf("unexpected HEXPIRE result: %v %v", expire.Val(), expire.Err())
+	}
+
+	// ttl 不足 1 毫秒时不发送命令, HEXPIRE 0 会删除字段
+	fake.Reset()
+	if _, err := client.HIncrByWithFieldTTL(ctx, HashFieldTTLCmd, map[string]any{"keyName": "counter"}, "clicks", 1, 0); err == nil {
+		t.Errorf("Expected error for zero ttl")
+	}
+	if got := fake.Commands(); len(got) != 0 {
+		t.Errorf("Expected no commands for zero ttl, got %v", got)
+	}
+
+	// HEXPIRE 没有设置成功时返回错误
+	mu.Lock()
+	failExpire = true
+	mu.Unlock()
+	if _, err := client.HIncrByWithFieldTTL(ctx, HashFieldTTLCmd, map[string]any{"keyName": "counter"}, "clicks", 1, time.Minute); err == nil {
+		t.Errorf("Expected error when HEXPIRE does not return 1")
+	}
+}
+
+// TestRedisClient_HashFieldExpireFamily 测试给部分字段设置毫秒过期时间, 以及 HGETEX/HGETDEL 读取部分字段
+func TestRedisClient_HashFieldExpireFamily(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{"a": "1", "b": "2", "c": "3"}
+	pttls := map[string]int64{}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "HPEXPIRE":
+			ms, _ := strconv.ParseInt(args[2], 10, 64)
+			res := []any{}
+			for _, field := range args[5:] {
+				if _, ok := values[field]; !ok {
+					res = append(res, -2)
+					continue
+				}
+				pttls[field] = ms
+				res = append(res, 1)
+			}
+			return res
+		case "HPTTL":
+			res := []any{}
+			for _, field := range args[4:] {
+				if _, ok := values[field]; !ok {
+					res = append(res, -2)
+				} else if ttl, ok := pttls[field]; ok {
+					res = append(res, ttl)
+				} else {
+					res = append(res, -1)
+				}
+			}
+			return res
+		case "HGETEX", "HGETDEL":
+			start := 4
+			if args[0] == "HGETEX" {
+				start = 6
+			}
+			res := []any{}
+			for _, field := range args[start:] {
+				v, ok := values[field]
+				if !ok {
+					res = append(res, nil)
+					continue
+				}
+				res = append(res, v)
+				if args[0] == "HGETDEL" {
+					delete(values, field)
+				} else {
+					sec, _ := strconv.ParseInt(args[3], 10, 64)
+					pttls[field] = sec * 1000
+				}
+			}
+			return res
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	expire := client.HPExpire(ctx, HashFieldTTLCmd, map[string]any{"keyName": "h", "milliseconds": 1500, "numfields": 2}, "a", "x").IntSlice()
+	if expire.Err() != nil || !reflect.DeepEqual(expire.Val(), []int64{1, -2}) {
+		t.Fatalf("unexpected HPEXPIRE result: %v %v", expire.Val(), expire.Err())
+	}
+	if got := fake.Commands()[0]; !reflect.DeepEqual(got, []string{"HPEXPIRE", "hash:h", "1500", "FIELDS", "2", "a", "x"}) {
+		t.Errorf("unexpected HPEXPIRE command: %v", got)
+	}
+
+	pttl := client.HPTTL(ctx, HashFieldTTLCmd, map[string]any{"keyName": "h", "numfields": 3}, "a", "b", "x").IntSlice()
+	if pttl.Err() != nil || !reflect.DeepEqual(pttl.Val(), []int64{1500, -1, -2}) {
+		t.Errorf("unexpected HPTTL result: %v %v", pttl.Val(), pttl.Err())
+	}
+
+	getEx := client.HGetEx(ctx, HashFieldTTLCmd, map[string]any{"keyName": "h", "seconds": 10, "numfields": 2}, "b", "x").Slice()
+	if getEx.Err() != nil || !reflect.DeepEqual(getEx.Val(), []any{"2", nil}) {
+		t.Errorf("unexpected HGETEX result: %v %v", getEx.Val(), getEx.Err())
+	}
+	pttl = client.HPTTL(ctx, HashFieldTTLCmd, map[string]any{"keyName": "h", "numfields": 1}, "b").IntSlice()
+	if pttl.Err() != nil || !reflect.DeepEqual(pttl.Val(), []int64{10000}) {
+		t.Errorf("unexpected HPTTL result after HGETEX: %v %v", pttl.Val(), pttl.Err())
+	}
+
+	getDel := client.HGetDel(ctx, HashFieldTTLCmd, map[string]any{"keyName": "h", "numfields": 2}, "c", "a").Slice()
+	if getDel.Err() != nil || !reflect.DeepEqual(getDel.Val(), []any{"3", "1"}) {
+		t.Errorf("unexpected HGETDEL result: %v %v", getDel.Val(), getDel.Err())
+	}
+	if _, ok := values["c"]; ok {
+		t.Errorf("HGETDEL should delete field c")
+	}
+}
+
+// HashFieldsCmd 多字段的哈希命令, 字段列表通过 slice/map 展开
+var HashFieldsCmd = RdCmd{
+	Key: "hash:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		HSET:    {Params: "{{fields}}"},
+		HMGET:   {Params: "{{fields}}"},
+		HDEL:    {Params: "{{fields}}"},
+		HGETALL: {},
+	},
+}
+
+// TestRedisClient_HashMultiFields 测试 HSET/HMGET/HDEL 通过 slice 和 map 展开多个字段
+func TestRedisClient_HashMultiFields(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "HSET":
+			added := 0
+			for i := 2; i+1 < len(args); i += 2 {
+				if _, ok := values[args[i]]; !ok {
+					added++
+				}
+				values[args[i]] = args[i+1]
+			}
+			return added
+		case "HMGET":
+			res := []any{}
+			for _, field := range args[2:] {
+				if v, ok := values[field]; ok {
+					res = append(res, v)
+				} else {
+					res = append(res, nil)
+				}
+			}
+			return res
+		case "HDEL":
+			deleted := 0
+			for _, field := range args[2:] {
+				if _, ok := values[field]; ok {
+					delete(values, field)
+					deleted++
+				}
+			}
+			return deleted
+		case "HGETALL":
+			res := []string{}
+			for k, v := range values {
+				res = append(res, k, v)
+			}
+			return res
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	added := client.HSet(ctx, HashFieldsCmd, map[string]any{"keyName": "u1", "fields": map[string]any{"name": "alice", "age": 30, "city": "sh"}}).Int()
+	if added.Err() != nil || added.Val() != 3 {
+		t.Fatalf("HSet expected 3, got %d %v", added.Val(), added.Err())
+	}
+	got := client.HMGet(ctx, HashFieldsCmd, map[string]any{"keyName": "u1", "fields": []string{"name", "missing", "age"}}).Slice()
+	if got.Err() != nil || !reflect.DeepEqual(got.Val(), []any{"alice", nil, "30"}) {
+		t.Errorf("unexpected HMGet result: %v %v", got.Val(), got.Err())
+	}
+	deleted := client.HDel(ctx, HashFieldsCmd, map[string]any{"keyName": "u1", "fields": []string{"age", "city"}}).Int()
+	if deleted.Err() != nil || deleted.Val() != 2 {
+		t.Errorf("HDel expected 2, got %d %v", deleted.Val(), deleted.Err())
+	}
+	all := client.HGetAll(ctx, HashFieldsCmd, map[string]any{"keyName": "u1"}).MapStringString()
+	if all.Err() != nil || !reflect.DeepEqual(all.Val(), map[string]string{"name": "alice"}) {
+		t.Errorf("unexpected HGetAll result: %v %v", all.Val(), all.Err())
+	}
+
+	want := [][]string{
+		{"HSET", "hash:u1", "age", "30", "city", "sh", "name", "alice"},
+		{"HMGET", "hash:u1", "name", "missing", "age"},
+		{"HDEL", "hash:u1", "age", "city"},
+		{"HGETALL", "hash:u1"},
+	}
+	if cmds := fake.Commands(); !reflect.DeepEqual(cmds, want) {
+		t.Errorf("Expected %v, got %v", want, cmds)
+	}
+}
+
+// TestRedisClient_HScan 测试 HScan 的游标循环、MATCH/COUNT 参数以及字段和值的解析
+func TestRedisClient_HScan(t *testing.T) {
+	pages := map[string][]any{
+		"0": {"5", []any{"name", "alice", "age", "30"}},
+		"5": {"9", []any{}},
+		"9": {"0", []any{"age", "30", "city", "paris"}},
+	}
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "HSCAN" {
+			return pages[args[2]]
+		}
+		return nil
+	})
+	cmd := RdCmd{Key: "user:{{id}}"}
+
+	var entries []HashEntry
+	iter := client.HScan(context.Background(), cmd, map[string]any{"id": 1}, "*", 10)
+	for iter.Next() {
+		entries = append(entries, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("HScan failed: %v", err)
+	}
+	want := []HashEntry{{"name", "alice"}, {"age", "30"}, {"city", "paris"}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Expected %v, got %v", want, entries)
+	}
+	wantCmds := [][]string{
+		{"HSCAN", "user:1", "0", "MATCH", "*", "COUNT", "10"},
+		{"HSCAN", "user:1", "5", "MATCH", "*", "COUNT", "10"},
+		{"HSCAN", "user:1", "9", "MATCH", "*", "COUNT", "10"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, wantCmds) {
+		t.Errorf("Expected %v, got %v", wantCmds, got)
+	}
+}
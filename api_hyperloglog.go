@@ -0,0 +1,26 @@
+package rdb
+
+import (
+	"context"
+)
+
+// PFADD key [element [element ...]], 把元素添加到 HyperLogLog 中
+// 多个元素使用 slice 展开, 如: Params: "{{elements}}", args: {"elements": []string{"a", "b"}}
+// return 基数估算值有变化时返回 1, 否则返回 0, 使用 Int() 获取
+func (b builder) PFAdd(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, PFADD, args, includeArgs...)
+}
+
+// PFCOUNT key [key ...], 返回 HyperLogLog 的基数估算值, 多个 key 时返回它们并集的估算值
+// 多个 key 使用 NoUseKey 加 slice 展开, 如: {Params: "{{keys}}", NoUseKey: true}
+// return 使用 Int() 获取
+func (b builder) PFCount(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, PFCOUNT, args, includeArgs...)
+}
+
+// PFMERGE destkey [sourcekey [sourcekey ...]], 把多个 HyperLogLog 合并到 destkey
+// 如: {Params: "{{destination}} {{keys}}", NoUseKey: true}
+// return 使用 Status() 获取
+func (b builder) PFMerge(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, PFMERGE, args, includeArgs...)
+}
@@ -0,0 +1,56 @@
+package rdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// HyperLogLogCmd HyperLogLog 命令测试使用的命令定义
+var HyperLogLogCmd = RdCmd{
+	Key: "uv:{{day}}",
+	CMD: map[Command]RdSubCmd{
+		PFADD:   {Params: "{{elements}}"},
+		PFCOUNT: {Params: "{{keys}}", NoUseKey: true},
+		PFMERGE: {Params: "{{destination}} {{keys}}", NoUseKey: true},
+	},
+}
+
+// TestRedisClient_HyperLogLog 测试 PFADD 的元素展开以及 PFCOUNT/PFMERGE 的多个 key
+func TestRedisClient_HyperLogLog(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "PFADD":
+			return 1
+		case "PFCOUNT":
+			return 3
+		case "PFMERGE":
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+	ctx := context.Background()
+	keys := []string{"uv:20240101", "uv:20240102"}
+
+	added, err := client.PFAdd(ctx, HyperLogLogCmd, map[string]any{"day": "20240101", "elements": []string{"u1", "u2"}}).Int().Result()
+	if err != nil || added != 1 {
+		t.Fatalf("PFAdd: expected 1, got %d, %v", added, err)
+	}
+	count, err := client.PFCount(ctx, HyperLogLogCmd, map[string]any{"keys": keys}).Int().Result()
+	if err != nil || count != 3 {
+		t.Fatalf("PFCount: expected 3, got %d, %v", count, err)
+	}
+	status, err := client.PFMerge(ctx, HyperLogLogCmd, map[string]any{"destination": "uv:week", "keys": keys}).Status().Result()
+	if err != nil || status != "OK" {
+		t.Fatalf("PFMerge: expected OK, got %q, %v", status, err)
+	}
+
+	want := [][]string{
+		{"PFADD", "uv:20240101", "u1", "u2"},
+		{"PFCOUNT", "uv:20240101", "uv:20240102"},
+		{"PFMERGE", "uv:week", "uv:20240101", "uv:20240102"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
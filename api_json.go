@@ -0,0 +1,71 @@
+package rdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisJSON 模块的命令, 需要服务端加载了 RedisJSON(redis8 已经内置), 没有加载时返回 ErrModuleNotLoaded
+
+var jsonCmd = RdCmd{
+	Key: "{{key}}",
+	CMD: map[Command]RdSubCmd{
+		JSONSET: {Params: "{{path}} {{value|json}}"},
+		JSONGET: {Params: "{{paths}}", ReturnNilError: true},
+		JSONDEL: {Params: "{{path}}"},
+		// 多个值需要分别序列化, 通过 includeArgs 传入
+		JSONARRAPPEND: {Params: "{{path}}"},
+	},
+}
+
+// JSONSet JSON.SET key path value, v 使用 json.Marshal 序列化之后写入 key 的 path 位置, 根路径为 "$"
+// 已经是 json 的数据使用 json.RawMessage 传入, 不会被重复序列化
+func (rdm *RedisClient) JSONSet(ctx context.Context, key, path string, v any) error {
+	if err := rdm.requireModule(ctx, ModuleJSON); err != nil {
+		return err
+	}
+	args := map[string]any{"key": key, "path": path, "value": v}
+	return ExecuteCmd[*redis.StatusCmd](rdm, ctx, jsonCmd, JSONSET, args).Err()
+}
+
+// JSONGet JSON.GET key [path ...], 返回 json 字符串, 需要自己 json.Unmarshal
+// 不传 path 时返回整个文档; 使用 $ 开头的 JSONPath 时结果是数组; key 不存在时 Err() 为 redis.Nil
+func (rdm *RedisClient) JSONGet(ctx context.Context, key string, paths ...string) *redis.StringCmd {
+	if err := rdm.requireModule(ctx, ModuleJSON); err != nil {
+		cmd := redis.NewStringCmd(ctx, string(JSONGET), key)
+		cmd.SetErr(err)
+		return cmd
+	}
+	args := map[string]any{"key": key, "paths": paths}
+	return ExecuteCmd[*redis.StringCmd](rdm, ctx, jsonCmd, JSONGET, args)
+}
+
+// JSONDel JSON.DEL key path, 删除 key 中 path 位置的值, path 为 "$" 时删除整个 key
+// return 删除的值的数量
+func (rdm *RedisClient) JSONDel(ctx context.Context, key, path string) (int64, error) {
+	if err := rdm.requireModule(ctx, ModuleJSON); err != nil {
+		return 0, err
+	}
+	args := map[string]any{"key": key, "path": path}
+	return ExecuteCmd[*redis.IntCmd](rdm, ctx, jsonCmd, JSONDEL, args).Result()
+}
+
+// JSONArrAppend JSON.ARRAPPEND key path value [value ...], 每个值分别使用 json.Marshal 序列化之后追加到 path 位置的数组末尾
+// return 每个匹配的数组追加之后的长度
+func (rdm *RedisClient) JSONArrAppend(ctx context.Context, key, path string, values ...any) ([]int64, error) {
+	if err := rdm.requireModule(ctx, ModuleJSON); err != nil {
+		return nil, err
+	}
+	encoded := make([]any, len(values))
+	for i, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("rdb: JSON.ARRAPPEND marshal values[%d]: %w", i, err)
+		}
+		encoded[i] = string(data)
+	}
+	args := map[string]any{"key": key, "path": path}
+	return ExecuteCmd[*redis.IntSliceCmd](rdm, ctx, jsonCmd, JSONARRAPPEND, args, encoded...).Result()
+}
@@ -0,0 +1,133 @@
+package rdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestRedisClient_JSON 测试嵌套 json 文档写入指定 path 后可以完整读回, 以及删除 path
+func TestRedisClient_JSON(t *testing.T) {
+	var mu sync.Mutex
+	docs := map[string]string{} // key + path => json
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "MODULE":
+			return []any{[]any{"name", "ReJSON", "ver", 20809}}
+		case "JSON.SET":
+			docs[args[1]+args[2]] = args[3]
+			return fakeStatus("OK")
+		case "JSON.GET":
+			if doc, ok := docs[args[1]+args[2]]; ok {
+				return doc
+			}
+			return nil
+		case "JSON.DEL":
+			if _, ok := docs[args[1]+args[2]]; ok {
+				delete(docs, args[1]+args[2])
+				return 1
+			}
+			return 0
+		}
+		return nil
+	})
+	type address struct {
+		City  string   `json:"city"`
+		Zones []string `json:"zones"`
+	}
+	type profile struct {
+		Name    string         `json:"name"`
+		Address address        `json:"address"`
+		Meta    map[string]any `json:"meta"`
+	}
+	ctx := context.Background()
+	doc := profile{
+		Name:    "alice",
+		Address: address{City: "Hangzhou", Zones: []string{"west", "lake"}},
+		Meta:    map[string]any{"level": float64(3), "tags": []any{"vip"}},
+	}
+
+	if err := client.JSONSet(ctx, "user:1", "$.profile", doc); err != nil {
+		t.Fatalf("JSONSet failed: %v", err)
+	}
+	raw, err := client.JSONGet(ctx, "user:1", "$.profile").Result()
+	if err != nil {
+		t.Fatalf("JSONGet failed: %v", err)
+	}
+	var got profile
+	if err := json.Unmarshal([]byte(raw), &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, doc) {
+		t.Errorf("Expected %+v, got %+v", doc, got)
+	}
+
+	if n, err := client.JSONDel(ctx, "user:1", "$.profile"); err != nil || n != 1 {
+		t.Errorf("JSONDel failed: %d %v", n, err)
+	}
+	if err := client.JSONGet(ctx, "user:1", "$.profile").Err(); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil after delete, got %v", err)
+	}
+	if err := client.JSONSet(ctx, "user:1", "$", map[string]any{"ch": make(chan int)}); err == nil {
+		t.Errorf("Expected marshal error")
+	}
+	if got := fake.Commands()[0]; !reflect.DeepEqual(got, []string{"MODULE", "LIST"}) {
+		t.Errorf("Expected module detection first, got %v", got)
+	}
+}
+
+// TestRedisClient_JSON_ModuleNotLoaded 测试没有加载 RedisJSON 时返回 ErrModuleNotLoaded 且不发送命令
+func TestRedisClient_JSON_ModuleNotLoaded(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "MODULE" {
+			return []any{}
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if err := client.JSONSet(ctx, "user:1", "$", 1); !errors.Is(err, ErrModuleNotLoaded) {
+		t.Errorf("Expected ErrModuleNotLoaded, got %v", err)
+	}
+	if err := client.JSONGet(ctx, "user:1").Err(); !errors.Is(err, ErrModuleNotLoaded) {
+		t.Errorf("Expected ErrModuleNotLoaded, got %v", err)
+	}
+	if len(fake.Commands()) != 1 {
+		t.Errorf("Expected only MODULE LIST to be sent, got %v", fake.Commands())
+	}
+}
+
+// TestRedisClient_JSONArrAppend 测试每个值分别序列化成一个参数, 带点的命令名原样发送
+func TestRedisClient_JSONArrAppend(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "MODULE":
+			return []any{[]any{"name", "ReJSON", "ver", 20809}}
+		case "JSON.ARRAPPEND":
+			return []any{int64(len(args) - 3)}
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	lens, err := client.JSONArrAppend(ctx, "user:1", "$.tags", "vip", map[string]any{"level": 3}, json.RawMessage(`[1,2]`))
+	if err != nil {
+		t.Fatalf("JSONArrAppend failed: %v", err)
+	}
+	if !reflect.DeepEqual(lens, []int64{3}) {
+		t.Errorf("Expected [3], got %v", lens)
+	}
+	want := []string{"JSON.ARRAPPEND", "user:1", "$.tags", `"vip"`, `{"level":3}`, `[1,2]`}
+	if got := fake.Commands()[1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if _, err := client.JSONArrAppend(ctx, "user:1", "$.tags", make(chan int)); err == nil {
+		t.Errorf("Expected marshal error")
+	}
+}
@@ -2,8 +2,123 @@ package rdb
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"sync"
+	"time"
 )
 
+var (
+	// ErrNoExpire key 存在但是没有设置过期时间
+	ErrNoExpire = errors.New("rdb: key has no associated expire")
+	// ErrKeyNotExist key 不存在
+	ErrKeyNotExist = errors.New("rdb: key does not exist")
+)
+
+//	UNLINK key [key ...], 删除 key, 和 DEL 一样但是在后台线程中回收内存, 不会阻塞
+//
+// return 使用 Int() 获取被删除的 key 的数量; 多个 key 设置 NoUseKey, 通过 Params 的 slice 展开传入, 如: Params: "{{keys}}"
+func (b builder) Unlink(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, UNLINK, args, includeArgs...)
+}
+
+//	EXISTS key [key ...], 检查 key 是否存在, 多个 key 同 Unlink 传入, 重复的 key 会重复计数
+//
+// return 使用 Int() 获取存在的 key 的数量
+func (b builder) Exists(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, EXISTS, args, includeArgs...)
+}
+
+//	TOUCH key [key ...], 更新 key 的最后访问时间, 多个 key 同 Unlink 传入
+//
+// return 使用 Int() 获取存在的 key 的数量
+func (b builder) Touch(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, TOUCH, args, includeArgs...)
+}
+
+//	TYPE key, 返回 key 的类型 string/list/set/zset/hash/stream, 不存在时返回 none
+//
+// return 使用 Status() 获取
+func (b builder) Type(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, TYPE, args, includeArgs...)
+}
+
+//	RENAME key newkey, 把 key 改名为 newkey, newkey 已经存在时会被覆盖, key 不存在时返回错误, 如: Params: "{{newKey}}"
+//
+// return 使用 Status() 获取
+func (b builder) Rename(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, RENAME, args, includeArgs...)
+}
+
+//	RENAMENX key newkey, 只在 newkey 不存在时把 key 改名为 newkey
+//
+// return 使用 Bool() 获取, true 成功， false newkey 已经存在
+func (b builder) RenameNX(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, RENAMENX, args, includeArgs...)
+}
+
+//	COPY source destination [DB destination-db] [REPLACE], 把 key 复制到 destination, 从redis6.2开始支持
+//
+// source 使用外层的 key, cmd 中不需要定义 COPY; destination 原样使用, 不会加缓存版本前缀
+// db 小于 0 时复制到当前库; replace 为 true 时覆盖已经存在的 destination
+// return 使用 Bool() 获取, true 成功， false destination 已经存在或者 key 不存在
+func (b builder) Copy(ctx context.Context, cmd RdCmd, args map[string]any, destination string, db int, replace bool) *CommandBuilder {
+	copyArgs := []any{destination}
+	if db >= 0 {
+		copyArgs = append(copyArgs, "DB", db)
+	}
+	if replace {
+		copyArgs = append(copyArgs, "REPLACE")
+	}
+	return b(ctx, cmd.withCmd(COPY, RdSubCmd{}), COPY, args, copyArgs...)
+}
+
+//	DUMP key, 序列化 key 的值, 结果可以通过 RESTORE 写回
+//
+// return 二进制数据, 使用 Bytes() 获取, key 不存在时返回 redis.Nil
+func (b builder) Dump(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, DUMP, args, includeArgs...)
+}
+
+// RestoreOptions RESTORE 命令的可选参数
+type RestoreOptions struct {
+	Replace  bool          // REPLACE 覆盖已经存在的 key, 否则 key 存在时返回 BUSYKEY 错误
+	ExpireAt time.Time     // 非零时使用 ABSTTL 设置绝对过期时间, 忽略 ttl
+	IdleTime time.Duration // IDLETIME seconds, 0 表示不设置
+	Freq     int           // FREQ frequency, 0 表示不设置
+}
+
+// args 按照 RESTORE key ttl serialized-value [REPLACE] [ABSTTL] [IDLETIME seconds] [FREQ frequency] 的顺序构造 key 之后的参数
+func (opts RestoreOptions) args(ttl time.Duration, payload []byte) []any {
+	args := []any{ttl.Milliseconds(), payload}
+	if !opts.ExpireAt.IsZero() {
+		args[0] = opts.ExpireAt.UnixMilli()
+	}
+	if opts.Replace {
+		args = append(args, "REPLACE")
+	}
+	if !opts.ExpireAt.IsZero() {
+		args = append(args, "ABSTTL")
+	}
+	if opts.IdleTime > 0 {
+		args = append(args, "IDLETIME", int64(opts.IdleTime/time.Second))
+	}
+	if opts.Freq > 0 {
+		args = append(args, "FREQ", opts.Freq)
+	}
+	return args
+}
+
+//	RESTORE key ttl serialized-value [REPLACE] [ABSTTL] [IDLETIME seconds] [FREQ frequency], 用 DUMP 的结果创建 key
+//
+// key 使用外层的 key, cmd 中不需要定义 RESTORE; ttl 为 0 时不过期, 精度为毫秒; payload 原样发送, 不会被转换
+// return 使用 Status() 获取
+func (b builder) Restore(ctx context.Context, cmd RdCmd, args map[string]any, ttl time.Duration, payload []byte, opts RestoreOptions) *CommandBuilder {
+	return b(ctx, cmd.withCmd(RESTORE, RdSubCmd{}), RESTORE, args, opts.args(ttl, payload)...)
+}
+
 //	EXPIRE key seconds, 给指定key设置过期时间
 //
 // return int, 1 成功， 0 失败
@@ -11,9 +126,315 @@ func (b builder) Expire(ctx context.Context, cmd RdCmd, args map[string]any, inc
 	return b(ctx, cmd, EXPIRE, args, includeArgs...)
 }
 
+// ExpireCondition EXPIRE 的条件, 从redis7.0开始支持
+type ExpireCondition string
+
+const (
+	ExpireNX ExpireCondition = "NX" // 只在 key 没有过期时间时设置
+	ExpireXX ExpireCondition = "XX" // 只在 key 已经有过期时间时设置
+	ExpireGT ExpireCondition = "GT" // 只在新的过期时间大于当前过期时间时设置, 没有过期时间的 key 视为无限大
+	ExpireLT ExpireCondition = "LT" // 只在新的过期时间小于当前过期时间时设置, 没有过期时间的 key 视为无限大
+)
+
+//	EXPIRE key seconds [NX|XX|GT|LT], 按条件给指定key设置过期时间, cond 追加在参数的最后, 如只延长不缩短 session 的过期时间使用 ExpireGT
+//
+// return int, 1 成功， 0 key 不存在或者条件不满足
+func (b builder) ExpireWithCondition(ctx context.Context, cmd RdCmd, args map[string]any, cond ExpireCondition) *CommandBuilder {
+	return b(ctx, cmd, EXPIRE, args, string(cond))
+}
+
+// processExpire 发送 RdSubCmd.Exp 对应的 EXPIRE, 设置了条件时追加 NX/XX/GT/LT
+func processExpire(ctx context.Context, processor Processor, key string, exp time.Duration, cond ExpireCondition) *redis.BoolCmd {
+	if cond == "" {
+		return processor.Expire(ctx, key, exp)
+	}
+	seconds := int64(exp / time.Second)
+	if exp > 0 && exp < time.Second {
+		seconds = 1
+	}
+	cmd := redis.NewBoolCmd(ctx, "expire", key, seconds, string(cond))
+	_ = processor.Process(ctx, cmd)
+	return cmd
+}
+
 //	TTL key  查询指定key的过期时间
 //
-// return int, >=0 有过期时间， -1 存在且永久有效， -2 不存在或过期
+// return int, >=0 有过期时间， -1 存在且永久有效， -2 不存在或过期; 也可以使用 Duration() 获取 time.Duration
 func (b builder) Ttl(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, TTL, args, includeArgs...)
 }
+
+//	PEXPIRE key milliseconds, 同 EXPIRE, 过期时间以毫秒为单位
+//
+// return 使用 Bool() 获取, true 成功， false 失败(key 不存在)
+func (b builder) PExpire(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, PEXPIRE, args, includeArgs...)
+}
+
+//	PTTL key  以毫秒为单位查询指定key的剩余过期时间
+//
+// return 使用 Duration() 获取, >=0 剩余时间， -1ns 存在且永久有效， -2ns 不存在或过期; 也可以使用 Int() 获取毫秒数
+func (b builder) PTtl(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, PTTL, args, includeArgs...)
+}
+
+//	PERSIST key  移除指定key的过期时间, key 变为永久有效
+//
+// return 使用 Bool() 获取, true 成功， false key 不存在或者没有过期时间
+func (b builder) Persist(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, PERSIST, args, includeArgs...)
+}
+
+//	EXPIREAT key timestamp, 给指定key设置绝对的过期时间, t 会转换成 unix 秒追加到参数的最后
+//
+// return int, 1 成功， 0 失败(key 不存在)
+func (b builder) ExpireAt(ctx context.Context, cmd RdCmd, args map[string]any, t time.Time) *CommandBuilder {
+	return b(ctx, cmd, EXPIREAT, args, t.Unix())
+}
+
+//	PEXPIREAT key milliseconds-timestamp, 同 ExpireAt, t 会转换成 unix 毫秒追加到参数的最后
+//
+// return 使用 Bool() 获取, true 成功， false 失败(key 不存在)
+func (b builder) PExpireAt(ctx context.Context, cmd RdCmd, args map[string]any, t time.Time) *CommandBuilder {
+	return b(ctx, cmd, PEXPIREAT, args, t.UnixMilli())
+}
+
+//	EXPIRETIME key  查询指定key的绝对过期时间(unix 秒), 从redis7.0开始支持
+//
+// return int, >=0 过期时间戳， -1 存在且永久有效， -2 不存在
+func (b builder) ExpireTime(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, EXPIRETIME, args, includeArgs...)
+}
+
+//	OBJECT ENCODING key, 查询 key 的内部编码, 如 listpack、hashtable
+//
+// return 使用 String() 获取, key 不存在时返回 redis.Nil
+func (b builder) ObjectEncoding(ctx context.Context, cmd RdCmd, args map[string]any) *CommandBuilder {
+	return b(ctx, objectCmd(cmd, "ENCODING"), OBJECT, args)
+}
+
+//	OBJECT IDLETIME key, 查询 key 的空闲时间(秒), maxmemory-policy 是 LFU 时不可用
+//
+// return 使用 Int() 获取
+func (b builder) ObjectIdleTime(ctx context.Context, cmd RdCmd, args map[string]any) *CommandBuilder {
+	return b(ctx, objectCmd(cmd, "IDLETIME"), OBJECT, args)
+}
+
+//	OBJECT REFCOUNT key, 查询 key 对应值的引用计数
+//
+// return 使用 Int() 获取
+func (b builder) ObjectRefCount(ctx context.Context, cmd RdCmd, args map[string]any) *CommandBuilder {
+	return b(ctx, objectCmd(cmd, "REFCOUNT"), OBJECT, args)
+}
+
+//	OBJECT FREQ key, 查询 key 的访问频率计数, 只有 maxmemory-policy 是 LFU 时可用
+//
+// return 使用 Int() 获取
+func (b builder) ObjectFreq(ctx context.Context, cmd RdCmd, args map[string]any) *CommandBuilder {
+	return b(ctx, objectCmd(cmd, "FREQ"), OBJECT, args)
+}
+
+// objectCmd 构造 OBJECT 子命令, OBJECT 的 key 在子命令之后, 通过 {{key}} 放到子命令后面; cmd 中不需要定义 OBJECT
+func objectCmd(cmd RdCmd, sub string) RdCmd {
+	return cmd.withCmd(OBJECT, RdSubCmd{Params: sub + " {{key}}", NoUseKey: true})
+}
+
+// ExpireTimeAt 查询指定key的绝对过期时间并转换成 time.Time, 命令会直接执行
+// key 存在且永久有效时返回零值时间和 ErrNoExpire, key 不存在时返回零值时间和 ErrKeyNotExist
+func (rdm *RedisClient) ExpireTimeAt(ctx context.Context, cmd RdCmd, args map[string]any) (time.Time, error) {
+	unix, err := rdm.ExpireTime(ctx, cmd, args).Int().Result()
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch unix {
+	case -1:
+		return time.Time{}, ErrNoExpire
+	case -2:
+		return time.Time{}, ErrKeyNotExist
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// KeyInspection Inspect 返回的 key 信息
+type KeyInspection struct {
+	Type     string        // TYPE, 如 string/hash/list
+	TTL      time.Duration // PTTL 剩余过期时间, 没有设置过期时间时为 -1
+	Memory   int64         // MEMORY USAGE 占用的字节数
+	Encoding string        // OBJECT ENCODING, 如 listpack/hashtable
+}
+
+// Inspect 在一个 pipeline 中执行 TYPE、PTTL、MEMORY USAGE 和 OBJECT ENCODING, 汇总 key 的信息
+// key 会加上 KeyPrefix; key 不存在时返回 ErrKeyNotExist
+func (rdm *RedisClient) Inspect(ctx context.Context, key string) (KeyInspection, error) {
+	key = rdm.KeyPrefix + key
+	var typ *redis.StatusCmd
+	var ttl *redis.DurationCmd
+	var memory *redis.IntCmd
+	var encoding *redis.StringCmd
+	_, err := rdm.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		typ = pipe.Type(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+		memory = pipe.MemoryUsage(ctx, key)
+		encoding = pipe.ObjectEncoding(ctx, key)
+		return nil
+	})
+	if typ.Val() == "none" || errors.Is(err, redis.Nil) {
+		return KeyInspection{}, ErrKeyNotExist
+	}
+	if err != nil {
+		return KeyInspection{}, err
+	}
+	info := KeyInspection{
+		Type:     typ.Val(),
+		TTL:      ttl.Val(),
+		Memory:   memory.Val(),
+		Encoding: encoding.Val(),
+	}
+	if info.TTL < 0 {
+		info.TTL = -1
+	}
+	return info, nil
+}
+
+// KeyIterator ScanMatch 返回的 key 迭代器, 不是并发安全的
+//
+//	iter := client.ScanMatch(ctx, "user:*", 100)
+//	for iter.Next() {
+//		key := iter.Val()
+//	}
+//	if err := iter.Err(); err != nil {
+//		// 处理错误
+//	}
+type KeyIterator struct {
+	ctx     context.Context
+	pattern string
+	prefix  string // KeyPrefix, 返回的 key 去掉这个前缀
+	count   int64
+	nodes   []redis.Cmdable // 需要扫描的节点, 集群时是所有 master
+	iter    *redis.ScanIterator
+	seen    map[string]struct{} // 当前节点已经返回过的 key, SCAN 可能返回重复的 key
+	val     string
+	err     error
+}
+
+// ScanMatch 使用 SCAN cursor MATCH pattern COUNT count 遍历匹配的 key, 不会像 KEYS 一样阻塞 redis
+// 集群模式下会依次扫描所有 master 节点; 同一个节点内重复返回的 key 会被去掉
+// 遍历过程中新增或者删除的 key 是否会被返回是不确定的, 这是 SCAN 本身的语义
+// 设置了 KeyPrefix 时只遍历这个命名空间下的 key, 返回的 key 不带 KeyPrefix, 可以直接用于构造其他命令
+func (rdm *RedisClient) ScanMatch(ctx context.Context, pattern string, count int64) *KeyIterator {
+	it := &KeyIterator{ctx: ctx, pattern: prefixPattern(rdm.KeyPrefix, pattern), prefix: rdm.KeyPrefix, count: count}
+	cluster, ok := rdm.Client.(*redis.ClusterClient)
+	if !ok {
+		it.nodes = []redis.Cmdable{rdm.Client}
+		return it
+	}
+	var mu sync.Mutex
+	it.err = cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		it.nodes = append(it.nodes, master)
+		return nil
+	})
+	return it
+}
+
+// Next 移动到下一个 key, 没有更多的 key 或者出错时返回 false
+func (it *KeyIterator) Next() bool {
+	for it.err == nil {
+		if it.iter == nil {
+			if len(it.nodes) == 0 {
+				return false
+			}
+			it.iter = it.nodes[0].Scan(it.ctx, 0, it.pattern, it.count).Iterator()
+			it.nodes = it.nodes[1:]
+			it.seen = map[string]struct{}{}
+		}
+		if !it.iter.Next(it.ctx) {
+			it.err = it.iter.Err()
+			it.iter = nil
+			continue
+		}
+		key := it.iter.Val()
+		if _, ok := it.seen[key]; ok {
+			continue
+		}
+		it.seen[key] = struct{}{}
+		it.val = strings.TrimPrefix(key, it.prefix)
+		return true
+	}
+	return false
+}
+
+// Val 返回当前的 key
+func (it *KeyIterator) Val() string {
+	return it.val
+}
+
+// Err 返回遍历过程中出现的错误
+func (it *KeyIterator) Err() error {
+	return it.err
+}
+
+// scanPager HSCAN/SSCAN/ZSCAN 的游标循环, 每次返回 n 个元素(一个成员及其值), 同一个成员重复返回时会被去掉
+type scanPager struct {
+	fetch  func(cursor string) (string, []string, error)
+	cursor string
+	done   bool
+	items  []string
+	seen   map[string]struct{}
+	err    error
+}
+
+// newScanPager 使用 cmd 的 key 模板和 args 构造 key, 通过 builder 执行 cmdName key cursor [MATCH match] [COUNT count]
+func (rdm *RedisClient) newScanPager(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, match string, count int64) *scanPager {
+	scanCmd := cmd.withCmd(cmdName, RdSubCmd{})
+	fetch := func(cursor string) (string, []string, error) {
+		extra := []any{cursor}
+		if match != "" {
+			extra = append(extra, "MATCH", match)
+		}
+		if count > 0 {
+			extra = append(extra, "COUNT", count)
+		}
+		reply, err := rdm.builder(ctx, scanCmd, cmdName, args, extra...).Slice().Result()
+		if err != nil {
+			return "", nil, err
+		}
+		if len(reply) != 2 {
+			return "", nil, fmt.Errorf("rdb: unexpected %s reply: %v", cmdName, reply)
+		}
+		items, _ := reply[1].([]any)
+		vals := make([]string, len(items))
+		for i, item := range items {
+			vals[i] = fmt.Sprint(item)
+		}
+		return fmt.Sprint(reply[0]), vals, nil
+	}
+	return &scanPager{fetch: fetch, cursor: "0", seen: map[string]struct{}{}}
+}
+
+// next 返回下一组 n 个元素, 没有更多的元素或者出错时返回 nil
+func (p *scanPager) next(n int) []string {
+	for {
+		for len(p.items) >= n {
+			group := p.items[:n]
+			p.items = p.items[n:]
+			if _, ok := p.seen[group[0]]; ok {
+				continue
+			}
+			p.seen[group[0]] = struct{}{}
+			return group
+		}
+		if p.done || p.err != nil {
+			return nil
+		}
+		cursor, items, err := p.fetch(p.cursor)
+		if err != nil {
+			p.err = err
+			return nil
+		}
+		p.items = append(p.items, items...)
+		p.cursor = cursor
+		p.done = cursor == "0"
+	}
+}
@@ -0,0 +1,564 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试 Key 操作的 RdCmd 定义
+var KeysCmd = RdCmd{
+	Key: "keys:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		EXPIREAT:   {},
+		EXPIRETIME: {},
+	},
+}
+
+// TestRedisClient_ExpireAt 测试 EXPIREAT 设置的绝对过期时间可以通过 ExpireTimeAt 读回
+func TestRedisClient_ExpireAt(t *testing.T) {
+	var mu sync.Mutex
+	expires := map[string]int64{"keys:persist": -1}
+	client, _ := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "EXPIREAT":
+			ts, _ := strconv.ParseInt(args[2], 10, 64)
+			expires[args[1]] = ts
+			return 1
+		case "EXPIRETIME":
+			if ts, ok := expires[args[1]]; ok {
+				return ts
+			}
+			return -2
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	ok, err := client.ExpireAt(ctx, KeysCmd, map[string]any{"keyName": "session"}, at).Bool().Result()
+	if err != nil || !ok {
+		t.Fatalf("ExpireAt failed: %v %v", ok, err)
+	}
+
+	got, err := client.ExpireTimeAt(ctx, KeysCmd, map[string]any{"keyName": "session"})
+	if err != nil {
+		t.Fatalf("ExpireTimeAt failed: %v", err)
+	}
+	if !got.Equal(at) {
+		t.Errorf("Expected %v, got %v", at, got)
+	}
+
+	got, err = client.ExpireTimeAt(ctx, KeysCmd, map[string]any{"keyName": "persist"})
+	if !errors.Is(err, ErrNoExpire) || !got.IsZero() {
+		t.Errorf("Expected zero time and ErrNoExpire, got %v %v", got, err)
+	}
+	got, err = client.ExpireTimeAt(ctx, KeysCmd, map[string]any{"keyName": "missing"})
+	if !errors.Is(err, ErrKeyNotExist) || !got.IsZero() {
+		t.Errorf("Expected zero time and ErrKeyNotExist, got %v %v", got, err)
+	}
+}
+
+// TestRedisClient_ExpireCondition 测试 EXPIRE 的条件参数以及 Exp 自动设置过期时间时带上条件
+func TestRedisClient_ExpireCondition(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "EXPIRE":
+			return 1
+		case "GET":
+			return "v"
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "session:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			EXPIRE: {Params: "{{seconds}}"},
+			GET: {
+				Exp:          func() time.Duration { return 30 * time.Minute },
+				ExpCondition: ExpireGT,
+			},
+		},
+	}
+	ctx := context.Background()
+
+	ok, err := client.ExpireWithCondition(ctx, cmd, map[string]any{"id": 1, "seconds": 60}, ExpireNX).Bool().Result()
+	if err != nil || !ok {
+		t.Fatalf("ExpireWithCondition failed: %v %v", ok, err)
+	}
+	if err := client.Get(ctx, cmd, map[string]any{"id": 2}).String().Err(); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	pip := client.PipeLine()
+	pip.Get(ctx, cmd, map[string]any{"id": 3}).String()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+
+	want := [][]string{
+		{"EXPIRE", "session:1", "60", "NX"},
+		{"GET", "session:2"},
+		{"EXPIRE", "session:2", "1800", "GT"},
+		{"GET", "session:3"},
+		{"EXPIRE", "session:3", "1800", "GT"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_Inspect 测试一次 pipeline 汇总 hash key 的类型、过期时间、内存和编码
+func TestRedisClient_Inspect(t *testing.T) {
+	client, _ := newFakeClient(t, func(args []string) any {
+		exists := len(args) > 1 && args[len(args)-1] == "user:1"
+		switch args[0] {
+		case "TYPE":
+			if exists {
+				return fakeStatus("hash")
+			}
+			return fakeStatus("none")
+		case "PTTL":
+			if exists {
+				return 90000
+			}
+			return -2
+		case "MEMORY":
+			if exists {
+				return 128
+			}
+		case "OBJECT":
+			if exists {
+				return "listpack"
+			}
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	info, err := client.Inspect(ctx, "user:1")
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	want := KeyInspection{Type: "hash", TTL: 90 * time.Second, Memory: 128, Encoding: "listpack"}
+	if info != want {
+		t.Errorf("Expected %+v, got %+v", want, info)
+	}
+
+	if _, err := client.Inspect(ctx, "user:404"); !errors.Is(err, ErrKeyNotExist) {
+		t.Errorf("Expected ErrKeyNotExist, got %v", err)
+	}
+}
+
+// TestRedisClient_PExpireFamily 测试 PEXPIRE/PEXPIREAT/PTTL/PERSIST 的参数和结果类型
+func TestRedisClient_PExpireFamily(t *testing.T) {
+	var mu sync.Mutex
+	ttls := map[string]int64{"keys:a": -1} // key => 剩余毫秒, -1 永久有效
+	client, fake := newFakeClient(t, func(args []string) any {
+		if len(args) < 2 {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		ttl, exists := ttls[args[1]]
+		switch args[0] {
+		case "PEXPIRE", "PEXPIREAT":
+			if !exists {
+				return 0
+			}
+			ms, _ := strconv.ParseInt(args[2], 10, 64)
+			if args[0] == "PEXPIREAT" {
+				ms = 5000
+			}
+			ttls[args[1]] = ms
+			return 1
+		case "PTTL", "TTL":
+			if !exists {
+				return -2
+			}
+			if args[0] == "TTL" && ttl > 0 {
+				return ttl / 1000
+			}
+			return ttl
+		case "PERSIST":
+			if !exists || ttl == -1 {
+				return 0
+			}
+			ttls[args[1]] = -1
+			return 1
+		}
+		return nil
+	})
+	ctx := context.Background()
+	a := map[string]any{"keyName": "a"}
+	cmd := RdCmd{
+		Key: "keys:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			PEXPIRE: {Params: "{{ms}}"}, PEXPIREAT: {}, PTTL: {}, TTL: {}, PERSIST: {},
+		},
+	}
+
+	if ok, err := client.PExpire(ctx, cmd, map[string]any{"keyName": "a", "ms": 1500}).Bool().Result(); err != nil || !ok {
+		t.Fatalf("PExpire failed: %v %v", ok, err)
+	}
+	if d, err := client.PTtl(ctx, cmd, a).Duration().Result(); err != nil || d != 1500*time.Millisecond {
+		t.Errorf("Expected PTTL 1.5s, got %v %v", d, err)
+	}
+	if ok, err := client.Persist(ctx, cmd, a).Bool().Result(); err != nil || !ok {
+		t.Errorf("Persist failed: %v %v", ok, err)
+	}
+	if d := client.PTtl(ctx, cmd, a).Duration().Val(); d != -1 {
+		t.Errorf("Expected -1 after persist, got %v", d)
+	}
+	at := time.UnixMilli(1700000000123)
+	if ok, err := client.PExpireAt(ctx, cmd, a, at).Bool().Result(); err != nil || !ok {
+		t.Errorf("PExpireAt failed: %v %v", ok, err)
+	}
+	if d := client.Ttl(ctx, cmd, a).Duration().Val(); d != 5*time.Second {
+		t.Errorf("Expected TTL 5s parsed in seconds, got %v", d)
+	}
+	if ok := client.PExpire(ctx, cmd, map[string]any{"keyName": "missing", "ms": 10}).Bool().Val(); ok {
+		t.Errorf("Expected false for missing key")
+	}
+
+	got := fake.Commands()
+	if want := []string{"PEXPIREAT", "keys:a", "1700000000123"}; !reflect.DeepEqual(got[4], want) {
+		t.Errorf("Expected %v, got %v", want, got[4])
+	}
+}
+
+// TestRedisClient_KeyManagement 测试 DEL/UNLINK/EXISTS/TOUCH 的多 key 参数以及 TYPE/RENAME/RENAMENX 的结果类型
+func TestRedisClient_KeyManagement(t *testing.T) {
+	var mu sync.Mutex
+	keys := map[string]string{"keys:a": "string", "keys:b": "hash", "keys:c": "list"}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "DEL", "UNLINK", "EXISTS", "TOUCH":
+			n := 0
+			for _, key := range args[1:] {
+				if _, ok := keys[key]; ok {
+					n++
+					if args[0] == "DEL" || args[0] == "UNLINK" {
+						delete(keys, key)
+					}
+				}
+			}
+			return n
+		case "TYPE":
+			if typ, ok := keys[args[1]]; ok {
+				return fakeStatus(typ)
+			}
+			return fakeStatus("none")
+		case "RENAME", "RENAMENX":
+			typ, ok := keys[args[1]]
+			if !ok {
+				return errors.New("ERR no such key")
+			}
+			if _, exists := keys[args[2]]; exists && args[0] == "RENAMENX" {
+				return 0
+			}
+			delete(keys, args[1])
+			keys[args[2]] = typ
+			if args[0] == "RENAMENX" {
+				return 1
+			}
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+	multi := RdCmd{
+		CMD: map[Command]RdSubCmd{
+			DEL: {Params: "{{keys}}", NoUseKey: true}, UNLINK: {Params: "{{keys}}", NoUseKey: true},
+			EXISTS: {Params: "{{keys}}", NoUseKey: true}, TOUCH: {Params: "{{keys}}", NoUseKey: true},
+		},
+	}
+	single := RdCmd{
+		Key: "keys:{{keyName}}",
+		CMD: map[Command]RdSubCmd{TYPE: {}, RENAME: {Params: "{{newKey}}"}, RENAMENX: {Params: "{{newKey}}"}},
+	}
+	ctx := context.Background()
+	all := map[string]any{"keys": []string{"keys:a", "keys:b", "keys:x"}}
+
+	if n := client.Exists(ctx, multi, all).Int().Val(); n != 2 {
+		t.Errorf("Expected 2 existing keys, got %d", n)
+	}
+	if n := client.Touch(ctx, multi, all).Int().Val(); n != 2 {
+		t.Errorf("Expected 2 touched keys, got %d", n)
+	}
+	if typ := client.Type(ctx, single, map[string]any{"keyName": "b"}).Status().Val(); typ != "hash" {
+		t.Errorf("Expected hash, got %q", typ)
+	}
+	if ok, err := client.RenameNX(ctx, single, map[string]any{"keyName": "c", "newKey": "keys:a"}).Bool().Result(); err != nil || ok {
+		t.Errorf("Expected RENAMENX to fail on existing target, got %v %v", ok, err)
+	}
+	if err := client.Rename(ctx, single, map[string]any{"keyName": "c", "newKey": "keys:d"}).Status().Err(); err != nil {
+		t.Errorf("Rename failed: %v", err)
+	}
+	if err := client.Rename(ctx, single, map[string]any{"keyName": "c", "newKey": "keys:e"}).Status().Err(); err == nil {
+		t.Errorf("Expected error renaming missing key")
+	}
+	if n := client.Del(ctx, multi, map[string]any{"keys": []string{"keys:a"}}).Int().Val(); n != 1 {
+		t.Errorf("Expected 1 deleted key, got %d", n)
+	}
+	if n := client.Unlink(ctx, multi, map[string]any{"keys": []string{"keys:b", "keys:d"}}).Int().Val(); n != 2 {
+		t.Errorf("Expected 2 unlinked keys, got %d", n)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected all keys removed, got %v", keys)
+	}
+	if got := fake.Commands()[0]; !reflect.DeepEqual(got, []string{"EXISTS", "keys:a", "keys:b", "keys:x"}) {
+		t.Errorf("unexpected EXISTS args: %v", got)
+	}
+}
+
+// fakeScanPages 按页返回 SCAN 结果, cursor 是页的序号
+func fakeScanPages(args []string, pages [][]string) any {
+	cursor, _ := strconv.Atoi(args[1])
+	next := "0"
+	if cursor+1 < len(pages) {
+		next = strconv.Itoa(cursor + 1)
+	}
+	return []any{next, pages[cursor]}
+}
+
+// TestRedisClient_ScanMatch 测试游标循环以及同一个节点内重复 key 的去重
+func TestRedisClient_ScanMatch(t *testing.T) {
+	pages := [][]string{{"user:1", "user:2"}, {}, {"user:2", "user:3"}}
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "SCAN" {
+			return fakeScanPages(args, pages)
+		}
+		return nil
+	})
+
+	var keys []string
+	iter := client.ScanMatch(context.Background(), "user:*", 10)
+	for iter.Next() {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("ScanMatch failed: %v", err)
+	}
+	if want := []string{"user:1", "user:2", "user:3"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v, got %v", want, keys)
+	}
+	if got := fake.Commands()[0]; !reflect.DeepEqual(got, []string{"SCAN", "0", "match", "user:*", "count", "10"}) {
+		t.Errorf("unexpected SCAN args: %v", got)
+	}
+	if n := len(fake.Commands()); n != 3 {
+		t.Errorf("Expected 3 SCAN calls, got %d", n)
+	}
+}
+
+// TestRedisClient_ScanMatch_Cluster 测试集群模式下扫描所有 master 节点
+func TestRedisClient_ScanMatch_Cluster(t *testing.T) {
+	var addrs [2]string
+	slots := func() any {
+		res := []any{}
+		for i, addr := range addrs {
+			host, port, _ := net.SplitHostPort(addr)
+			p, _ := strconv.Atoi(port)
+			res = append(res, []any{i * 8192, i*8192 + 8191, []any{host, p, fmt.Sprintf("node-%d", i)}})
+		}
+		return res
+	}
+	nodes := [2]*fakeRedis{}
+	for i, pages := range [][][]string{{{"a:1", "a:2"}}, {{"b:1"}, {"b:2"}}} {
+		nodes[i] = newFakeRedis(t, func(args []string) any {
+			switch args[0] {
+			case "CLUSTER":
+				return slots()
+			case "SCAN":
+				return fakeScanPages(args, pages)
+			}
+			return nil
+		})
+		addrs[i] = nodes[i].ln.Addr().String()
+	}
+	client := NewRedisClusterClient(&redis.ClusterOptions{Addrs: addrs[:]})
+	defer client.RedisClose()
+
+	var keys []string
+	iter := client.ScanMatch(context.Background(), "*", 100)
+	for iter.Next() {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("ScanMatch failed: %v", err)
+	}
+	slices.Sort(keys)
+	if want := []string{"a:1", "a:2", "b:1", "b:2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v, got %v", want, keys)
+	}
+}
+
+// TestRedisClient_Object 测试 OBJECT 子命令在 key 之前, 并且 cmd 中不需要定义 OBJECT
+func TestRedisClient_Object(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] != "OBJECT" {
+			return nil
+		}
+		if args[1] == "ENCODING" {
+			return "listpack"
+		}
+		return 7
+	})
+	ctx := context.Background()
+	cmd := RdCmd{Key: "user:{{id}}"}
+	args := map[string]any{"id": 1}
+
+	encoding, err := client.ObjectEncoding(ctx, cmd, args).String().Result()
+	if err != nil || encoding != "listpack" {
+		t.Fatalf("ObjectEncoding: expected listpack, got %q, %v", encoding, err)
+	}
+	for _, run := range []func() *redis.IntCmd{
+		func() *redis.IntCmd { return client.ObjectIdleTime(ctx, cmd, args).Int() },
+		func() *redis.IntCmd { return client.ObjectRefCount(ctx, cmd, args).Int() },
+		func() *redis.IntCmd { return client.ObjectFreq(ctx, cmd, args).Int() },
+	} {
+		if n, err := run().Result(); err != nil || n != 7 {
+			t.Errorf("Expected 7, got %d, %v", n, err)
+		}
+	}
+	want := [][]string{
+		{"OBJECT", "ENCODING", "user:1"},
+		{"OBJECT", "IDLETIME", "user:1"},
+		{"OBJECT", "REFCOUNT", "user:1"},
+		{"OBJECT", "FREQ", "user:1"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_Copy 测试 COPY 的 DB 和 REPLACE 选项, source 使用外层 key
+func TestRedisClient_Copy(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "COPY" {
+			return 1
+		}
+		return nil
+	})
+	ctx := context.Background()
+	cmd := RdCmd{Key: "user:{{id}}"}
+	args := map[string]any{"id": 1}
+
+	ok, err := client.Copy(ctx, cmd, args, "user:1:bak", -1, false).Bool().Result()
+	if err != nil || !ok {
+		t.Fatalf("Copy: expected true, got %v, %v", ok, err)
+	}
+	if err := client.Copy(ctx, cmd, args, "user:1", 2, true).Bool().Err(); err != nil {
+		t.Fatalf("Copy with DB failed: %v", err)
+	}
+	want := [][]string{
+		{"COPY", "user:1", "user:1:bak"},
+		{"COPY", "user:1", "user:1", "DB", "2", "REPLACE"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_HashTagHelpers 测试从 cmd 派生命令的辅助方法保留 HashTag, 和 GET 构造出同一个 key
+func TestRedisClient_HashTagHelpers(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "COPY":
+			return 1
+		case "RESTORE":
+			return fakeStatus("OK")
+		case "OBJECT":
+			return "listpack"
+		case "HSCAN", "SSCAN", "ZSCAN":
+			return []any{"0", []any{}}
+		}
+		return nil
+	})
+	ctx := context.Background()
+	cmd := RdCmd{Key: "user:{{id}}:cart", HashTag: "id", CMD: map[Command]RdSubCmd{GET: {}}}
+	args := map[string]any{"id": 1}
+
+	client.Get(ctx, cmd, args).String()
+	client.Copy(ctx, cmd, args, "bak", -1, false).Bool()
+	client.Restore(ctx, cmd, args, 0, []byte("x"), RestoreOptions{}).Status()
+	client.ObjectEncoding(ctx, cmd, args).String()
+	for _, it := range []interface {
+		Next() bool
+		Err() error
+	}{
+		client.HScan(ctx, cmd, args, "", 0),
+		client.SScan(ctx, cmd, args, "", 0),
+		client.ZScan(ctx, cmd, args, "", 0),
+	} {
+		for it.Next() {
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+	}
+
+	want := [][]string{
+		{"GET", "user:{1}:cart"},
+		{"COPY", "user:{1}:cart", "bak"},
+		{"RESTORE", "user:{1}:cart", "0", "x"},
+		{"OBJECT", "ENCODING", "user:{1}:cart"},
+		{"HSCAN", "user:{1}:cart", "0"},
+		{"SSCAN", "user:{1}:cart", "0"},
+		{"ZSCAN", "user:{1}:cart", "0"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_DumpRestore 测试 DUMP 的二进制结果原样传给 RESTORE, 以及 RESTORE 的选项
+func TestRedisClient_DumpRestore(t *testing.T) {
+	payload := []byte("\x00\x03abc\r\n\xff\x0b\x00")
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "DUMP":
+			return payload
+		case "RESTORE":
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+	ctx := context.Background()
+	cmd := RdCmd{Key: "user:{{id}}", CMD: map[Command]RdSubCmd{DUMP: {}}}
+
+	dumped, err := client.Dump(ctx, cmd, map[string]any{"id": 1}).String().Bytes()
+	if err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	if !reflect.DeepEqual(dumped, payload) {
+		t.Fatalf("Expected %q, got %q", payload, dumped)
+	}
+	if err := client.Restore(ctx, cmd, map[string]any{"id": 2}, time.Minute, dumped, RestoreOptions{}).Status().Err(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	expireAt := time.UnixMilli(1700000000000)
+	opts := RestoreOptions{Replace: true, ExpireAt: expireAt, IdleTime: 30 * time.Second, Freq: 5}
+	if err := client.Restore(ctx, cmd, map[string]any{"id": 3}, time.Minute, dumped, opts).Status().Err(); err != nil {
+		t.Fatalf("Restore with options failed: %v", err)
+	}
+
+	want := [][]string{
+		{"DUMP", "user:1"},
+		{"RESTORE", "user:2", "60000", string(payload)},
+		{"RESTORE", "user:3", "1700000000000", string(payload), "REPLACE", "ABSTTL", "IDLETIME", "30", "FREQ", "5"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
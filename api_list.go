@@ -2,9 +2,11 @@ package rdb
 
 import (
 	"context"
+	"github.com/redis/go-redis/v9"
+	"time"
 )
 
-// LINDEX key index, 用于获取列表中指定索引位置上的元素
+// LINDEX key index, 用于获取列表中指定索引位置上的元素, 使用 String() 获取
 func (b builder) LIndex(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, LINDEX, args, includeArgs...)
 }
@@ -16,12 +18,13 @@ func (b builder) LInsert(ctx context.Context, cmd RdCmd, args map[string]any, in
 	return b(ctx, cmd, LINSERT, args, includeArgs...)
 }
 
-// LLEN mylist , 获取列表中元素数量
+// LLEN mylist , 获取列表中元素数量, 使用 Int() 获取
 func (b builder) LLen(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, LLEN, args, includeArgs...)
 }
 
 // LPUSH mylist value [value2 ...] , 将一个或多个值插入到列表头部, 如果 key 不存在，一个空列表会被创建并执行 LPUSH 操作
+// 多个值使用 slice 展开, 如: Params: "{{values}}", args: {"values": []string{"a", "b"}}, 返回列表的长度, 使用 Int() 获取
 func (b builder) LPush(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, LPUSH, args, includeArgs...)
 }
@@ -31,12 +34,44 @@ func (b builder) LPushx(ctx context.Context, cmd RdCmd, args map[string]any, inc
 	return b(ctx, cmd, LPUSHX, args, includeArgs...)
 }
 
-// LPOP mylist , 移出并获取列表的第一个元素
+// LPOP mylist , 移出并获取列表的第一个元素, 使用 String() 获取
+// 返回的类型由调用的结果方法决定, 而不是由参数决定: 不带 count 时使用 String(), 带 count 时 redis 返回数组, 需要使用 StringSlice()
 func (b builder) LPop(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, LPOP, args, includeArgs...)
 }
 
-// LRANGE mylist start stop, 获取列表指定范围内的元素
+// LPOP mylist count, 移出并获取列表的前 count 个元素, 从redis6.2开始支持, 使用 StringSlice() 获取
+// count 追加在 Params 之后, LPOP 的 Params 需要为空
+func (b builder) LPopCount(ctx context.Context, cmd RdCmd, args map[string]any, count int) *CommandBuilder {
+	return b(ctx, cmd, LPOP, args, count)
+}
+
+// LPOS key element [RANK rank] [MAXLEN len], 返回元素在列表中的下标, 从redis6.0.6开始支持
+// element 写在 Params 中, 如: Params: "{{element}}", opts 中的选项追加在 Params 之后; Rank 为负数时从列表尾部开始找
+// return 下标, 使用 Int() 获取; 没有找到时根据 ReturnNilError 决定是否返回 redis.Nil, 不返回时 Val() 为 0, 和下标 0 无法区分
+func (b builder) LPos(ctx context.Context, cmd RdCmd, args map[string]any, opts redis.LPosArgs) *CommandBuilder {
+	return b(ctx, cmd, LPOS, args, lposArgs(opts)...)
+}
+
+// LPOS key element [RANK rank] COUNT count [MAXLEN len], 返回前 count 个匹配元素的下标, count 为 0 时返回所有匹配的下标
+// return 下标列表, 没有找到时为空, 使用 IntSlice() 获取
+func (b builder) LPosCount(ctx context.Context, cmd RdCmd, args map[string]any, count int64, opts redis.LPosArgs) *CommandBuilder {
+	return b(ctx, cmd, LPOS, args, append(lposArgs(opts), "count", count)...)
+}
+
+// lposArgs 把 LPosArgs 转成 LPOS 的选项, 0 表示不带这个选项
+func lposArgs(opts redis.LPosArgs) []any {
+	var args []any
+	if opts.Rank != 0 {
+		args = append(args, "rank", opts.Rank)
+	}
+	if opts.MaxLen != 0 {
+		args = append(args, "maxlen", opts.MaxLen)
+	}
+	return args
+}
+
+// LRANGE mylist start stop, 获取列表指定范围内的元素, 使用 StringSlice() 获取
 // 其中 0 表示列表的第一个元素， 1 表示列表的第二个元素，以此类推。 你也可以使用负数下标，以 -1 表示列表的最后一个元素， -2 表示列表的倒数第二个元素，以此类推。
 func (b builder) LRange(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, LRANGE, args, includeArgs...)
@@ -47,7 +82,7 @@ func (b builder) LRange(ctx context.Context, cmd RdCmd, args map[string]any, inc
 // count > 0 : 从表头开始向表尾搜索，移除与 VALUE 相等的元素，数量为 COUNT 。
 // count < 0 : 从表尾开始向表头搜索，移除与 VALUE 相等的元素，数量为 COUNT 的绝对值。
 // count = 0 : 移除表中所有与 VALUE 相等的值。
-// return 被移除元素的数量。 列表不存在时返回 0 。
+// return 被移除元素的数量。 列表不存在时返回 0 。使用 Int() 获取
 func (b builder) LRem(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, LREM, args, includeArgs...)
 }
@@ -63,19 +98,27 @@ func (b builder) LTrim(ctx context.Context, cmd RdCmd, args map[string]any, incl
 	return b(ctx, cmd, LTRIM, args, includeArgs...)
 }
 
-// RPOP key, 移除列表的最后一个元素，返回值为移除的元素。
+// RPOP key, 移除列表的最后一个元素，返回值为移除的元素。使用 String() 获取, 带 count 时同 LPOP 使用 StringSlice()
 func (b builder) RPop(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, RPOP, args, includeArgs...)
 }
 
+// RPOP key count, 移除列表的最后 count 个元素, 从redis6.2开始支持, 使用 StringSlice() 获取
+// count 追加在 Params 之后, RPOP 的 Params 需要为空
+func (b builder) RPopCount(ctx context.Context, cmd RdCmd, args map[string]any, count int) *CommandBuilder {
+	return b(ctx, cmd, RPOP, args, count)
+}
+
 // RPOPLPUSH source target, 移除列表的最后一个元素，并将该元素添加到另一个列表并返回
-// return 返回这个元素
+// source 使用 cmd 的 key, target 写在 Params 中, 如: Params: "{{target}}"; source 和 target 可以是同一个列表, 用于循环队列
+// target 和 source 使用同样的命名空间, 同样加上缓存版本前缀和 KeyPrefix
+// return 返回这个元素, 使用 String() 获取, source 为空时根据 ReturnNilError 决定是否返回 redis.Nil; redis6.2 之后推荐使用 LMove
 func (b builder) RPopLPush(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, RPOPLPUSH, args, includeArgs...)
 }
 
-// RPUSH key value [value2 ...], 在列表中添加一个或多个值到列表尾部
-// return 执行 RPUSH 操作后，列表的长度。
+// RPUSH key value [value2 ...], 在列表中添加一个或多个值到列表尾部, 多个值同 LPUSH 使用 slice 展开
+// return 执行 RPUSH 操作后，列表的长度。使用 Int() 获取
 func (b builder) RPush(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, RPUSH, args, includeArgs...)
 }
@@ -85,3 +128,58 @@ func (b builder) RPush(ctx context.Context, cmd RdCmd, args map[string]any, incl
 func (b builder) RPushx(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, RPUSHX, args, includeArgs...)
 }
+
+// LMOVE source destination LEFT|RIGHT LEFT|RIGHT, 把 source 一端的元素移动到 destination 的一端, 从redis6.2开始支持
+// source 使用 cmd 的 key, destination 和方向按顺序写在 Params 中, 如: Params: "{{destination}} RIGHT LEFT"; RIGHT LEFT 等同于 RPOPLPUSH
+// destination 和 source 使用同样的命名空间, 同样加上缓存版本前缀和 KeyPrefix, Params 中只需要写不带前缀的 key
+// 可靠队列: 消费者用 RIGHT LEFT 把元素从队列取到 processing, 处理完成后从 processing 中 LREM, 阻塞等待使用 BLMove
+// return 被移动的元素, 使用 String() 获取, source 为空时根据 ReturnNilError 决定是否返回 redis.Nil
+func (b builder) LMove(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, LMOVE, args, includeArgs...)
+}
+
+// LMPOP numkeys key [key ...] LEFT|RIGHT [COUNT count], 从第一个非空的列表中弹出元素, 从redis7开始支持
+// 多个 key 同 SDIFF 使用 NoUseKey 加 slice 展开, numkeys 写在 Params 中, 如: {Params: "{{numkeys}} {{keys}} LEFT COUNT {{count}}", NoUseKey: true}
+// keys 会加上 KeyPrefix, 不加缓存版本前缀; 一个 key 时可以通过 {{key}} 使用 cmd 的 key, 如: {Params: "1 {{key}} LEFT", NoUseKey: true}, 同时加上缓存版本前缀
+// return 弹出元素的 key 和元素列表, 使用 KeyValues() 获取; 所有列表都为空时结果为空
+func (b builder) LMPop(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, LMPOP, args, includeArgs...)
+}
+
+// BLPOP key [key ...] timeout, 阻塞地从第一个非空的列表头部弹出一个元素
+// 一个 key 时使用 cmd 的 key, 如: Params: "{{timeout}}"; 多个 key 同 SDIFF 使用 NoUseKey 加 slice 展开, 如: {Params: "{{keys}} {{timeout}}", NoUseKey: true}, keys 会加上 KeyPrefix
+// timeout 是最后一个参数, 单位是秒, 可以是小数; 为 0 时一直阻塞, 直到 ctx 结束; ctx 的剩余时间比 timeout 短时直接返回错误, 不会发送命令
+// 直接执行时连接的读超时会按 timeout 延长, 这时只能使用 StringSlice() 获取结果; pipeline 中 go-redis 按 ReadTimeout 读取所有回复, timeout 需要比 ReadTimeout 短
+// return [key, value], 使用 StringSlice() 获取; 超时没有元素时结果为空, 不返回 redis.Nil
+func (b builder) BLPop(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BLPOP, args, includeArgs...)
+}
+
+// BRPOP key [key ...] timeout, 阻塞地从第一个非空的列表尾部弹出一个元素, 参数和读超时同 BLPOP
+// return [key, value], 使用 StringSlice() 获取
+func (b builder) BRPop(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BRPOP, args, includeArgs...)
+}
+
+// BLMOVE source destination LEFT|RIGHT LEFT|RIGHT timeout, 阻塞地把 source 的元素移动到 destination, 从redis6.2开始支持
+// source 使用 cmd 的 key, 其余参数同 LMove 写在 Params 中, 如: Params: "{{destination}} RIGHT LEFT {{timeout}}"; timeout 和读超时同 BLPOP
+// return 被移动的元素, 使用 String() 获取; 超时时结果为空
+func (b builder) BLMove(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BLMOVE, args, includeArgs...)
+}
+
+// ReliablePop 可靠队列的出队, 使用 BLMOVE src processing RIGHT LEFT 把元素从 src 队尾原子地移动到 processing,
+// 生产者使用 LPUSH 入队时按先进先出消费; 消费者崩溃时元素仍然保留在 processing 中, 可以由其他进程重新处理
+// 处理完成后需要调用返回的 ack, ack 通过 LREM processing 1 item 把元素从 processing 中删除
+// timeout 为 0 时一直阻塞, 超时没有元素时返回 redis.Nil; src 和 processing 都会加上 KeyPrefix
+func (rdm *RedisClient) ReliablePop(ctx context.Context, src, processing string, timeout time.Duration) (string, func(ctx context.Context) error, error) {
+	src, processing = rdm.KeyPrefix+src, rdm.KeyPrefix+processing
+	item, err := rdm.Client.BLMove(ctx, src, processing, "RIGHT", "LEFT", timeout).Result()
+	if err != nil {
+		return "", nil, err
+	}
+	ack := func(ctx context.Context) error {
+		return rdm.Client.LRem(ctx, processing, 1, item).Err()
+	}
+	return item, ack, nil
+}
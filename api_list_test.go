@@ -2,7 +2,12 @@ package rdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -535,3 +540,396 @@ func TestRedisClient_List_Integration(t *testing.T) {
 	fmt.Printf("5. Final list: %v\n", finalRange.Val())
 }
 
+
+// ListValuesCmd 多个值通过 slice 展开的列表命令
+var ListValuesCmd = RdCmd{
+	Key: "list:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		LPUSH:  {Params: "{{values}}"},
+		RPUSH:  {Params: "{{values}}"},
+		LPOP:   {},
+		RPOP:   {},
+		LLEN:   {},
+		LRANGE: {Params: "{{start}} {{stop}}"},
+	},
+}
+
+// TestRedisClient_ListPopCount 测试 slice 展开的 LPUSH/RPUSH, 以及带 count 的 LPOP/RPOP 返回 StringSlice
+func TestRedisClient_ListPopCount(t *testing.T) {
+	var mu sync.Mutex
+	var list []string
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "LPUSH":
+			for _, v := range args[2:] {
+				list = append([]string{v}, list...)
+			}
+			return len(list)
+		case "RPUSH":
+			list = append(list, args[2:]...)
+			return len(list)
+		case "LLEN":
+			return len(list)
+		case "LRANGE":
+			return append([]string{}, list...)
+		case "LPOP", "RPOP":
+			count := 1
+			if len(args) > 2 {
+				count, _ = strconv.Atoi(args[2])
+			}
+			count = min(count, len(list))
+			var popped []string
+			if args[0] == "LPOP" {
+				popped, list = list[:count], list[count:]
+			} else {
+				popped, list = list[len(list)-count:], list[:len(list)-count]
+				popped = append([]string{}, popped...)
+				for i, j := 0, len(popped)-1; i < j; i, j = i+1, j-1 {
+					popped[i], popped[j] = popped[j], popped[i]
+				}
+			}
+			if len(args) > 2 {
+				return popped
+			}
+			return popped[0]
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if n := client.RPush(ctx, ListValuesCmd, map[string]any{"keyName": "q", "values": []string{"c", "d", "e"}}).Int().Val(); n != 3 {
+		t.Errorf("RPush expected 3, got %d", n)
+	}
+	if n := client.LPush(ctx, ListValuesCmd, map[string]any{"keyName": "q", "values": []any{"b", "a"}}).Int().Val(); n != 5 {
+		t.Errorf("LPush expected 5, got %d", n)
+	}
+	if v := client.LPop(ctx, ListValuesCmd, map[string]any{"keyName": "q"}).String().Val(); v != "a" {
+		t.Errorf("LPop expected a, got %q", v)
+	}
+	if v := client.LPopCount(ctx, ListValuesCmd, map[string]any{"keyName": "q"}, 2).StringSlice().Val(); !reflect.DeepEqual(v, []string{"b", "c"}) {
+		t.Errorf("LPopCount expected [b c], got %v", v)
+	}
+	if v := client.RPopCount(ctx, ListValuesCmd, map[string]any{"keyName": "q"}, 5).StringSlice().Val(); !reflect.DeepEqual(v, []string{"e", "d"}) {
+		t.Errorf("RPopCount expected [e d], got %v", v)
+	}
+	if n := client.LLen(ctx, ListValuesCmd, map[string]any{"keyName": "q"}).Int().Val(); n != 0 {
+		t.Errorf("LLen expected 0, got %d", n)
+	}
+
+	want := [][]string{
+		{"RPUSH", "list:q", "c", "d", "e"},
+		{"LPUSH", "list:q", "b", "a"},
+		{"LPOP", "list:q"},
+		{"LPOP", "list:q", "2"},
+		{"RPOP", "list:q", "5"},
+		{"LLEN", "list:q"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_ReliablePop 测试出队后元素保留在 processing 中, ack 之后才删除
+func TestRedisClient_ReliablePop(t *testing.T) {
+	var mu sync.Mutex
+	lists := map[string][]string{"jobs": {"job-3", "job-2", "job-1"}}
+	client, _ := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "BLMOVE":
+			src := lists[args[1]]
+			if len(src) == 0 {
+				return nil
+			}
+			item := src[len(src)-1]
+			lists[args[1]] = src[:len(src)-1]
+			lists[args[2]] = append([]string{item}, lists[args[2]]...)
+			return item
+		case "LREM":
+			for i, v := range lists[args[1]] {
+				if v == args[3] {
+					lists[args[1]] = append(lists[args[1]][:i:i], lists[args[1]][i+1:]...)
+					return 1
+				}
+			}
+			return 0
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	item, ack, err := client.ReliablePop(ctx, "jobs", "jobs:processing", time.Second)
+	if err != nil || item != "job-1" {
+		t.Fatalf("Expected job-1, got %q %v", item, err)
+	}
+	if got := lists["jobs:processing"]; !reflect.DeepEqual(got, []string{"job-1"}) {
+		t.Errorf("Expected job-1 in processing, got %v", got)
+	}
+	if err := ack(ctx); err != nil {
+		t.Fatalf("ack failed: %v", err)
+	}
+	if got := lists["jobs:processing"]; len(got) != 0 {
+		t.Errorf("Expected empty processing after ack, got %v", got)
+	}
+
+	// 模拟消费者崩溃: 出队后不 ack, 元素仍保留在 processing 中
+	item, _, err = client.ReliablePop(ctx, "jobs", "jobs:processing", time.Second)
+	if err != nil || item != "job-2" {
+		t.Fatalf("Expected job-2, got %q %v", item, err)
+	}
+	if got := lists["jobs:processing"]; !reflect.DeepEqual(got, []string{"job-2"}) {
+		t.Errorf("Expected job-2 to remain in processing, got %v", got)
+	}
+	if got := lists["jobs"]; !reflect.DeepEqual(got, []string{"job-3"}) {
+		t.Errorf("Expected job-3 left in jobs, got %v", got)
+	}
+
+	if _, _, err := client.ReliablePop(ctx, "empty", "jobs:processing", time.Second); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil on empty queue, got %v", err)
+	}
+}
+
+// TestRedisClient_LMPop 测试 LMPOP 的 numkeys 和 COUNT 参数, 以及所有列表为空时不返回错误
+func TestRedisClient_LMPop(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] != "LMPOP" {
+			return nil
+		}
+		if args[2] == "queue:empty" {
+			return nil
+		}
+		return []any{"queue:high", []any{"a", "b"}}
+	})
+	ctx := context.Background()
+	cmd := RdCmd{
+		Key: "queue:{{name}}",
+		CMD: map[Command]RdSubCmd{
+			LMPOP: {Params: "{{numkeys}} {{keys}} LEFT COUNT {{count}}", NoUseKey: true},
+		},
+	}
+	singleCmd := RdCmd{
+		Key: "queue:{{name}}",
+		CMD: map[Command]RdSubCmd{
+			LMPOP: {Params: "1 {{key}} RIGHT", NoUseKey: true},
+		},
+	}
+
+	key, items, err := client.LMPop(ctx, cmd, map[string]any{"numkeys": 2, "keys": []string{"queue:high", "queue:low"}, "count": 2}).KeyValues().Result()
+	if err != nil {
+		t.Fatalf("LMPop failed: %v", err)
+	}
+	if key != "queue:high" || !reflect.DeepEqual(items, []string{"a", "b"}) {
+		t.Errorf("Expected queue:high [a b], got %s %v", key, items)
+	}
+	key, items, err = client.LMPop(ctx, singleCmd, map[string]any{"name": "empty"}).KeyValues().Result()
+	if err != nil || key != "" || len(items) != 0 {
+		t.Errorf("Expected empty result without error, got %q %v %v", key, items, err)
+	}
+
+	// 多个 key 只加 KeyPrefix, {{key}} 使用 cmd 的 key 时同时加上缓存版本前缀
+	client.SetCacheVersion("v1")
+	client.KeyPrefix = "t1:"
+	client.LMPop(ctx, cmd, map[string]any{"numkeys": 2, "keys": []string{"queue:high", "queue:low"}, "count": 1}).KeyValues()
+	client.LMPop(ctx, singleCmd, map[string]any{"name": "high"}).KeyValues()
+
+	want := [][]string{
+		{"LMPOP", "2", "queue:high", "queue:low", "LEFT", "COUNT", "2"},
+		{"LMPOP", "1", "queue:empty", "RIGHT"},
+		{"LMPOP", "2", "t1:queue:high", "t1:queue:low", "LEFT", "COUNT", "1"},
+		{"LMPOP", "1", "t1:v1:queue:high", "RIGHT"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_BLPop 测试阻塞命令的参数顺序、超时时不返回 redis.Nil, 以及读超时按阻塞时间延长
+func TestRedisClient_BLPop(t *testing.T) {
+	fake := newFakeRedis(t, func(args []string) any {
+		switch args[0] {
+		case "BLPOP":
+			if args[1] == "queue:empty" {
+				return nil
+			}
+			time.Sleep(150 * time.Millisecond) // 比 ReadTimeout 长, 比阻塞时间短
+			return []string{args[1], "job"}
+		case "BLMOVE":
+			return "job"
+		}
+		return nil
+	})
+	client := NewRedisClientWithOptions(&redis.Options{Addr: fake.ln.Addr().String(), ReadTimeout: 50 * time.Millisecond, MaxRetries: -1})
+	t.Cleanup(client.RedisClose)
+	fake.Reset()
+	ctx := context.Background()
+	queueCmd := RdCmd{
+		Key: "queue:{{name}}",
+		CMD: map[Command]RdSubCmd{
+			BLPOP:  {Params: "{{timeout}}"},
+			BRPOP:  {Params: "{{timeout}}"},
+			BLMOVE: {Params: "{{destination}} RIGHT LEFT {{timeout}}"},
+		},
+	}
+	multiCmd := RdCmd{
+		CMD: map[Command]RdSubCmd{
+			BLPOP: {Params: "{{keys}} {{timeout}}", NoUseKey: true},
+		},
+	}
+
+	got, err := client.BLPop(ctx, multiCmd, map[string]any{"keys": []string{"queue:a", "queue:b"}, "timeout": 1}).StringSlice().Result()
+	if err != nil {
+		t.Fatalf("BLPop failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"queue:a", "job"}) {
+		t.Errorf("Expected [queue:a job], got %v", got)
+	}
+	got, err = client.BRPop(ctx, queueCmd, map[string]any{"name": "empty", "timeout": 1.5}).StringSlice().Result()
+	if err != nil || len(got) != 0 {
+		t.Errorf("Expected empty result without error, got %v %v", got, err)
+	}
+	item, err := client.BLMove(ctx, queueCmd, map[string]any{"name": "a", "destination": "queue:doing", "timeout": 0}).String().Result()
+	if err != nil || item != "job" {
+		t.Errorf("BLMove: expected job, got %q, %v", item, err)
+	}
+
+	// 结果类型和 go-redis 的阻塞命令不一致时无法延长读超时, 直接返回错误, 不发送命令
+	if err := client.BLPop(ctx, queueCmd, map[string]any{"name": "a", "timeout": 1}).String().Err(); err == nil {
+		t.Errorf("Expected error for BLPOP read as String()")
+	}
+	if err := client.BLPop(ctx, queueCmd, map[string]any{"name": "a", "timeout": 1}).Err(); err == nil {
+		t.Errorf("Expected error for BLPOP without a result method")
+	}
+
+	// 一个 key 时使用 cmd 的 key, 加上缓存版本前缀和 KeyPrefix
+	client.SetCacheVersion("v1")
+	client.KeyPrefix = "t1:"
+	if got, err := client.BLPop(ctx, queueCmd, map[string]any{"name": "a", "timeout": 1}).StringSlice().Result(); err != nil || got[0] != "t1:v1:queue:a" {
+		t.Errorf("Expected t1:v1:queue:a, got %v %v", got, err)
+	}
+
+	want := [][]string{
+		{"BLPOP", "queue:a", "queue:b", "1"},
+		{"BRPOP", "queue:empty", "1.5"},
+		{"BLMOVE", "queue:a", "queue:doing", "RIGHT", "LEFT", "0"},
+		{"BLPOP", "t1:v1:queue:a", "1"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_BLPopContextDeadline 测试 ctx 的剩余时间比阻塞时间短时直接返回错误, 不发送命令
+func TestRedisClient_BLPopContextDeadline(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	cmd := RdCmd{
+		Key: "queue:{{name}}",
+		CMD: map[Command]RdSubCmd{
+			BLPOP: {Params: "{{timeout}}"},
+		},
+	}
+
+	if err := client.BLPop(ctx, cmd, map[string]any{"name": "a", "timeout": 1}).StringSlice().Err(); err == nil {
+		t.Errorf("Expected deadline error")
+	}
+	if got := fake.Commands(); len(got) != 0 {
+		t.Errorf("Expected no commands, got %v", got)
+	}
+}
+
+// TestRedisClient_LPos 测试 LPOS 的参数顺序为 key element 选项, 带 COUNT 时返回 IntSlice
+func TestRedisClient_LPos(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] != "LPOS" {
+			return nil
+		}
+		if args[2] == "missing" {
+			return nil
+		}
+		for _, arg := range args {
+			if arg == "count" {
+				return []any{int64(1), int64(4)}
+			}
+		}
+		return 4
+	})
+	ctx := context.Background()
+	cmd := RdCmd{Key: "list:{{id}}", CMD: map[Command]RdSubCmd{LPOS: {Params: "{{element}}", ReturnNilError: true}}}
+
+	idx, err := client.LPos(ctx, cmd, map[string]any{"id": 1, "element": "a"}, redis.LPosArgs{Rank: -1, MaxLen: 100}).Int().Result()
+	if err != nil || idx != 4 {
+		t.Fatalf("LPos: expected 4, got %d, %v", idx, err)
+	}
+	if _, err := client.LPos(ctx, cmd, map[string]any{"id": 1, "element": "missing"}, redis.LPosArgs{}).Int().Result(); !errors.Is(err, redis.Nil) {
+		t.Errorf("LPos: expected redis.Nil, got %v", err)
+	}
+	all, err := client.LPosCount(ctx, cmd, map[string]any{"id": 1, "element": "a"}, 0, redis.LPosArgs{Rank: 2}).IntSlice().Result()
+	if err != nil || !reflect.DeepEqual(all, []int64{1, 4}) {
+		t.Fatalf("LPosCount: expected [1 4], got %v, %v", all, err)
+	}
+
+	want := [][]string{
+		{"LPOS", "list:1", "a", "rank", "-1", "maxlen", "100"},
+		{"LPOS", "list:1", "missing"},
+		{"LPOS", "list:1", "a", "rank", "2", "count", "0"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_MoveCommands 测试 SMOVE、RPOPLPUSH、LMOVE 的 source/destination 顺序和返回类型, destination 和 source 使用同样的命名空间
+func TestRedisClient_MoveCommands(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SMOVE":
+			return 1
+		case "RPOPLPUSH", "LMOVE":
+			return "job-1"
+		}
+		return nil
+	})
+	ctx := context.Background()
+	client.SetCacheVersion("v1")
+	cmd := RdCmd{
+		Key: "queue:{{name}}",
+		CMD: map[Command]RdSubCmd{
+			SMOVE:     {Params: "{{destination}} {{member}}"},
+			RPOPLPUSH: {Params: "{{target}}"},
+			LMOVE:     {Params: "{{destination}} RIGHT LEFT"},
+		},
+	}
+
+	if ok, err := client.SMove(ctx, cmd, map[string]any{"name": "a", "destination": "b", "member": "m"}).Bool().Result(); err != nil || !ok {
+		t.Errorf("SMove expected true, got %v, %v", ok, err)
+	}
+	if v, err := client.RPopLPush(ctx, cmd, map[string]any{"name": "jobs", "target": "processing"}).String().Result(); err != nil || v != "job-1" {
+		t.Errorf("RPopLPush expected job-1, got %q, %v", v, err)
+	}
+	if v, err := client.LMove(ctx, cmd, map[string]any{"name": "jobs", "destination": "processing"}).String().Result(); err != nil || v != "job-1" {
+		t.Errorf("LMove expected job-1, got %q, %v", v, err)
+	}
+
+	want := [][]string{
+		{"SMOVE", "v1:queue:a", "v1:b", "m"},
+		{"RPOPLPUSH", "v1:queue:jobs", "v1:processing"},
+		{"LMOVE", "v1:queue:jobs", "v1:processing", "RIGHT", "LEFT"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+
+	// KeyPrefix 同样加在两个 key 上
+	fake.Reset()
+	client.KeyPrefix = "t1:"
+	client.LMove(ctx, cmd, map[string]any{"name": "jobs", "destination": "processing"}).String()
+	if got, want := fake.Commands(), [][]string{{"LMOVE", "t1:v1:queue:jobs", "t1:v1:processing", "RIGHT", "LEFT"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"sync"
 	"time"
 )
 
@@ -45,6 +46,8 @@ func (rdm RedisClient) EvalSha(ctx context.Context, lua string, keys []string, v
 	return cmd
 }
 
+// ExecScript 执行 lua 脚本, KEYS 按 lua.Keys 的顺序从 keyInfo 和 lua.Default 中取值, 并加上 KeyPrefix
+// KEYS 不加 CacheVersion 的前缀, 脚本中通过 KEYS 访问的 key 和客户端的其他命令在同一个命名空间
 func (rdm RedisClient) ExecScript(ctx context.Context, lua LuaScript, keyInfo map[string]string, valueInfo map[string]any) *redis.Cmd {
 	var defaultData map[string]any = make(map[string]any)
 	if len(lua.Default) > 0 {
@@ -65,7 +68,85 @@ func (rdm RedisClient) ExecScript(ctx context.Context, lua LuaScript, keyInfo ma
 		return &cmd
 	}
 
-	return rdm.EvalSha(ctx, lua.Script, keys, values)
+	return rdm.EvalSha(ctx, lua.Script, prefixKeys(rdm.KeyPrefix, keys), values)
+}
+
+// scriptCache 记录 LoadScript 加载过的脚本, SHA1 -> 脚本内容, 在客户端和 clone 之间共享
+// 主从切换之后新的主节点可能没有缓存脚本, 需要根据这里的内容重新加载
+type scriptCache struct {
+	mu      sync.RWMutex
+	scripts map[string]string
+}
+
+func (c *scriptCache) set(sha, src string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scripts == nil {
+		c.scripts = map[string]string{}
+	}
+	c.scripts[sha] = src
+}
+
+func (c *scriptCache) get(sha string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	src, ok := c.scripts[sha]
+	return src, ok
+}
+
+func (c *scriptCache) all() map[string]string {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	scripts := make(map[string]string, len(c.scripts))
+	for sha, src := range c.scripts {
+		scripts[sha] = src
+	}
+	return scripts
+}
+
+// LoadScript 使用 SCRIPT LOAD 把脚本缓存到 redis, 并在本地记录 SHA1 和脚本内容, 之后可以通过 EvalCached 执行
+// return 脚本的 SHA1 校验和
+func (rdm *RedisClient) LoadScript(ctx context.Context, src string) (string, error) {
+	sha, err := rdm.Client.ScriptLoad(ctx, src).Result()
+	if err != nil {
+		return "", err
+	}
+	if rdm.scripts != nil {
+		rdm.scripts.set(sha, src)
+	}
+	return sha, nil
+}
+
+// EvalCached 使用 EVALSHA 执行 LoadScript 加载过的脚本
+// redis 返回 NOSCRIPT 时(如: 主从切换之后新的主节点没有这个脚本), 使用本地记录的脚本内容改用 EVAL 执行, EVAL 同时会让 redis 重新缓存脚本
+// sha 没有通过 LoadScript 加载过时直接返回 EVALSHA 的结果; keys 和 ExecScript 一样加上 KeyPrefix
+func (rdm *RedisClient) EvalCached(ctx context.Context, sha string, keys []string, args ...any) *redis.Cmd {
+	keys = prefixKeys(rdm.KeyPrefix, keys)
+	cmd := rdm.Client.EvalSha(ctx, sha, keys, args...)
+	if cmd.Err() == nil || !redis.HasErrorPrefix(cmd.Err(), "NOSCRIPT") {
+		return cmd
+	}
+	src, ok := rdm.scripts.get(sha)
+	if !ok {
+		return cmd
+	}
+	return rdm.Client.Eval(ctx, src, keys, args...)
+}
+
+// ReloadScripts 把 LoadScript 加载过的脚本全部重新 SCRIPT LOAD 一次, 用于主从切换或者 SCRIPT FLUSH 之后预热
+func (rdm *RedisClient) ReloadScripts(ctx context.Context) error {
+	for sha, src := range rdm.scripts.all() {
+		if err := rdm.Client.ScriptLoad(ctx, src).Err(); err != nil {
+			return fmt.Errorf("rdb: reload script %s: %w", sha, err)
+		}
+	}
+	return nil
 }
 
 // PipeLine 的专属
@@ -91,6 +172,7 @@ func (rdm RedisPipeline) EvalSha(ctx context.Context, lua string, keys []string,
 	return cmd
 }
 
+// ExecScript 同 RedisClient.ExecScript, KEYS 加上创建 pipeline 的客户端的 KeyPrefix
 func (rdm RedisPipeline) ExecScript(ctx context.Context, lua LuaScript, keyInfo map[string]string, valueInfo map[string]any) *redis.Cmd {
 	var defaultData map[string]any = make(map[string]any)
 	if len(lua.Default) > 0 {
@@ -111,7 +193,7 @@ func (rdm RedisPipeline) ExecScript(ctx context.Context, lua LuaScript, keyInfo
 		return &cmd
 	}
 
-	return rdm.EvalSha(ctx, lua.Script, keys, values)
+	return rdm.EvalSha(ctx, lua.Script, prefixKeys(rdm.opts.keyPrefix, keys), values)
 }
 
 func handlerDefaultValue(data map[string]any) map[string]any {
@@ -138,3 +220,98 @@ func getValues[T string | any](keyNames []string, keyInfo map[string]T, defaultD
 	}
 	return keys, nil
 }
+
+// SWAP_KEYS 交换两个 key 的值, 两个都存在时用 DUMP/RESTORE 交叉写入(保留各自的过期时间), 只有一个存在时 RENAME 到另一个 key
+var SWAP_KEYS string = `
+	local a, b = KEYS[1], KEYS[2]
+	local va = redis.call("DUMP", a)
+	local vb = redis.call("DUMP", b)
+	if va and vb then
+		local ta = redis.call("PTTL", a)
+		local tb = redis.call("PTTL", b)
+		if ta < 0 then ta = 0 end
+		if tb < 0 then tb = 0 end
+		redis.call("RESTORE", a, tb, vb, "REPLACE")
+		redis.call("RESTORE", b, ta, va, "REPLACE")
+	elseif va then
+		redis.call("RENAME", a, b)
+	elseif vb then
+		redis.call("RENAME", b, a)
+	end
+	return 1`
+
+var swapScript = LuaScript{
+	Script: SWAP_KEYS,
+	Keys:   []string{"a", "b"},
+}
+
+// Swap 原子地交换 a、b 两个 key 的值, 支持任意类型, 过期时间跟随值一起交换
+// 只有一个 key 存在时相当于把它改名为另一个 key, 都不存在时什么也不做; 集群模式下两个 key 需要在同一个 slot
+func (rdm *RedisClient) Swap(ctx context.Context, a, b string) error {
+	return rdm.ExecScript(ctx, swapScript, map[string]string{"a": a, "b": b}, nil).Err()
+}
+
+// DECR_AND_MAYBE_DELETE 引用计数减一, 结果小于等于 0 时删除 key, 返回 {新的计数, 是否删除}
+var DECR_AND_MAYBE_DELETE string = `
+	local n = redis.call("DECR", KEYS[1])
+	if n <= 0 then
+		redis.call("DEL", KEYS[1])
+		return {n, 1}
+	end
+	return {n, 0}`
+
+var decrAndMaybeDeleteScript = LuaScript{
+	Script: DECR_AND_MAYBE_DELETE,
+	Keys:   []string{"key"},
+}
+
+// DecrAndMaybeDelete 原子地把 key 的引用计数减一, 减到 0 或以下时删除 key
+// return 减一之后的计数以及 key 是否被删除; key 不存在时按 0 处理, 返回 -1 并删除
+func (rdm *RedisClient) DecrAndMaybeDelete(ctx context.Context, key string) (int64, bool, error) {
+	res, err := rdm.ExecScript(ctx, decrAndMaybeDeleteScript, map[string]string{"key": key}, nil).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	if len(res) != 2 {
+		return 0, false, fmt.Errorf("rdb: unexpected DecrAndMaybeDelete reply: %v", res)
+	}
+	return res[0], res[1] == 1, nil
+}
+
+// COMPARE_AND_PEXPIRE key 的值等于 token 时重新设置过期时间(毫秒), 返回 1; 否则返回 0, 用于锁和选主的续期
+var COMPARE_AND_PEXPIRE string = `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	return 0`
+
+var compareAndPExpireScript = LuaScript{
+	Script: COMPARE_AND_PEXPIRE,
+	Keys:   []string{"key"},
+	Args:   []string{"token", "ttl"},
+}
+
+// COMPARE_AND_DELETE key 的值等于 token 时删除 key, 返回 1; 否则返回 0, 用于释放锁, 避免删除别人持有的锁
+var COMPARE_AND_DELETE string = `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0`
+
+var compareAndDeleteScript = LuaScript{
+	Script: COMPARE_AND_DELETE,
+	Keys:   []string{"key"},
+	Args:   []string{"token"},
+}
+
+// compareAndPExpire key 的值等于 token 时把过期时间设置为 ttl, 返回是否设置成功
+func (rdm *RedisClient) compareAndPExpire(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	n, err := rdm.ExecScript(ctx, compareAndPExpireScript, map[string]string{"key": key}, map[string]any{"token": token, "ttl": ttl.Milliseconds()}).Int()
+	return n == 1, err
+}
+
+// compareAndDelete key 的值等于 token 时删除 key, 返回是否删除
+func (rdm *RedisClient) compareAndDelete(ctx context.Context, key, token string) (bool, error) {
+	n, err := rdm.ExecScript(ctx, compareAndDeleteScript, map[string]string{"key": key}, map[string]any{"token": token}).Int()
+	return n == 1, err
+}
@@ -3,7 +3,10 @@ package rdb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -92,3 +95,122 @@ var SAVE_DISCONT_USER_LIST string = `
 	-- 获取当前分数的 数据
 	local resData = redis.call("ZRANGEBYSCORE", redisK, user_ids_data[1], user_ids_data[1]+size)
 	return resData`
+
+// TestRedisClient_Swap 测试两个 key 都存在、只有一个存在以及都不存在时的交换
+func TestRedisClient_Swap(t *testing.T) {
+	client := InitRedis()
+	ctx := context.Background()
+	a, b := "swap:{test}:a", "swap:{test}:b"
+	client.Client.Del(ctx, a, b)
+
+	client.Client.Set(ctx, a, "blue", 0)
+	client.Client.Set(ctx, b, "green", time.Minute)
+	if err := client.Swap(ctx, a, b); err != nil {
+		t.Fatalf("Swap failed: %v", err)
+	}
+	if got := client.Client.Get(ctx, a).Val(); got != "green" {
+		t.Errorf("Expected a=green, got %q", got)
+	}
+	if got := client.Client.Get(ctx, b).Val(); got != "blue" {
+		t.Errorf("Expected b=blue, got %q", got)
+	}
+	if ttl := client.Client.TTL(ctx, a).Val(); ttl <= 0 {
+		t.Errorf("Expected ttl to move with the value, got %v", ttl)
+	}
+
+	client.Client.Del(ctx, b)
+	if err := client.Swap(ctx, a, b); err != nil {
+		t.Fatalf("Swap with one missing failed: %v", err)
+	}
+	if n := client.Client.Exists(ctx, a).Val(); n != 0 {
+		t.Errorf("Expected a to be missing after swap")
+	}
+	if got := client.Client.Get(ctx, b).Val(); got != "green" {
+		t.Errorf("Expected b=green, got %q", got)
+	}
+
+	client.Client.Del(ctx, a, b)
+	if err := client.Swap(ctx, a, b); err != nil {
+		t.Fatalf("Swap with both missing failed: %v", err)
+	}
+	if n := client.Client.Exists(ctx, a, b).Val(); n != 0 {
+		t.Errorf("Expected both keys to stay missing, got %d", n)
+	}
+}
+
+// TestRedisClient_DecrAndMaybeDelete 测试计数大于 0 时保留 key, 减到 0 时删除 key
+func TestRedisClient_DecrAndMaybeDelete(t *testing.T) {
+	client := InitRedis()
+	ctx := context.Background()
+	key := "refcount:test"
+	client.Client.Set(ctx, key, 2, 0)
+
+	n, deleted, err := client.DecrAndMaybeDelete(ctx, key)
+	if err != nil || n != 1 || deleted {
+		t.Fatalf("Expected 1 false, got %d %v %v", n, deleted, err)
+	}
+	if client.Client.Exists(ctx, key).Val() != 1 {
+		t.Errorf("Expected key to remain")
+	}
+
+	n, deleted, err = client.DecrAndMaybeDelete(ctx, key)
+	if err != nil || n != 0 || !deleted {
+		t.Fatalf("Expected 0 true, got %d %v %v", n, deleted, err)
+	}
+	if client.Client.Exists(ctx, key).Val() != 0 {
+		t.Errorf("Expected key to be deleted")
+	}
+}
+
+// TestRedisClient_EvalCached 测试 LoadScript 记录脚本, EvalCached 遇到 NOSCRIPT 时改用 EVAL
+func TestRedisClient_EvalCached(t *testing.T) {
+	src := "return ARGV[1]"
+	sha := sha1String(src)
+	var flushed bool
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SCRIPT":
+			return sha
+		case "EVALSHA":
+			if flushed {
+				return errors.New("NOSCRIPT No matching script. Please use EVAL.")
+			}
+			return args[len(args)-1]
+		case "EVAL":
+			return args[len(args)-1]
+		}
+		return fakeStatus("OK")
+	})
+	ctx := context.Background()
+
+	got, err := client.LoadScript(ctx, src)
+	if err != nil || got != sha {
+		t.Fatalf("LoadScript: expected %s, got %s, %v", sha, got, err)
+	}
+	if val, err := client.EvalCached(ctx, sha, []string{"k"}, "a").Text(); err != nil || val != "a" {
+		t.Fatalf("EvalCached: expected a, got %q, %v", val, err)
+	}
+
+	flushed = true
+	if val, err := client.EvalCached(ctx, sha, []string{"k"}, "b").Text(); err != nil || val != "b" {
+		t.Fatalf("EvalCached after NOSCRIPT: expected b, got %q, %v", val, err)
+	}
+	if err := client.EvalCached(ctx, sha1String("unknown"), nil).Err(); err == nil || !strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		t.Errorf("Expected NOSCRIPT for unknown script, got %v", err)
+	}
+	if err := client.ReloadScripts(ctx); err != nil {
+		t.Fatalf("ReloadScripts failed: %v", err)
+	}
+
+	want := [][]string{
+		{"SCRIPT", "load", src},
+		{"EVALSHA", sha, "1", "k", "a"},
+		{"EVALSHA", sha, "1", "k", "b"},
+		{"EVAL", src, "1", "k", "b"},
+		{"EVALSHA", sha1String("unknown"), "0"},
+		{"SCRIPT", "load", src},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,163 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+)
+
+// RediSearch 模块的命令, 需要服务端加载了 RediSearch(redis8 已经内置), 没有加载时返回 ErrModuleNotLoaded
+
+var ftSearchCmd = RdCmd{
+	CMD: map[Command]RdSubCmd{
+		FTSEARCH: {Params: "{{index}} {{query}}", NoUseKey: true},
+	},
+}
+
+// SearchOptions FT.SEARCH 的可选参数, 零值的字段不会发送
+type SearchOptions struct {
+	NoContent bool     // NOCONTENT 只返回文档 id
+	Return    []string // RETURN num field ..., 只返回指定的字段
+	SortBy    string   // SORTBY field, 字段需要在索引中声明为 SORTABLE
+	SortDesc  bool     // 设置了 SortBy 时按降序排列
+	Offset    int      // LIMIT offset num, Limit 大于 0 时才会发送
+	Limit     int
+}
+
+// args 按照 FT.SEARCH index query [NOCONTENT] [RETURN ...] [SORTBY ...] [LIMIT ...] 的顺序构造 query 之后的参数
+func (opts SearchOptions) args() []any {
+	var args []any
+	if opts.NoContent {
+		args = append(args, "NOCONTENT")
+	}
+	if len(opts.Return) > 0 {
+		args = append(args, "RETURN", strconv.Itoa(len(opts.Return)))
+		for _, field := range opts.Return {
+			args = append(args, field)
+		}
+	}
+	if opts.SortBy != "" {
+		order := "ASC"
+		if opts.SortDesc {
+			order = "DESC"
+		}
+		args = append(args, "SORTBY", opts.SortBy, order)
+	}
+	if opts.Limit > 0 {
+		args = append(args, "LIMIT", strconv.Itoa(opts.Offset), strconv.Itoa(opts.Limit))
+	}
+	return args
+}
+
+// SearchDocument FT.SEARCH 返回的一个文档
+type SearchDocument struct {
+	ID     string
+	Fields map[string]string // NOCONTENT 时为 nil
+}
+
+// SearchResult FT.SEARCH 的结果
+type SearchResult struct {
+	Total int64 // 匹配的文档总数, 不受 LIMIT 影响
+	Docs  []SearchDocument
+}
+
+// FTSearch FT.SEARCH index query, 按照 opts 组装参数并把回复解析成 SearchResult
+// 同时支持 RESP2 的 [total, id, [field, value, ...], ...] 和 RESP3 的 map 格式的回复
+func (rdm *RedisClient) FTSearch(ctx context.Context, index, query string, opts SearchOptions) (SearchResult, error) {
+	if err := rdm.requireModule(ctx, ModuleSearch); err != nil {
+		return SearchResult{}, err
+	}
+	args := map[string]any{"index": index, "query": query}
+	reply, err := ExecuteCmd[*redis.Cmd](rdm, ctx, ftSearchCmd, FTSEARCH, args, opts.args()...).Result()
+	if err != nil {
+		return SearchResult{}, err
+	}
+	return parseSearchReply(reply)
+}
+
+// FTSearchRaw FT.SEARCH index query [option ...], 不解析回复, SearchOptions 不支持的参数(PARAMS、HIGHLIGHT 等)通过 options 原样传入
+// query 作为一个参数发送, 其中的空格、引号不会被拆分或转义; options 中的每个元素都是一个独立的参数, 如:
+//
+//	client.FTSearchRaw(ctx, "idx", "@name:$name", "PARAMS", 2, "name", "alice", "DIALECT", 2)
+func (rdm *RedisClient) FTSearchRaw(ctx context.Context, index, query string, options ...any) *redis.Cmd {
+	if err := rdm.requireModule(ctx, ModuleSearch); err != nil {
+		cmd := redis.NewCmd(ctx, string(FTSEARCH), index, query)
+		cmd.SetErr(err)
+		return cmd
+	}
+	args := map[string]any{"index": index, "query": query}
+	return ExecuteCmd[*redis.Cmd](rdm, ctx, ftSearchCmd, FTSEARCH, args, options...)
+}
+
+// parseSearchReply 解析 FT.SEARCH 的回复
+func parseSearchReply(reply any) (SearchResult, error) {
+	switch v := reply.(type) {
+	case []any:
+		return parseSearchSlice(v)
+	case map[any]any:
+		return parseSearchMap(v)
+	}
+	return SearchResult{}, fmt.Errorf("rdb: unexpected FT.SEARCH reply %T", reply)
+}
+
+// parseSearchSlice 解析 RESP2 格式: [total, id1, [field, value, ...], id2, ...], NOCONTENT 时没有字段数组
+func parseSearchSlice(vals []any) (SearchResult, error) {
+	if len(vals) == 0 {
+		return SearchResult{}, fmt.Errorf("rdb: empty FT.SEARCH reply")
+	}
+	total, ok := vals[0].(int64)
+	if !ok {
+		return SearchResult{}, fmt.Errorf("rdb: unexpected FT.SEARCH total %T", vals[0])
+	}
+	result := SearchResult{Total: total}
+	for i := 1; i < len(vals); i++ {
+		id, err := decodeString(vals[i])
+		if err != nil {
+			return SearchResult{}, err
+		}
+		doc := SearchDocument{ID: id}
+		if i+1 < len(vals) {
+			if fields, ok := vals[i+1].([]any); ok {
+				if doc.Fields, err = decodeFieldPairs(fields); err != nil {
+					return SearchResult{}, err
+				}
+				i++
+			}
+		}
+		result.Docs = append(result.Docs, doc)
+	}
+	return result, nil
+}
+
+// parseSearchMap 解析 RESP3 格式: {total_results: n, results: [{id: xx, extra_attributes: {field: value}}, ...]}
+func parseSearchMap(vals map[any]any) (SearchResult, error) {
+	total, _ := vals["total_results"].(int64)
+	result := SearchResult{Total: total}
+	docs, _ := vals["results"].([]any)
+	for _, item := range docs {
+		m, ok := item.(map[any]any)
+		if !ok {
+			return SearchResult{}, fmt.Errorf("rdb: unexpected FT.SEARCH result %T", item)
+		}
+		id, err := decodeString(m["id"])
+		if err != nil {
+			return SearchResult{}, err
+		}
+		doc := SearchDocument{ID: id}
+		if attrs, ok := m["extra_attributes"].(map[any]any); ok {
+			doc.Fields = make(map[string]string, len(attrs))
+			for k, v := range attrs {
+				key, err := decodeString(k)
+				if err != nil {
+					return SearchResult{}, err
+				}
+				if doc.Fields[key], err = decodeString(v); err != nil {
+					return SearchResult{}, err
+				}
+			}
+		}
+		result.Docs = append(result.Docs, doc)
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package rdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestRedisClient_FTSearch 测试 FT.SEARCH 的参数构造以及 RESP2 回复的解析
+func TestRedisClient_FTSearch(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "MODULE":
+			return []any{[]any{"name", "search", "ver", 21005}}
+		case "FT.SEARCH":
+			return []any{
+				int64(12),
+				"product:7", []any{"title", "red shoes", "price", "59"},
+				"product:3", []any{"title", "red hat", "price", "19"},
+			}
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	res, err := client.FTSearch(ctx, "idx:products", "@color:{red} shoes|hat", SearchOptions{
+		Return: []string{"title", "price"},
+		SortBy: "price", SortDesc: true,
+		Offset: 0, Limit: 2,
+	})
+	if err != nil {
+		t.Fatalf("FTSearch failed: %v", err)
+	}
+	want := SearchResult{
+		Total: 12,
+		Docs: []SearchDocument{
+			{ID: "product:7", Fields: map[string]string{"title": "red shoes", "price": "59"}},
+			{ID: "product:3", Fields: map[string]string{"title": "red hat", "price": "19"}},
+		},
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("Expected %+v, got %+v", want, res)
+	}
+	wantArgs := []string{"FT.SEARCH", "idx:products", "@color:{red} shoes|hat", "RETURN", "2", "title", "price", "SORTBY", "price", "DESC", "LIMIT", "0", "2"}
+	if got := fake.Commands()[1]; !reflect.DeepEqual(got, wantArgs) {
+		t.Errorf("Expected %v, got %v", wantArgs, got)
+	}
+}
+
+// Test_parseSearchReply 测试 NOCONTENT 以及 RESP3 格式的回复解析
+func Test_parseSearchReply(t *testing.T) {
+	res, err := parseSearchReply([]any{int64(2), "doc:1", "doc:2"})
+	if err != nil {
+		t.Fatalf("parse NOCONTENT reply failed: %v", err)
+	}
+	if want := (SearchResult{Total: 2, Docs: []SearchDocument{{ID: "doc:1"}, {ID: "doc:2"}}}); !reflect.DeepEqual(res, want) {
+		t.Errorf("Expected %+v, got %+v", want, res)
+	}
+
+	res, err = parseSearchReply(map[any]any{
+		"total_results": int64(1),
+		"results": []any{
+			map[any]any{"id": "doc:1", "extra_attributes": map[any]any{"title": "hello", "views": int64(3)}, "values": []any{}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parse RESP3 reply failed: %v", err)
+	}
+	want := SearchResult{Total: 1, Docs: []SearchDocument{{ID: "doc:1", Fields: map[string]string{"title": "hello", "views": "3"}}}}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("Expected %+v, got %+v", want, res)
+	}
+
+	if _, err := parseSearchReply("OK"); err == nil {
+		t.Errorf("Expected error for unexpected reply")
+	}
+}
+
+// TestRedisClient_FTSearchRaw 测试 query 中的空格、引号和花括号原样作为一个参数发送, options 中的每个元素是独立的参数
+func TestRedisClient_FTSearchRaw(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "MODULE":
+			return []any{[]any{"name", "search", "ver", 21005}}
+		case "FT.SEARCH":
+			return []any{int64(0)}
+		}
+		return nil
+	})
+	query := `@title:"red  shoes" @tag:{sale} -{{x}} $name`
+
+	reply, err := client.FTSearchRaw(context.Background(), "idx:products", query, "PARAMS", 2, "name", "alice bob", "LIMIT", 0, 10).Result()
+	if err != nil {
+		t.Fatalf("FTSearchRaw failed: %v", err)
+	}
+	if !reflect.DeepEqual(reply, []any{int64(0)}) {
+		t.Errorf("Unexpected reply %v", reply)
+	}
+	want := []string{"FT.SEARCH", "idx:products", query, "PARAMS", "2", "name", "alice bob", "LIMIT", "0", "10"}
+	if got := fake.Commands()[1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,178 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientKillFilter CLIENT KILL 的过滤条件, 零值的字段不会作为条件, 至少需要设置一个
+type ClientKillFilter struct {
+	ID     int64         // ID client-id
+	Addr   string        // ADDR ip:port
+	Type   string        // TYPE normal|master|replica|pubsub
+	MaxAge time.Duration // MAXAGE 连接存活超过这个时间的才会被关闭, 精度为秒, 不是整秒时向上取整(如: 500ms 按 1 秒), 不会关闭比 MaxAge 更新的连接
+}
+
+// args 按照 CLIENT KILL 的过滤语法构造参数
+func (f ClientKillFilter) args() []any {
+	args := []any{}
+	if f.ID != 0 {
+		args = append(args, "ID", strconv.FormatInt(f.ID, 10))
+	}
+	if f.Addr != "" {
+		args = append(args, "ADDR", f.Addr)
+	}
+	if f.Type != "" {
+		args = append(args, "TYPE", f.Type)
+	}
+	if f.MaxAge > 0 {
+		seconds := int64((f.MaxAge + time.Second - 1) / time.Second)
+		args = append(args, "MAXAGE", strconv.FormatInt(seconds, 10))
+	}
+	return args
+}
+
+var clientKillCmd = RdCmd{
+	CMD: map[Command]RdSubCmd{
+		CLIENT: {Params: "KILL", NoUseKey: true},
+	},
+}
+
+// ClientKill CLIENT KILL [ID client-id] [ADDR ip:port] [TYPE type] [MAXAGE seconds], 按照过滤条件关闭客户端连接
+// return 被关闭的连接数量
+func (rdm *RedisClient) ClientKill(ctx context.Context, filter ClientKillFilter) (int, error) {
+	filterArgs := filter.args()
+	if len(filterArgs) == 0 {
+		return 0, errors.New("rdb: client kill needs at least one filter")
+	}
+	n, err := ExecuteCmd[*redis.IntCmd](rdm, ctx, clientKillCmd, CLIENT, nil, filterArgs...).Result()
+	return int(n), err
+}
+
+var (
+	clientSetNameCmd = RdCmd{CMD: map[Command]RdSubCmd{CLIENT: {Params: "SETNAME {{name}}", NoUseKey: true, StrictArgs: true}}}
+	clientGetNameCmd = RdCmd{CMD: map[Command]RdSubCmd{CLIENT: {Params: "GETNAME", NoUseKey: true}}}
+	clientIDCmd      = RdCmd{CMD: map[Command]RdSubCmd{CLIENT: {Params: "ID", NoUseKey: true}}}
+	clientInfoCmd    = RdCmd{CMD: map[Command]RdSubCmd{CLIENT: {Params: "INFO", NoUseKey: true}}}
+)
+
+// ClientSetName CLIENT SETNAME name, 设置当前连接的名字, 名字中不能有空格
+// 直接执行时只对连接池中的某一个连接生效, 需要所有连接都带名字时使用 redis.Options 的 ClientName; 在 pipeline 中对同一个 pipeline 的命令生效
+// return OK, 使用 Status() 获取
+func (b builder) ClientSetName(ctx context.Context, name string) *CommandBuilder {
+	return b(ctx, clientSetNameCmd, CLIENT, map[string]any{"name": name})
+}
+
+// ClientGetName CLIENT GETNAME, 返回当前连接的名字, 没有设置时为空, 使用 String() 获取
+func (b builder) ClientGetName(ctx context.Context) *CommandBuilder {
+	return b(ctx, clientGetNameCmd, CLIENT, nil)
+}
+
+// ClientID CLIENT ID, 返回当前连接的 ID, 可以作为 ClientKillFilter 的 ID, 使用 Int() 获取
+func (b builder) ClientID(ctx context.Context) *CommandBuilder {
+	return b(ctx, clientIDCmd, CLIENT, nil)
+}
+
+// ClientInfo CLIENT INFO, 返回当前连接的信息, 格式和 CLIENT LIST 的一行相同, 从redis6.2开始支持, 使用 String() 获取
+func (b builder) ClientInfo(ctx context.Context) *CommandBuilder {
+	return b(ctx, clientInfoCmd, CLIENT, nil)
+}
+
+var waitCmd = RdCmd{
+	CMD: map[Command]RdSubCmd{
+		WAIT: {NoUseKey: true},
+	},
+}
+
+// Wait WAIT numreplicas timeout, 阻塞到之前的写命令被至少 numReplicas 个副本确认, 或者超时
+// timeout 精度为毫秒, 0 表示一直阻塞; 没有 key, 不受缓存版本影响; 在 pipeline 中放在写命令之后可以一次发送
+// return 确认了写命令的副本数量, 超时时可能小于 numReplicas, 使用 Int() 获取
+func (b builder) Wait(ctx context.Context, numReplicas int, timeout time.Duration) *CommandBuilder {
+	return b(ctx, waitCmd, WAIT, nil, numReplicas, timeout.Milliseconds())
+}
+
+// EncodingReportTop EncodingReport 中保留的最大 key 数量
+const EncodingReportTop = 10
+
+// KeyMemory 单个 key 的编码和内存占用
+type KeyMemory struct {
+	Key      string
+	Encoding string
+	Bytes    int64
+}
+
+// EncodingReport 匹配 key 的编码分布统计
+type EncodingReport struct {
+	Keys      int            // 统计到的 key 数量
+	Encodings map[string]int // 每种编码(OBJECT ENCODING)对应的 key 数量
+	Largest   []KeyMemory    // MEMORY USAGE 最大的 key, 按占用从大到小, 最多 EncodingReportTop 个
+}
+
+// add 把一个 key 计入统计, 只保留占用最大的 EncodingReportTop 个
+func (r *EncodingReport) add(km KeyMemory) {
+	r.Keys++
+	r.Encodings[km.Encoding]++
+	i := sort.Search(len(r.Largest), func(i int) bool { return r.Largest[i].Bytes < km.Bytes })
+	if i >= EncodingReportTop {
+		return
+	}
+	r.Largest = slices.Insert(r.Largest, i, km)
+	if len(r.Largest) > EncodingReportTop {
+		r.Largest = r.Largest[:EncodingReportTop]
+	}
+}
+
+// EncodingReport 用 SCAN 遍历匹配 pattern 的 key, 每批 batch 个通过 pipeline 查询 OBJECT ENCODING 和 MEMORY USAGE,
+// 汇总每种编码的 key 数量以及内存占用最大的 key, 用于检查 *-max-listpack-entries 之类的编码阈值是否合适
+// batch <= 0 时使用 100; 遍历过程中被删除的 key 会被跳过; 集群模式下只会扫描其中一个节点
+// 设置了 KeyPrefix 时只统计这个命名空间下的 key, Largest 中的 key 不带 KeyPrefix
+func (rdm *RedisClient) EncodingReport(ctx context.Context, pattern string, batch int) (EncodingReport, error) {
+	if batch <= 0 {
+		batch = 100
+	}
+	report := EncodingReport{Encodings: map[string]int{}}
+	var cursor uint64
+	for {
+		keys, next, err := rdm.Client.Scan(ctx, cursor, prefixPattern(rdm.KeyPrefix, pattern), int64(batch)).Result()
+		if err != nil {
+			return report, err
+		}
+		if len(keys) > 0 {
+			pipe := rdm.Client.Pipeline()
+			encodings := make([]*redis.StringCmd, len(keys))
+			usages := make([]*redis.IntCmd, len(keys))
+			for i, key := range keys {
+				encodings[i] = pipe.ObjectEncoding(ctx, key)
+				usages[i] = pipe.MemoryUsage(ctx, key)
+			}
+			if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+				return report, err
+			}
+			for i, key := range keys {
+				encoding, err := encodings[i].Result()
+				if errors.Is(err, redis.Nil) {
+					continue
+				} else if err != nil {
+					return report, err
+				}
+				bytes, err := usages[i].Result()
+				if errors.Is(err, redis.Nil) {
+					continue
+				} else if err != nil {
+					return report, err
+				}
+				report.add(KeyMemory{Key: strings.TrimPrefix(key, rdm.KeyPrefix), Encoding: encoding, Bytes: bytes})
+			}
+		}
+		if next == 0 {
+			return report, nil
+		}
+		cursor = next
+	}
+}
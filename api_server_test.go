@@ -0,0 +1,216 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestRedisClient_ClientKill 测试 CLIENT KILL 按 ID 和 TYPE 过滤时的命令构造
+func TestRedisClient_ClientKill(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "CLIENT" {
+			return 2
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	n, err := client.ClientKill(ctx, ClientKillFilter{ID: 42})
+	if err != nil || n != 2 {
+		t.Fatalf("ClientKill by ID failed: %d %v", n, err)
+	}
+	_, err = client.ClientKill(ctx, ClientKillFilter{Type: "pubsub", MaxAge: time.Minute})
+	if err != nil {
+		t.Fatalf("ClientKill by TYPE failed: %v", err)
+	}
+	// 不是整秒的 MaxAge 向上取整
+	for _, maxAge := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond} {
+		if _, err := client.ClientKill(ctx, ClientKillFilter{MaxAge: maxAge}); err != nil {
+			t.Fatalf("ClientKill by MAXAGE failed: %v", err)
+		}
+	}
+
+	want := [][]string{
+		{"CLIENT", "KILL", "ID", "42"},
+		{"CLIENT", "KILL", "TYPE", "pubsub", "MAXAGE", "60"},
+		{"CLIENT", "KILL", "MAXAGE", "1"},
+		{"CLIENT", "KILL", "MAXAGE", "2"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if _, err := client.ClientKill(ctx, ClientKillFilter{}); err == nil {
+		t.Errorf("Expected error for empty filter")
+	}
+}
+
+// TestRedisClient_ClientName 测试 CLIENT 的子命令作为固定参数发送, SETNAME 的名字来自 args
+func TestRedisClient_ClientName(t *testing.T) {
+	var name string
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] != "CLIENT" {
+			return fakeStatus("OK")
+		}
+		switch args[1] {
+		case "SETNAME":
+			name = args[2]
+			return fakeStatus("OK")
+		case "GETNAME":
+			return name
+		case "ID":
+			return 42
+		case "INFO":
+			return "id=42 name=" + name
+		}
+		return nil
+	})
+	ctx := context.Background()
+	client.SetCacheVersion("v1")
+
+	pip := client.PipeLine()
+	set := pip.ClientSetName(ctx, "worker-1").Status()
+	get := pip.ClientGetName(ctx).String()
+	id := pip.ClientID(ctx).Int()
+	info := pip.ClientInfo(ctx).String()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+	if set.Val() != "OK" || get.Val() != "worker-1" || id.Val() != 42 || info.Val() != "id=42 name=worker-1" {
+		t.Errorf("unexpected results: %q %q %d %q", set.Val(), get.Val(), id.Val(), info.Val())
+	}
+
+	want := [][]string{
+		{"CLIENT", "SETNAME", "worker-1"},
+		{"CLIENT", "GETNAME"},
+		{"CLIENT", "ID"},
+		{"CLIENT", "INFO"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_EncodingReport 测试混合编码的 keyspace 分页扫描后的汇总结果
+func TestRedisClient_EncodingReport(t *testing.T) {
+	type object struct {
+		encoding string
+		bytes    int64
+	}
+	keyspace := map[string]object{}
+	var keys []string
+	addKeys := func(prefix, encoding string, n int, bytes int64) {
+		for i := 0; i < n; i++ {
+			key := fmt.Sprintf("%s:%d", prefix, i)
+			keyspace[key] = object{encoding, bytes + int64(i)}
+			keys = append(keys, key)
+		}
+	}
+	addKeys("hash", "listpack", 6, 100)
+	addKeys("bighash", "hashtable", 3, 5000)
+	addKeys("counter", "int", 4, 50)
+	addKeys("name", "embstr", 2, 60)
+	// SCAN 返回但是查询前已经被删除的 key
+	keys = append(keys, "deleted")
+
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SCAN":
+			cursor, _ := strconv.Atoi(args[1])
+			count, _ := strconv.Atoi(args[len(args)-1])
+			end := min(cursor+count, len(keys))
+			next := end
+			if end == len(keys) {
+				next = 0
+			}
+			return []any{strconv.Itoa(next), keys[cursor:end]}
+		case "OBJECT":
+			if obj, ok := keyspace[args[2]]; ok {
+				return obj.encoding
+			}
+		case "MEMORY":
+			if obj, ok := keyspace[args[2]]; ok {
+				return obj.bytes
+			}
+		}
+		return nil
+	})
+
+	report, err := client.EncodingReport(context.Background(), "*", 4)
+	if err != nil {
+		t.Fatalf("EncodingReport failed: %v", err)
+	}
+	if report.Keys != 15 {
+		t.Errorf("Expected 15 keys, got %d", report.Keys)
+	}
+	wantEncodings := map[string]int{"listpack": 6, "hashtable": 3, "int": 4, "embstr": 2}
+	if !reflect.DeepEqual(report.Encodings, wantEncodings) {
+		t.Errorf("Expected %v, got %v", wantEncodings, report.Encodings)
+	}
+	if len(report.Largest) != EncodingReportTop {
+		t.Fatalf("Expected %d largest keys, got %d", EncodingReportTop, len(report.Largest))
+	}
+	wantTop := []KeyMemory{
+		{"bighash:2", "hashtable", 5002},
+		{"bighash:1", "hashtable", 5001},
+		{"bighash:0", "hashtable", 5000},
+		{"hash:5", "listpack", 105},
+	}
+	if !reflect.DeepEqual(report.Largest[:4], wantTop) {
+		t.Errorf("Expected %v, got %v", wantTop, report.Largest[:4])
+	}
+	if last := report.Largest[EncodingReportTop-1]; last.Bytes != 61 {
+		t.Errorf("Expected smallest kept key to use 61 bytes, got %v", last)
+	}
+
+	scans := 0
+	for _, c := range fake.Commands() {
+		if c[0] == "SCAN" {
+			scans++
+		}
+	}
+	if scans != 4 {
+		t.Errorf("Expected 4 SCAN pages, got %d", scans)
+	}
+}
+
+// TestRedisClient_Wait 测试 WAIT 没有 key, 不受缓存版本影响, 并且可以放在 pipeline 的写命令之后
+func TestRedisClient_Wait(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "WAIT" {
+			return 1
+		}
+		return fakeStatus("OK")
+	})
+	ctx := context.Background()
+	client.SetCacheVersion("v1")
+
+	n, err := client.Wait(ctx, 2, 1500*time.Millisecond).Int().Result()
+	if err != nil || n != 1 {
+		t.Fatalf("Wait: expected 1, got %d, %v", n, err)
+	}
+
+	cmd := RdCmd{Key: "order:{{id}}", CMD: map[Command]RdSubCmd{SET: {Params: "{{value}}"}}}
+	pip := client.PipeLine()
+	pip.Set(ctx, cmd, map[string]any{"id": 1, "value": "paid"}).Status()
+	acked := pip.Wait(ctx, 1, 0).Int()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+	if acked.Val() != 1 {
+		t.Errorf("Expected 1 acked replica, got %d", acked.Val())
+	}
+
+	want := [][]string{
+		{"WAIT", "2", "1500"},
+		{"SET", "v1:order:1", "paid"},
+		{"WAIT", "1", "0"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
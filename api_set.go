@@ -2,17 +2,19 @@ package rdb
 
 import (
 	"context"
+	"github.com/redis/go-redis/v9"
 )
 
 //	SADD key member [member ...], 向集合添加一个或多个成员
 //
-// return 被添加到集合中的新元素的数量，不包括被忽略的元素。
+// return 被添加到集合中的新元素的数量，不包括被忽略的元素。使用 Int() 获取
+// 多个成员使用 slice 展开, 如: Params: "{{members}}", args: {"members": []string{"a", "b"}}
 func (b builder) SAdd(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SADD, args, includeArgs...)
 }
 
 // SCARD key, 获取集合的成员数
-// return 集合的数量。 当集合 key 不存在时，返回 0 。
+// return 集合的数量。 当集合 key 不存在时，返回 0 。使用 Int() 获取
 func (b builder) SCard(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SCARD, args, includeArgs...)
 }
@@ -23,36 +25,51 @@ func (b builder) SCard(ctx context.Context, cmd RdCmd, args map[string]any, incl
 // key2 = {c}
 // key3 = {a,c,e}
 // SDIFF key1 key2 key3 = {b,d}
+// 多个 key 使用 NoUseKey 加 slice 展开, 如: {Params: "{{keys}}", NoUseKey: true}, args: {"keys": []string{"key1", "key2"}}, 使用 StringSlice() 获取
 func (b builder) SDiff(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SDIFF, args, includeArgs...)
 }
 
 // SDIFFSTORE destination key [key …] ,给定所有集合的差集并存储在 destination 中, 如果指定的集合 destination 已存在，则会被覆盖。
-// return 结果集中的元素数量。
+// return 结果集中的元素数量。使用 Int() 获取, 如: {Params: "{{destination}} {{keys}}", NoUseKey: true}
 func (b builder) SDiffStore(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SDIFFSTORE, args, includeArgs...)
 }
 
 // SINTER key key1  ...keyn  , 返回给定所有给定集合的交集。 不存在的集合 key 被视为空集。 当给定集合当中有一个空集时，结果也为空集(根据集合运算定律)。
-// return 交集的集合
+// return 交集的集合, 多个 key 同 SDIFF, 使用 StringSlice() 获取
 func (b builder) SInter(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SINTER, args, includeArgs...)
 }
 
+// SINTERCARD numkeys key [key ...] [LIMIT limit], 返回交集的成员数量, 不会返回交集本身, 从redis7.0开始支持
+// 多个 key 同 SDIFF 使用 NoUseKey 加 slice 展开, numkeys 写在 Params 中, 如: {Params: "{{numkeys}} {{keys}} LIMIT {{limit}}", NoUseKey: true}, keys 会加上 KeyPrefix
+// limit 大于 0 时数到 limit 就停止计算, 适合只需要判断交集是否足够大的场景; 不需要 LIMIT 时去掉 Params 中的 LIMIT, 或者通过 includeArgs 传入 "LIMIT", limit
+// return 交集的成员数量, 使用 Int() 获取
+func (b builder) SInterCard(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, SINTERCARD, args, includeArgs...)
+}
+
 // SINTERSTORE destination key key1 ...,  将给定集合之间的交集存储在指定的集合中。如果指定的集合已经存在，则将其覆盖。
-// return 返回存储交集的集合的元素数量。
+// return 返回存储交集的集合的元素数量。使用 Int() 获取
 func (b builder) SInterStore(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SINTERSTORE, args, includeArgs...)
 }
 
 // SISMEMBER key member ,  判断member是否存在于key对应的集合中
-// return 如果成员元素是集合的成员，返回 1 。 如果成员元素不是集合的成员，或 key 不存在，返回 0 。
+// return 如果成员元素是集合的成员，返回 1 。 如果成员元素不是集合的成员，或 key 不存在，返回 0 。使用 Bool() 获取
 func (b builder) SIsMember(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SISMEMBER, args, includeArgs...)
 }
 
+// SMISMEMBER key member [member ...], 批量判断成员是否存在于集合中, 从redis6.2开始支持
+// return 和成员顺序一致的结果, 使用 BoolSlice() 获取
+func (b builder) SMIsMember(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, SMISMEMBER, args, includeArgs...)
+}
+
 // SMEMBERS key, 返回集合中的所有的成员。 不存在的集合 key 被视为空集合。
-// return 集合中的所有成员。
+// return 集合中的所有成员。使用 StringSlice() 获取
 func (b builder) SMembers(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SMEMBERS, args, includeArgs...)
 }
@@ -61,25 +78,104 @@ func (b builder) SMembers(ctx context.Context, cmd RdCmd, args map[string]any, i
 // 如果 source 集合不存在或不包含指定的 member 元素，则 SMOVE 命令不执行任何操作，仅返回 0 。否则， member 元素从 source 集合中被移除，并添加到 destination 集合中去。
 // 当 destination 集合已经包含 member 元素时， SMOVE 命令只是简单地将 source 集合中的 member 元素删除。
 // 当 source 或 destination 不是集合类型时，返回一个错误。
-// return 如果成员元素被成功移除，返回 1 。 如果成员元素不是 source 集合的成员，并且没有任何操作对 destination 集合执行，那么返回 0 。
+// return 如果成员元素被成功移除，返回 1 。 如果成员元素不是 source 集合的成员，并且没有任何操作对 destination 集合执行，那么返回 0 。使用 Bool() 获取
+// source 使用 cmd 的 key, destination 和 member 按顺序写在 Params 中, 如: Params: "{{destination}} {{member}}"
+// destination 和 source 使用同样的命名空间, 同样加上缓存版本前缀和 KeyPrefix
 func (b builder) SMove(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SMOVE, args, includeArgs...)
 }
 
+// SPOP key, 随机移除并返回集合中的一个成员
+// 返回的类型由调用的结果方法决定, 和 LPOP 一样: 不带 count 时使用 String() 获取, 集合为空时返回 redis.Nil; 带 count 使用 SPopN
+func (b builder) SPop(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, SPOP, args, includeArgs...)
+}
+
+// SPOP key count, 随机移除并返回集合中的 count 个成员, 使用 StringSlice() 获取, 集合为空时返回空数组
+// count 追加在 Params 之后, SPOP 的 Params 需要为空
+func (b builder) SPopN(ctx context.Context, cmd RdCmd, args map[string]any, count int) *CommandBuilder {
+	return b(ctx, cmd, SPOP, args, count)
+}
+
+// SRANDMEMBER key, 随机返回集合中的一个成员, 不会移除, 使用 String() 获取, 集合为空时返回 redis.Nil; 带 count 使用 SRandMemberN
+func (b builder) SRandMember(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, SRANDMEMBER, args, includeArgs...)
+}
+
+// SRANDMEMBER key count, 随机返回集合中的成员, 使用 StringSlice() 获取
+// count 为正数时返回不重复的 min(count, 集合大小) 个成员, 为负数时返回 |count| 个成员, 可能重复
+// count 追加在 Params 之后, SRANDMEMBER 的 Params 需要为空
+func (b builder) SRandMemberN(ctx context.Context, cmd RdCmd, args map[string]any, count int) *CommandBuilder {
+	return b(ctx, cmd, SRANDMEMBER, args, count)
+}
+
 // SREM key member1 member2 ... , 移除集合中的一个或多个成员元素，不存在的成员元素会被忽略。
-// return 被成功移除的元素的数量，不包括被忽略的元素。
+// return 被成功移除的元素的数量，不包括被忽略的元素。使用 Int() 获取, 多个成员同 SADD 使用 slice 展开
 func (b builder) SRem(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SREM, args, includeArgs...)
 }
 
 // SUNION key key1 key2 ..., 计算给定集合的并集。不存在的集合 key 被视为空集。
-// return 并集成员的列表。
+// return 并集成员的列表。使用 StringSlice() 获取
 func (b builder) SUnion(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SUNION, args, includeArgs...)
 }
 
 // SUNIONSTORE destination key [key …], 将给定集合的并集存储在指定的集合 destination 中。如果 destination 已经存在，则将其覆盖。
-// return 结果集中的元素数量。
+// return 结果集中的元素数量。使用 Int() 获取
 func (b builder) SUnionStore(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SUNIONSTORE, args, includeArgs...)
 }
+
+// Overlap 计算两个集合的重合度 |A∩B| / |A∪B|, 在一个 pipeline 中执行 SINTERCARD 2 a b 和 SCARD a、SCARD b
+// 相同的集合返回 1, 没有交集返回 0, 两个集合都为空(或不存在)时返回 0; a 和 b 会加上 KeyPrefix; SINTERCARD 从redis7.0开始支持
+func (rdm *RedisClient) Overlap(ctx context.Context, a, b string) (float64, error) {
+	a, b = rdm.KeyPrefix+a, rdm.KeyPrefix+b
+	var inter, cardA, cardB *redis.IntCmd
+	_, err := rdm.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		inter = pipe.SInterCard(ctx, 0, a, b)
+		cardA = pipe.SCard(ctx, a)
+		cardB = pipe.SCard(ctx, b)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	union := cardA.Val() + cardB.Val() - inter.Val()
+	if union == 0 {
+		return 0, nil
+	}
+	return float64(inter.Val()) / float64(union), nil
+}
+
+// SScanIterator 集合的成员迭代器, 不是并发安全的, 用法和 HScanIterator 一样
+type SScanIterator struct {
+	pager *scanPager
+	val   string
+}
+
+// SScan 使用 SSCAN key cursor MATCH match COUNT count 遍历集合的成员, key 使用 cmd 的 key 模板和 args 构造, cmd 中不需要定义 SSCAN
+// match 为空时不带 MATCH, count <= 0 时不带 COUNT; 重复返回的成员会被去掉
+func (rdm *RedisClient) SScan(ctx context.Context, cmd RdCmd, args map[string]any, match string, count int64) *SScanIterator {
+	return &SScanIterator{pager: rdm.newScanPager(ctx, cmd, SSCAN, args, match, count)}
+}
+
+// Next 移动到下一个成员, 没有更多的成员或者出错时返回 false
+func (it *SScanIterator) Next() bool {
+	member := it.pager.next(1)
+	if member == nil {
+		return false
+	}
+	it.val = member[0]
+	return true
+}
+
+// Val 返回当前的成员
+func (it *SScanIterator) Val() string {
+	return it.val
+}
+
+// Err 返回遍历过程中出现的错误
+func (it *SScanIterator) Err() error {
+	return it.pager.err
+}
@@ -3,6 +3,10 @@ package rdb
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 )
@@ -491,3 +495,251 @@ func TestRedisClient_Set_Integration(t *testing.T) {
 	fmt.Printf("5. Final set: %v\n", finalMembers.Val())
 }
 
+
+// SetMembersCmd 多个成员和多个 key 通过 slice 展开的集合命令
+var SetMembersCmd = RdCmd{
+	Key: "set:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		SADD:        {Params: "{{members}}"},
+		SREM:        {Params: "{{members}}"},
+		SMISMEMBER:  {Params: "{{members}}"},
+		SINTER:      {Params: "{{keys}}", NoUseKey: true},
+		SUNIONSTORE: {Params: "{{destination}} {{keys}}", NoUseKey: true},
+	},
+}
+
+// TestRedisClient_SetMembers 测试 SADD/SREM/SMISMEMBER 展开多个成员, SINTER/SUNIONSTORE 展开多个 key
+func TestRedisClient_SetMembers(t *testing.T) {
+	var mu sync.Mutex
+	sets := map[string]map[string]bool{}
+	members := func(key string) []string {
+		res := []string{}
+		for m := range sets[key] {
+			res = append(res, m)
+		}
+		sort.Strings(res)
+		return res
+	}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "SADD", "SREM":
+			if sets[args[1]] == nil {
+				sets[args[1]] = map[string]bool{}
+			}
+			n := 0
+			for _, m := range args[2:] {
+				if sets[args[1]][m] == (args[0] == "SREM") {
+					n++
+				}
+				if args[0] == "SADD" {
+					sets[args[1]][m] = true
+				} else {
+					delete(sets[args[1]], m)
+				}
+			}
+			return n
+		case "SMISMEMBER":
+			res := []any{}
+			for _, m := range args[2:] {
+				if sets[args[1]][m] {
+					res = append(res, 1)
+				} else {
+					res = append(res, 0)
+				}
+			}
+			return res
+		case "SINTER":
+			res := []string{}
+			for _, m := range members(args[1]) {
+				in := true
+				for _, key := range args[2:] {
+					in = in && sets[key][m]
+				}
+				if in {
+					res = append(res, m)
+				}
+			}
+			return res
+		case "SUNIONSTORE":
+			union := map[string]bool{}
+			for _, key := range args[2:] {
+				for m := range sets[key] {
+					union[m] = true
+				}
+			}
+			sets[args[1]] = union
+			return len(union)
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if n := client.SAdd(ctx, SetMembersCmd, map[string]any{"keyName": "a", "members": []string{"x", "y", "z"}}).Int().Val(); n != 3 {
+		t.Errorf("SAdd expected 3, got %d", n)
+	}
+	if err := client.SAdd(ctx, SetMembersCmd, map[string]any{"keyName": "b", "members": []string{"y", "z", "w"}}).Err(); err != nil {
+		t.Fatalf("SAdd failed: %v", err)
+	}
+	if n := client.SRem(ctx, SetMembersCmd, map[string]any{"keyName": "b", "members": []string{"w", "missing"}}).Int().Val(); n != 1 {
+		t.Errorf("SRem expected 1, got %d", n)
+	}
+	exists := client.SMIsMember(ctx, SetMembersCmd, map[string]any{"keyName": "a", "members": []string{"x", "w"}}).BoolSlice()
+	if exists.Err() != nil || !reflect.DeepEqual(exists.Val(), []bool{true, false}) {
+		t.Errorf("unexpected SMIsMember result: %v %v", exists.Val(), exists.Err())
+	}
+	inter := client.SInter(ctx, SetMembersCmd, map[string]any{"keys": []string{"set:a", "set:b"}}).StringSlice()
+	if inter.Err() != nil || !reflect.DeepEqual(inter.Val(), []string{"y", "z"}) {
+		t.Errorf("unexpected SInter result: %v %v", inter.Val(), inter.Err())
+	}
+	stored := client.SUnionStore(ctx, SetMembersCmd, map[string]any{"destination": "set:c", "keys": []string{"set:a", "set:b"}}).Int()
+	if stored.Err() != nil || stored.Val() != 3 {
+		t.Errorf("SUnionStore expected 3, got %d %v", stored.Val(), stored.Err())
+	}
+
+	want := []string{"SUNIONSTORE", "set:c", "set:a", "set:b"}
+	if cmds := fake.Commands(); !reflect.DeepEqual(cmds[len(cmds)-1], want) {
+		t.Errorf("Expected %v, got %v", want, cmds[len(cmds)-1])
+	}
+}
+
+// TestRedisClient_Overlap 测试相同、不相交以及部分重合集合的重合度
+func TestRedisClient_Overlap(t *testing.T) {
+	sets := map[string][]string{
+		"tags:a": {"go", "redis", "sql"},
+		"tags:b": {"go", "redis", "sql"},
+		"tags:c": {"rust", "kafka"},
+		"tags:d": {"go", "redis", "kafka", "mq"},
+	}
+	client, _ := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SCARD":
+			return len(sets[args[1]])
+		case "SINTERCARD":
+			n := 0
+			for _, m := range sets[args[2]] {
+				if slices.Contains(sets[args[3]], m) {
+					n++
+				}
+			}
+			return n
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	cases := []struct {
+		a, b string
+		want float64
+	}{
+		{"tags:a", "tags:b", 1},
+		{"tags:a", "tags:c", 0},
+		{"tags:a", "tags:d", 2.0 / 5.0},
+		{"tags:none", "tags:empty", 0},
+	}
+	for _, c := range cases {
+		got, err := client.Overlap(ctx, c.a, c.b)
+		if err != nil {
+			t.Fatalf("Overlap(%s, %s) failed: %v", c.a, c.b, err)
+		}
+		if got != c.want {
+			t.Errorf("Overlap(%s, %s): expected %v, got %v", c.a, c.b, c.want, got)
+		}
+	}
+}
+
+// TestRedisClient_SScan 测试 SScan 遍历集合成员并去掉重复的成员
+func TestRedisClient_SScan(t *testing.T) {
+	client, _ := newFakeClient(t, func(args []string) any {
+		if args[0] != "SSCAN" {
+			return nil
+		}
+		if args[2] == "0" {
+			return []any{"7", []any{"a", "b"}}
+		}
+		return []any{"0", []any{"b", "c"}}
+	})
+
+	var members []string
+	iter := client.SScan(context.Background(), RdCmd{Key: "tags:{{id}}"}, map[string]any{"id": 1}, "", 100)
+	for iter.Next() {
+		members = append(members, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("SScan failed: %v", err)
+	}
+	if !reflect.DeepEqual(members, []string{"a", "b", "c"}) {
+		t.Errorf("Expected [a b c], got %v", members)
+	}
+}
+
+// TestRedisClient_SPopSRandMember 测试不带 count 时 SPOP/SRANDMEMBER 返回单个成员, 带 count 时返回 StringSlice
+func TestRedisClient_SPopSRandMember(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if len(args) > 2 {
+			return []string{"a", "b"}
+		}
+		return "a"
+	})
+	ctx := context.Background()
+	cmd := RdCmd{Key: "tags:{{id}}", CMD: map[Command]RdSubCmd{SPOP: {}, SRANDMEMBER: {}}}
+	args := map[string]any{"id": 1}
+
+	if v, err := client.SPop(ctx, cmd, args).String().Result(); err != nil || v != "a" {
+		t.Errorf("SPop expected a, got %q, %v", v, err)
+	}
+	if v, err := client.SPopN(ctx, cmd, args, 2).StringSlice().Result(); err != nil || !reflect.DeepEqual(v, []string{"a", "b"}) {
+		t.Errorf("SPopN expected [a b], got %v, %v", v, err)
+	}
+	if v, err := client.SRandMember(ctx, cmd, args).String().Result(); err != nil || v != "a" {
+		t.Errorf("SRandMember expected a, got %q, %v", v, err)
+	}
+	if v, err := client.SRandMemberN(ctx, cmd, args, -2).StringSlice().Result(); err != nil || !reflect.DeepEqual(v, []string{"a", "b"}) {
+		t.Errorf("SRandMemberN expected [a b], got %v, %v", v, err)
+	}
+
+	want := [][]string{
+		{"SPOP", "tags:1"},
+		{"SPOP", "tags:1", "2"},
+		{"SRANDMEMBER", "tags:1"},
+		{"SRANDMEMBER", "tags:1", "-2"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_InterCard 测试 SINTERCARD/ZINTERCARD 的 numkeys、keys 展开和 LIMIT, 每个 key 都加上 KeyPrefix
+func TestRedisClient_InterCard(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		return 3
+	})
+	ctx := context.Background()
+	client.KeyPrefix = "t1:"
+	cmd := RdCmd{
+		CMD: map[Command]RdSubCmd{
+			SINTERCARD: {Params: "{{numkeys}} {{keys}}", NoUseKey: true},
+			ZINTERCARD: {Params: "{{numkeys}} {{keys}} LIMIT {{limit}}", NoUseKey: true},
+		},
+	}
+
+	if n, err := client.SInterCard(ctx, cmd, map[string]any{"numkeys": 2, "keys": []string{"a", "b"}}).Int().Result(); err != nil || n != 3 {
+		t.Errorf("SInterCard expected 3, got %d, %v", n, err)
+	}
+	if n, err := client.SInterCard(ctx, cmd, map[string]any{"numkeys": 2, "keys": []string{"a", "b"}}, "LIMIT", 5).Int().Result(); err != nil || n != 3 {
+		t.Errorf("SInterCard with LIMIT expected 3, got %d, %v", n, err)
+	}
+	if n, err := client.ZInterCard(ctx, cmd, map[string]any{"numkeys": 3, "keys": []string{"a", "b", "c"}, "limit": 10}).Int().Result(); err != nil || n != 3 {
+		t.Errorf("ZInterCard expected 3, got %d, %v", n, err)
+	}
+
+	want := [][]string{
+		{"SINTERCARD", "2", "t1:a", "t1:b"},
+		{"SINTERCARD", "2", "t1:a", "t1:b", "LIMIT", "5"},
+		{"ZINTERCARD", "3", "t1:a", "t1:b", "t1:c", "LIMIT", "10"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
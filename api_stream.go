@@ -0,0 +1,28 @@
+package rdb
+
+import (
+	"context"
+	"time"
+)
+
+// PruneConsumers 清理消费组中空闲时间不少于 idle 且没有待处理消息的消费者, 返回被删除的消费者名
+// 通过 XINFO CONSUMERS 获取消费者列表, 再用 XGROUP DELCONSUMER 逐个删除
+// 检查和删除之间消费者可能又读取了消息, 这些消息会随消费者一起从 PEL 中删除, 所以 idle 不要设置得太小; stream 会加上 KeyPrefix
+func (rdm *RedisClient) PruneConsumers(ctx context.Context, stream, group string, idle time.Duration) ([]string, error) {
+	stream = rdm.KeyPrefix + stream
+	consumers, err := rdm.Client.XInfoConsumers(ctx, stream, group).Result()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, consumer := range consumers {
+		if consumer.Pending > 0 || consumer.Idle < idle {
+			continue
+		}
+		if err := rdm.Client.XGroupDelConsumer(ctx, stream, group, consumer.Name).Err(); err != nil {
+			return removed, err
+		}
+		removed = append(removed, consumer.Name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,57 @@
+package rdb
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRedisClient_PruneConsumers 测试只删除空闲超时且没有待处理消息的消费者
+func TestRedisClient_PruneConsumers(t *testing.T) {
+	var mu sync.Mutex
+	consumers := map[string][2]int64{ // name => {pending, idle ms}
+		"idle-worker":   {0, int64(time.Hour / time.Millisecond)},
+		"busy-worker":   {3, int64(time.Hour / time.Millisecond)},
+		"active-worker": {0, 10},
+	}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case args[0] == "XINFO" && len(args) == 4:
+			res := []any{}
+			for _, name := range []string{"active-worker", "busy-worker", "idle-worker"} {
+				if c, ok := consumers[name]; ok {
+					res = append(res, []any{"name", name, "pending", c[0], "idle", c[1], "inactive", c[1]})
+				}
+			}
+			return res
+		case args[0] == "XGROUP" && len(args) == 5:
+			n := consumers[args[4]][0]
+			delete(consumers, args[4])
+			return n
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	removed, err := client.PruneConsumers(ctx, "events", "billing", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("PruneConsumers failed: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []string{"idle-worker"}) {
+		t.Errorf("Expected [idle-worker], got %v", removed)
+	}
+	if _, ok := consumers["idle-worker"]; ok {
+		t.Errorf("idle-worker should be deleted")
+	}
+	want := [][]string{
+		{"XINFO", "consumers", "events", "billing"},
+		{"XGROUP", "delconsumer", "events", "billing", "idle-worker"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
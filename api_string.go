@@ -2,16 +2,97 @@ package rdb
 
 import (
 	"context"
+	"github.com/redis/go-redis/v9"
+	"maps"
+	"slices"
+	"time"
 )
 
+// SET key value, 使用 Status() 获取结果
 func (b builder) Set(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SET, args, includeArgs...)
 }
 
+// SetMode SET 命令的 NX/XX 条件
+type SetMode string
+
+const (
+	SetModeNX SetMode = "NX" // 只在 key 不存在时设置
+	SetModeXX SetMode = "XX" // 只在 key 存在时设置
+)
+
+// SetOptions SET 命令的可选参数
+type SetOptions struct {
+	Expiration time.Duration // 过期时间, 整秒时使用 EX, 否则使用 PX, 不足 1 毫秒时按 1 毫秒设置, 0 表示不设置
+	Mode       SetMode       // NX 或 XX, 空表示不限制
+	KeepTTL    bool          // KEEPTTL 保留原来的过期时间, 设置了 Expiration 时忽略
+	Get        bool          // GET 返回 key 原来的值, 从redis6.2开始支持
+}
+
+// args 按照 SET key value [NX|XX] [GET] [EX seconds|PX milliseconds|KEEPTTL] 的顺序构造 value 之后的参数
+func (opts SetOptions) args(value any) []any {
+	args := []any{value}
+	if opts.Mode != "" {
+		args = append(args, string(opts.Mode))
+	}
+	if opts.Get {
+		args = append(args, "GET")
+	}
+	switch {
+	case opts.Expiration > 0 && opts.Expiration%time.Second == 0:
+		args = append(args, "EX", int64(opts.Expiration/time.Second))
+	case opts.Expiration > 0:
+		// PX 0 会被 redis 拒绝, 和 processExpire 一样向上取整
+		args = append(args, "PX", max(int64(opts.Expiration/time.Millisecond), 1))
+	case opts.KeepTTL:
+		args = append(args, "KEEPTTL")
+	}
+	return args
+}
+
+// SetWithOptions SET key value [NX|XX] [GET] [EX seconds|PX milliseconds|KEEPTTL], 按照 opts 组装 SET 的参数
+// SET 子命令的 Params 需要为空, value 和选项会按照正确的顺序追加在 key 之后
+// 一般使用 Status() 获取结果, 设置了 Get 时使用 String() 获取 key 原来的值
+// 因为 NX/XX 条件没有设置成功或者 Get 时 key 不存在, redis 会返回 nil:
+// ReturnNilError 为 false 时 Err() 为 nil, Val() 为空字符串; 为 true 时 Err() 为 redis.Nil
+func (b builder) SetWithOptions(ctx context.Context, cmd RdCmd, args map[string]any, value any, opts SetOptions) *CommandBuilder {
+	return b(ctx, cmd, SET, args, opts.args(value)...)
+}
+
+// MSET key value [key value ...], 同时设置多个 key, 使用 Status() 获取结果
 func (b builder) MSet(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, MSET, args, includeArgs...)
 }
 
+// MSetMap 同 MSet, key 和 value 通过 map 传入, 按 key 排序后展开成 key value 对, 保证每次构造的参数顺序一致
+// MSET 子命令需要设置 NoUseKey, Params 为空, value 的格式化和模板参数一致, 其他类型原样交给 go-redis 处理
+func (b builder) MSetMap(ctx context.Context, cmd RdCmd, args map[string]any, values map[string]any) *CommandBuilder {
+	return b(ctx, cmd, MSET, args, flattenKeyValues(values)...)
+}
+
+// MSETNX key value [key value ...], 所有 key 都不存在时才会全部设置, 使用 Bool() 获取结果
+func (b builder) MSetNx(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, MSETNX, args, includeArgs...)
+}
+
+// MSetNxMap 同 MSetNx, key 和 value 通过 map 传入, 规则同 MSetMap
+func (b builder) MSetNxMap(ctx context.Context, cmd RdCmd, args map[string]any, values map[string]any) *CommandBuilder {
+	return b(ctx, cmd, MSETNX, args, flattenKeyValues(values)...)
+}
+
+// flattenKeyValues 把 map 按 key 排序后展开成 key value 对
+func flattenKeyValues(values map[string]any) []any {
+	args := make([]any, 0, 2*len(values))
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		if v, ok := appendParamValue(nil, values[k]); ok {
+			args = append(args, k, string(v))
+		} else {
+			args = append(args, k, values[k])
+		}
+	}
+	return args
+}
+
 // SETRANGE key offset value   , 用 value 参数覆写给定 key 所储存的字符串值，从偏移量 offset 开始。
 func (b builder) SetRange(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, SETRANGE, args, includeArgs...)
@@ -27,46 +108,110 @@ func (b builder) SetNx(ctx context.Context, cmd RdCmd, args map[string]any, incl
 	return b(ctx, cmd, SETNX, args, includeArgs...)
 }
 
+// DEL key [key ...], 删除 key, 使用 Int() 获取被删除的 key 的数量, 多个 key 同 Unlink 传入
 func (b builder) Del(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, DEL, args, includeArgs...)
 }
 
+// GET key, 使用 String() 获取结果, key 不存在时根据 ReturnNilError 决定是否返回 redis.Nil
 func (b builder) Get(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, GET, args, includeArgs...)
 }
 
+// GETSET key value, 设置新值并返回旧值, 使用 String() 获取结果
 func (b builder) GetSet(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, GETSET, args, includeArgs...)
 }
 
+// GETRANGE key start end, 返回 key 中字符串值的子字符串, 使用 String() 获取结果
 func (b builder) GetRange(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, GETRANGE, args, includeArgs...)
 }
 
+// GETDEL key, 获取 key 的值并删除 key, 从redis6.2开始支持, 使用 String() 获取结果
+func (b builder) GetDel(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, GETDEL, args, includeArgs...)
+}
+
+// GETEX key [EX seconds|PX milliseconds|EXAT unix-time-seconds|PXAT unix-time-milliseconds|PERSIST]
+// 获取 key 的值并同时设置或者移除过期时间, 从redis6.2开始支持, 如: Params: "EX {{seconds}}", 使用 String() 获取结果
+func (b builder) GetEx(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, GETEX, args, includeArgs...)
+}
+
+// GetExOptions GETEX 命令的过期时间选项, 按 Expiration、ExpireAt、Persist 的顺序只使用第一个设置了的选项
+type GetExOptions struct {
+	Expiration time.Duration // 相对过期时间, 整秒时使用 EX, 否则使用 PX
+	ExpireAt   time.Time     // 绝对过期时间, 整秒时使用 EXAT, 否则使用 PXAT
+	Persist    bool          // PERSIST 移除过期时间
+}
+
+// args 构造 GETEX key 之后的过期时间参数, 没有设置选项时返回空, 相当于 GET
+func (opts GetExOptions) args() []any {
+	switch {
+	case opts.Expiration > 0 && opts.Expiration%time.Second == 0:
+		return []any{"EX", int64(opts.Expiration / time.Second)}
+	case opts.Expiration > 0:
+		return []any{"PX", opts.Expiration.Milliseconds()}
+	case !opts.ExpireAt.IsZero() && opts.ExpireAt.Nanosecond() == 0:
+		return []any{"EXAT", opts.ExpireAt.Unix()}
+	case !opts.ExpireAt.IsZero():
+		return []any{"PXAT", opts.ExpireAt.UnixMilli()}
+	case opts.Persist:
+		return []any{"PERSIST"}
+	}
+	return nil
+}
+
+// GetExWithOptions GETEX key [EX seconds|PX milliseconds|EXAT unix-time-seconds|PXAT unix-time-milliseconds|PERSIST]
+// 在一条命令中获取值并刷新过期时间, 适合滑动过期的 session, 不会出现 GET 之后单独 EXPIRE 之前 key 已经过期的情况
+// GETEX 子命令的 Params 需要为空, 也不要设置 Exp; key 不存在时的 nil 处理同 Get
+func (b builder) GetExWithOptions(ctx context.Context, cmd RdCmd, args map[string]any, opts GetExOptions) *redis.StringCmd {
+	return b(ctx, cmd, GETEX, args, opts.args()...).String()
+}
+
+// STRLEN key, 返回 key 所储存的字符串值的长度, key 不存在时返回 0, 使用 Int() 获取结果
+func (b builder) StrLen(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, STRLEN, args, includeArgs...)
+}
+
 func (b builder) MGet(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, MGET, args, includeArgs...)
 }
 
+// 计数器相关的命令都使用模板声明增量, 如: INCRBY: {Params: "{{by}}"}, 调用时传入 map[string]any{"by": 10}
+
+// INCR key, 将 key 中储存的数字值加一, 使用 Int() 获取结果 *redis.IntCmd
 func (b builder) Incr(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, INCR, args, includeArgs...)
 }
 
+// INCRBY key increment, 将 key 中储存的数字值加上增量 {{by}}, 使用 Int() 获取结果 *redis.IntCmd
 func (b builder) IncrBy(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, INCRBY, args, includeArgs...)
 }
 
+// INCRBYFLOAT key increment, 将 key 中储存的值加上浮点数增量 {{by}}, 使用 Float() 获取结果 *redis.FloatCmd
 func (b builder) IncrByFloat(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, INCRBYFLOAT, args, includeArgs...)
 }
 
+// DECRBY key decrement, 将 key 中储存的数字值减去 {{by}}, 使用 Int() 获取结果 *redis.IntCmd
 func (b builder) DecrBy(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, DECRBY, args, includeArgs...)
 }
 
+// DECR key, 将 key 中储存的数字值减一, 使用 Int() 获取结果 *redis.IntCmd
 func (b builder) Decr(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, DECR, args, includeArgs...)
 }
 
-func (b builder) StringAppend(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+// APPEND key value, 将 value 追加到 key 原来的值的末尾, 返回追加后字符串的长度, 使用 Int() 获取结果
+func (b builder) Append(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, APPEND, args, includeArgs...)
 }
+
+// StringAppend 同 Append
+func (b builder) StringAppend(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b.Append(ctx, cmd, args, includeArgs...)
+}
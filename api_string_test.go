@@ -1,8 +1,14 @@
 package rdb
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -516,3 +522,393 @@ func TestRedisClient_String_Integration(t *testing.T) {
 	fmt.Printf("3. INCR: %d\n", incrCmd.Val())
 }
 
+
+// TestRedisClient_SetWithOptions 测试 SET 选项的参数顺序和返回类型
+func TestRedisClient_SetWithOptions(t *testing.T) {
+	var mu sync.Mutex
+	store := map[string]string{"string:exists": "old"}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		if args[0] != "SET" {
+			return nil
+		}
+		old, exists := store[args[1]]
+		nx, get := false, false
+		for _, arg := range args[3:] {
+			nx = nx || arg == "NX"
+			get = get || arg == "GET"
+		}
+		if nx && exists {
+			return nil
+		}
+		store[args[1]] = args[2]
+		if get {
+			if !exists {
+				return nil
+			}
+			return old
+		}
+		return fakeStatus("OK")
+	})
+
+	var SetCmd = RdCmd{
+		Key: "string:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {},
+		},
+	}
+	var SetNilCmd = RdCmd{
+		Key: "string:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {ReturnNilError: true},
+		},
+	}
+	ctx := context.Background()
+
+	status := client.SetWithOptions(ctx, SetCmd, map[string]any{"keyName": "a"}, "v1", SetOptions{Expiration: 10 * time.Second, Mode: SetModeNX}).Status()
+	if status.Err() != nil || status.Val() != "OK" {
+		t.Fatalf("SetWithOptions failed: %v %v", status.Val(), status.Err())
+	}
+	old := client.SetWithOptions(ctx, SetCmd, map[string]any{"keyName": "exists"}, "new", SetOptions{Expiration: 1500 * time.Millisecond, Get: true}).String()
+	if old.Err() != nil || old.Val() != "old" {
+		t.Fatalf("SetWithOptions GET failed: %v %v", old.Val(), old.Err())
+	}
+	client.SetWithOptions(ctx, SetCmd, map[string]any{"keyName": "b"}, 1, SetOptions{KeepTTL: true, Mode: SetModeXX}).Status()
+	// 不足 1 毫秒时按 1 毫秒设置, 不会发送 PX 0
+	client.SetWithOptions(ctx, SetCmd, map[string]any{"keyName": "c"}, 1, SetOptions{Expiration: 500 * time.Microsecond}).Status()
+
+	want := [][]string{
+		{"SET", "string:a", "v1", "NX", "EX", "10"},
+		{"SET", "string:exists", "new", "GET", "PX", "1500"},
+		{"SET", "string:b", "1", "XX", "KEEPTTL"},
+		{"SET", "string:c", "1", "PX", "1"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// NX 条件不满足时 redis 返回 nil
+	status = client.SetWithOptions(ctx, SetCmd, map[string]any{"keyName": "a"}, "v2", SetOptions{Mode: SetModeNX}).Status()
+	if status.Err() != nil || status.Val() != "" {
+		t.Errorf("Expected empty result without error, got %q %v", status.Val(), status.Err())
+	}
+	status = client.SetWithOptions(ctx, SetNilCmd, map[string]any{"keyName": "a"}, "v2", SetOptions{Mode: SetModeNX}).Status()
+	if !errors.Is(status.Err(), redis.Nil) {
+		t.Errorf("Expected redis.Nil, got %v", status.Err())
+	}
+}
+
+// CounterCmd 计数器命令, 增量通过 {{by}} 声明
+var CounterCmd = RdCmd{
+	Key: "counter:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		INCR:         {},
+		DECR:         {},
+		INCRBY:       {Params: "{{by}}"},
+		DECRBY:       {Params: "{{by}}"},
+		INCRBYFLOAT:  {Params: "{{by}}"},
+		HINCRBY:      {Params: "{{field}} {{by}}"},
+		HINCRBYFLOAT: {Params: "{{field}} {{by}}"},
+	},
+}
+
+// TestRedisClient_Counters 测试计数器命令的参数构造和返回类型
+func TestRedisClient_Counters(t *testing.T) {
+	var mu sync.Mutex
+	ints := map[string]int64{}
+	floats := map[string]float64{}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "INCR":
+			ints[args[1]]++
+			return ints[args[1]]
+		case "DECR":
+			ints[args[1]]--
+			return ints[args[1]]
+		case "INCRBY", "DECRBY":
+			n, _ := strconv.ParseInt(args[2], 10, 64)
+			if args[0] == "DECRBY" {
+				n = -n
+			}
+			ints[args[1]] += n
+			return ints[args[1]]
+		case "HINCRBY":
+			n, _ := strconv.ParseInt(args[3], 10, 64)
+			ints[args[1]+"."+args[2]] += n
+			return ints[args[1]+"."+args[2]]
+		case "INCRBYFLOAT":
+			f, _ := strconv.ParseFloat(args[2], 64)
+			floats[args[1]] += f
+			return strconv.FormatFloat(floats[args[1]], 'f', -1, 64)
+		case "HINCRBYFLOAT":
+			f, _ := strconv.ParseFloat(args[3], 64)
+			floats[args[1]+"."+args[2]] += f
+			return strconv.FormatFloat(floats[args[1]+"."+args[2]], 'f', -1, 64)
+		}
+		return nil
+	})
+	ctx := context.Background()
+	key := map[string]any{"keyName": "hits"}
+
+	if v := client.Incr(ctx, CounterCmd, key).Int().Val(); v != 1 {
+		t.Errorf("Incr expected 1, got %d", v)
+	}
+	if v := client.IncrBy(ctx, CounterCmd, map[string]any{"keyName": "hits", "by": 10}).Int().Val(); v != 11 {
+		t.Errorf("IncrBy expected 11, got %d", v)
+	}
+	if v := client.DecrBy(ctx, CounterCmd, map[string]any{"keyName": "hits", "by": 3}).Int().Val(); v != 8 {
+		t.Errorf("DecrBy expected 8, got %d", v)
+	}
+	if v := client.Decr(ctx, CounterCmd, key).Int().Val(); v != 7 {
+		t.Errorf("Decr expected 7, got %d", v)
+	}
+	if v := client.IncrByFloat(ctx, CounterCmd, map[string]any{"keyName": "score", "by": 1.5}).Float().Val(); v != 1.5 {
+		t.Errorf("IncrByFloat expected 1.5, got %v", v)
+	}
+	if v := client.HIncrBy(ctx, CounterCmd, map[string]any{"keyName": "h", "field": "views", "by": 5}).Int().Val(); v != 5 {
+		t.Errorf("HIncrBy expected 5, got %d", v)
+	}
+	if v := client.HIncrByFloat(ctx, CounterCmd, map[string]any{"keyName": "h", "field": "avg", "by": 0.25}).Float().Val(); v != 0.25 {
+		t.Errorf("HIncrByFloat expected 0.25, got %v", v)
+	}
+
+	want := [][]string{
+		{"INCR", "counter:hits"},
+		{"INCRBY", "counter:hits", "10"},
+		{"DECRBY", "counter:hits", "3"},
+		{"DECR", "counter:hits"},
+		{"INCRBYFLOAT", "counter:score", "1.5"},
+		{"HINCRBY", "counter:h", "views", "5"},
+		{"HINCRBYFLOAT", "counter:h", "avg", "0.25"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// StringCoreCmd 字符串常用命令
+var StringCoreCmd = RdCmd{
+	Key: "string:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		APPEND:   {Params: "{{value}}"},
+		STRLEN:   {},
+		GETRANGE: {Params: "{{start}} {{end}}"},
+		SETRANGE: {Params: "{{offset}} {{value}}"},
+		GETEX:    {Params: "EX {{seconds}}"},
+		GETDEL:   {},
+	},
+}
+
+// TestRedisClient_StringCore 测试 APPEND/STRLEN/GETRANGE/SETRANGE/GETEX/GETDEL 的参数构造和返回值
+func TestRedisClient_StringCore(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(args) < 2 {
+			return nil
+		}
+		v, ok := values[args[1]]
+		switch args[0] {
+		case "APPEND":
+			values[args[1]] = v + args[2]
+			return len(values[args[1]])
+		case "STRLEN":
+			return len(v)
+		case "GETRANGE":
+			start, _ := strconv.Atoi(args[2])
+			end, _ := strconv.Atoi(args[3])
+			return v[start : end+1]
+		case "SETRANGE":
+			offset, _ := strconv.Atoi(args[2])
+			values[args[1]] = v[:offset] + args[3]
+			return len(values[args[1]])
+		case "GETEX":
+			if !ok {
+				return nil
+			}
+			return v
+		case "GETDEL":
+			if !ok {
+				return nil
+			}
+			delete(values, args[1])
+			return v
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if n := client.Append(ctx, StringCoreCmd, map[string]any{"keyName": "s", "value": "hello"}).Int().Val(); n != 5 {
+		t.Errorf("Append expected 5, got %d", n)
+	}
+	if n := client.StringAppend(ctx, StringCoreCmd, map[string]any{"keyName": "s", "value": "world"}).Int().Val(); n != 10 {
+		t.Errorf("StringAppend expected 10, got %d", n)
+	}
+	if n := client.StrLen(ctx, StringCoreCmd, map[string]any{"keyName": "s"}).Int().Val(); n != 10 {
+		t.Errorf("StrLen expected 10, got %d", n)
+	}
+	if v := client.GetRange(ctx, StringCoreCmd, map[string]any{"keyName": "s", "start": 0, "end": 4}).String().Val(); v != "hello" {
+		t.Errorf("GetRange expected hello, got %q", v)
+	}
+	if n := client.SetRange(ctx, StringCoreCmd, map[string]any{"keyName": "s", "offset": 5, "value": "redis"}).Int().Val(); n != 10 {
+		t.Errorf("SetRange expected 10, got %d", n)
+	}
+	if v := client.GetEx(ctx, StringCoreCmd, map[string]any{"keyName": "s", "seconds": 60}).String().Val(); v != "helloredis" {
+		t.Errorf("GetEx expected helloredis, got %q", v)
+	}
+	if v := client.GetDel(ctx, StringCoreCmd, map[string]any{"keyName": "s"}).String().Val(); v != "helloredis" {
+		t.Errorf("GetDel expected helloredis, got %q", v)
+	}
+	if res := client.GetDel(ctx, StringCoreCmd, map[string]any{"keyName": "s"}).String(); res.Err() != nil || res.Val() != "" {
+		t.Errorf("GetDel on missing key expected empty result, got %q %v", res.Val(), res.Err())
+	}
+
+	want := []string{"GETEX", "string:s", "EX", "60"}
+	if got := fake.Commands()[5]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// MultiStringCmd MSET/MSETNX 不使用外层的 key
+var MultiStringCmd = RdCmd{
+	CMD: map[Command]RdSubCmd{
+		MSET:   {NoUseKey: true},
+		MSETNX: {NoUseKey: true},
+	},
+}
+
+// TestRedisClient_MSetMap 测试 map 按 key 排序展开成 key value 对
+func TestRedisClient_MSetMap(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "MSET":
+			for i := 1; i+1 < len(args); i += 2 {
+				values[args[i]] = args[i+1]
+			}
+			return fakeStatus("OK")
+		case "MSETNX":
+			for i := 1; i+1 < len(args); i += 2 {
+				if _, ok := values[args[i]]; ok {
+					return 0
+				}
+			}
+			for i := 1; i+1 < len(args); i += 2 {
+				values[args[i]] = args[i+1]
+			}
+			return 1
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	status := client.MSetMap(ctx, MultiStringCmd, nil, map[string]any{"k3": 1.5, "k1": "v1", "k2": 2}).Status()
+	if status.Err() != nil || status.Val() != "OK" {
+		t.Fatalf("MSetMap failed: %v %v", status.Val(), status.Err())
+	}
+	if ok := client.MSetNxMap(ctx, MultiStringCmd, nil, map[string]any{"k1": "x", "k4": "y"}).Bool(); ok.Err() != nil || ok.Val() {
+		t.Errorf("MSetNxMap expected false, got %v %v", ok.Val(), ok.Err())
+	}
+	if ok := client.MSetNxMap(ctx, MultiStringCmd, nil, map[string]any{"k5": true, "k4": "y"}).Bool(); ok.Err() != nil || !ok.Val() {
+		t.Errorf("MSetNxMap expected true, got %v %v", ok.Val(), ok.Err())
+	}
+
+	want := [][]string{
+		{"MSET", "k1", "v1", "k2", "2", "k3", "1.5"},
+		{"MSETNX", "k1", "x", "k4", "y"},
+		{"MSETNX", "k4", "y", "k5", "true"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_BinaryValue 测试 []byte 参数原样发送, 包含 NUL 的值可以完整读回
+func TestRedisClient_BinaryValue(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{}
+	client, _ := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "SET":
+			values[args[1]] = args[2]
+			return fakeStatus("OK")
+		case "GET":
+			if v, ok := values[args[1]]; ok {
+				return v
+			}
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "blob:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {Params: "{{value}}"},
+			GET: {},
+		},
+	}
+	ctx := context.Background()
+	data := []byte{'a', 0x00, 0xff, 0x00, '\r', '\n', 'z'}
+
+	if err := client.Set(ctx, cmd, map[string]any{"id": 1, "value": data}).Err(); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	got, err := client.Get(ctx, cmd, map[string]any{"id": 1}).String().Bytes()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Expected %v, got %v", data, got)
+	}
+}
+
+// TestRedisClient_GetExWithOptions 测试 GETEX 的过期时间选项编码在同一条命令中
+func TestRedisClient_GetExWithOptions(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "GETEX" {
+			return "session-data"
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "session:{{id}}",
+		CMD: map[Command]RdSubCmd{GETEX: {}},
+	}
+	ctx := context.Background()
+	at := time.Unix(1700000000, 0)
+
+	optsList := []GetExOptions{
+		{Expiration: 30 * time.Minute},
+		{Expiration: 1500 * time.Millisecond},
+		{ExpireAt: at},
+		{ExpireAt: at.Add(250 * time.Millisecond)},
+		{Persist: true},
+		{},
+	}
+	for _, opts := range optsList {
+		val, err := client.GetExWithOptions(ctx, cmd, map[string]any{"id": 1}, opts).Result()
+		if err != nil || val != "session-data" {
+			t.Fatalf("GetExWithOptions(%+v) failed: %q %v", opts, val, err)
+		}
+	}
+	want := [][]string{
+		{"GETEX", "session:1", "EX", "1800"},
+		{"GETEX", "session:1", "PX", "1500"},
+		{"GETEX", "session:1", "EXAT", "1700000000"},
+		{"GETEX", "session:1", "PXAT", "1700000000250"},
+		{"GETEX", "session:1", "PERSIST"},
+		{"GETEX", "session:1"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
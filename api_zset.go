@@ -2,16 +2,64 @@ package rdb
 
 import (
 	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"strconv"
 )
 
 // ZADD key score1 member1 [score2 member2] , 向有序集合添加一个或多个成员，或者更新已存在成员的分数。
-// return 被成功添加的新成员的数量，不包括那些被更新的、已经存在的成员。
+// return 被成功添加的新成员的数量，不包括那些被更新的、已经存在的成员。使用 Int() 获取
 func (b builder) ZAdd(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZADD, args, includeArgs...)
 }
 
+// ZAddCompare ZADD 命令的 GT/LT 条件
+type ZAddCompare string
+
+const (
+	ZAddGT ZAddCompare = "GT" // 只在新分数大于原来的分数时更新
+	ZAddLT ZAddCompare = "LT" // 只在新分数小于原来的分数时更新
+)
+
+// ZAddOptions ZADD 命令的可选参数
+type ZAddOptions struct {
+	Mode    SetMode     // NX 只添加新成员, XX 只更新已存在的成员, 空表示不限制
+	Compare ZAddCompare // GT 或 LT, 从redis6.2开始支持, 不能和 NX 同时使用
+	CH      bool        // 返回值包含被更新分数的成员数量
+	Incr    bool        // 和 ZINCRBY 一样对分数做自增, 只能有一个成员
+}
+
+// args 按照 ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...] 的顺序构造 key 之后的参数
+func (opts ZAddOptions) args(members []redis.Z) []any {
+	args := make([]any, 0, 4+2*len(members))
+	if opts.Mode != "" {
+		args = append(args, string(opts.Mode))
+	}
+	if opts.Compare != "" {
+		args = append(args, string(opts.Compare))
+	}
+	if opts.CH {
+		args = append(args, "CH")
+	}
+	if opts.Incr {
+		args = append(args, "INCR")
+	}
+	for _, m := range members {
+		args = append(args, m.Score, m.Member)
+	}
+	return args
+}
+
+// ZAddWithOptions ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...], 按照 opts 组装 ZADD 的参数
+// ZADD 子命令的 Params 需要为空, 选项和成员会按照正确的顺序追加在 key 之后
+// 一般使用 Int() 获取结果, 设置了 Incr 时使用 Float() 获取成员的新分数, 条件不满足时 redis 返回 nil
+func (b builder) ZAddWithOptions(ctx context.Context, cmd RdCmd, args map[string]any, opts ZAddOptions, members ...redis.Z) *CommandBuilder {
+	return b(ctx, cmd, ZADD, args, opts.args(members)...)
+}
+
 // ZCARD key , 获取有序集合的成员数
-// return 当 key 存在且是有序集类型时，返回有序集的基数。 当 key 不存在时，返回 0 。
+// return 当 key 存在且是有序集类型时，返回有序集的基数。 当 key 不存在时，返回 0 。使用 Int() 获取
 func (b builder) ZCard(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZCARD, args, includeArgs...)
 }
@@ -25,7 +73,7 @@ func (b builder) ZCount(ctx context.Context, cmd RdCmd, args map[string]any, inc
 // ZINCRBY key increment member,  有序集合中对指定成员的分数加上增量 increment,
 // 可以通过传递一个负数值 increment ，让分数减去相应的值，比如 ZINCRBY key -5 member ，就是让 member 的 score 值减去 5 。
 // 当 key 不存在，或分数不是 key 的成员时， ZINCRBY key increment member 等同于 ZADD key increment member 。
-// return member 成员的新分数值，以字符串形式表示。
+// return member 成员的新分数值，以字符串形式表示。使用 Float() 获取
 func (b builder) ZIncrBy(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZINCRBY, args, includeArgs...)
 }
@@ -57,6 +105,12 @@ func (b builder) ZRange(ctx context.Context, cmd RdCmd, args map[string]any, inc
 	return b(ctx, cmd, ZRANGE, args, includeArgs...)
 }
 
+// ZRANGE key start stop WITHSCORES, 在 ZRANGE 的参数最后追加 WITHSCORES, 使用 ZSlice() 获取成员和分数
+// ZRANGE 子命令的 Params 中不要再写 WITHSCORES
+func (b builder) ZRangeWithScores(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, ZRANGE, args, append(slices.Clip(includeArgs), "WITHSCORES")...)
+}
+
 // ZREVRANGE key start stop [WITHSCORES], 通过索引区间返回有序集合指定区间内的成员, 和上面的类似， 只是排序不一样
 // 其中成员的位置按分数值递减(从大到小)来排列。
 // 具有相同分数值的成员按字典序的逆序(reverse lexicographical order)排列。
@@ -92,19 +146,19 @@ func (b builder) ZRevRangeByScore(ctx context.Context, cmd RdCmd, args map[strin
 
 // ZRANK key member , 返回有序集中指定成员的排名。其中有序集成员按分数值递增(从小到大)顺序排列。
 // return 如果成员是有序集 key 的成员，返回 member 的排名。 如果成员不是有序集 key 的成员，返回 nil 。
-// 排名是从0开始的
+// 排名是从0开始的, 使用 Int() 获取
 func (b builder) ZRank(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZRANK, args, includeArgs...)
 }
 
 // ZREVRANK key member , 返回有序集合中指定成员的排名，有序集成员按分数值递减(从大到小)排序,  排名以 0 为底，也就是说， 分数值最大的成员排名为 0 。
-// return 如果成员是有序集 key 的成员，返回成员的排名。 如果成员不是有序集 key 的成员，返回 nil 。
+// return 如果成员是有序集 key 的成员，返回成员的排名。 如果成员不是有序集 key 的成员，返回 nil 。使用 Int() 获取
 func (b builder) ZRevRank(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZREVRANK, args, includeArgs...)
 }
 
 // ZREM key member [member2 ...], 移除有序集合中的一个或多个成员,不存在的成员将被忽略。
-// return 被成功移除的成员的数量，不包括被忽略的成员。
+// return 被成功移除的成员的数量，不包括被忽略的成员。使用 Int() 获取, 多个成员使用 slice 展开
 func (b builder) ZRem(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZREM, args, includeArgs...)
 }
@@ -131,7 +185,7 @@ func (b builder) ZRemRangeByScore(ctx context.Context, cmd RdCmd, args map[strin
 }
 
 // ZSCORE key member, 返回有序集中，成员的分数值
-// return  成员的分数值，以字符串形式表示。
+// return  成员的分数值，以字符串形式表示。使用 Float() 获取
 func (b builder) ZScore(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZSCORE, args, includeArgs...)
 }
@@ -179,3 +233,78 @@ func (b builder) ZUnionStore(ctx context.Context, cmd RdCmd, args map[string]any
 func (b builder) ZUnion(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return b(ctx, cmd, ZUNION, args, includeArgs...)
 }
+
+// ZINTERCARD numkeys key [key ...] [LIMIT limit], 返回有序集合交集的成员数量, 参数的写法同 SINTERCARD, 从redis7.0开始支持
+// return 交集的成员数量, 使用 Int() 获取
+func (b builder) ZInterCard(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, ZINTERCARD, args, includeArgs...)
+}
+
+// ZMPOP numkeys key [key ...] MIN|MAX [COUNT count], 从第一个非空的有序集合中弹出分数最小或最大的成员, 从redis7开始支持
+// key 的写法同 LMPOP, 如: {Params: "{{numkeys}} {{keys}} MIN COUNT {{count}}", NoUseKey: true}
+// return 弹出成员的 key 和成员列表, 使用 ZSliceWithKey() 获取; 所有集合都为空时结果为空
+func (b builder) ZMPop(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, ZMPOP, args, includeArgs...)
+}
+
+// BZPOPMIN key [key ...] timeout, 阻塞地从第一个非空的有序集合中弹出分数最小的成员, key 和 timeout 的写法以及读超时同 BLPOP
+// return 使用 ZWithKey() 获取; 超时没有成员时结果为空, 不返回 redis.Nil
+func (b builder) BZPopMin(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BZPOPMIN, args, includeArgs...)
+}
+
+// BZPOPMAX key [key ...] timeout, 阻塞地从第一个非空的有序集合中弹出分数最大的成员, 同 BZPOPMIN
+// return 使用 ZWithKey() 获取
+func (b builder) BZPopMax(ctx context.Context, cmd RdCmd, args map[string]any, includeArgs ...any) *CommandBuilder {
+	return b(ctx, cmd, BZPOPMAX, args, includeArgs...)
+}
+
+// ZSetEntry ZScanIterator 返回的成员和分数
+type ZSetEntry struct {
+	Member string
+	Score  float64
+}
+
+// ZScanIterator 有序集合的成员迭代器, 不是并发安全的, 用法和 HScanIterator 一样
+type ZScanIterator struct {
+	pager *scanPager
+	val   ZSetEntry
+	err   error
+}
+
+// ZScan 使用 ZSCAN key cursor MATCH match COUNT count 遍历有序集合的成员和分数, key 使用 cmd 的 key 模板和 args 构造, cmd 中不需要定义 ZSCAN
+// match 为空时不带 MATCH, count <= 0 时不带 COUNT; 重复返回的成员会被去掉
+func (rdm *RedisClient) ZScan(ctx context.Context, cmd RdCmd, args map[string]any, match string, count int64) *ZScanIterator {
+	return &ZScanIterator{pager: rdm.newScanPager(ctx, cmd, ZSCAN, args, match, count)}
+}
+
+// Next 移动到下一个成员, 没有更多的成员或者出错时返回 false
+func (it *ZScanIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	pair := it.pager.next(2)
+	if pair == nil {
+		return false
+	}
+	score, err := strconv.ParseFloat(pair[1], 64)
+	if err != nil {
+		it.err = fmt.Errorf("rdb: invalid score %q for member %q: %w", pair[1], pair[0], err)
+		return false
+	}
+	it.val = ZSetEntry{Member: pair[0], Score: score}
+	return true
+}
+
+// Val 返回当前的成员和分数
+func (it *ZScanIterator) Val() ZSetEntry {
+	return it.val
+}
+
+// Err 返回遍历过程中出现的错误
+func (it *ZScanIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.pager.err
+}
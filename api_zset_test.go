@@ -4,6 +4,11 @@ import (
 	"context"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -812,3 +817,203 @@ func TestRedisClient_ZSet_Integration(t *testing.T) {
 	})
 	fmt.Printf("6. ZCOUNT [15,25]: %d\n", countCmd.Val())
 }
+
+// ZSetScoresCmd ZADD 的选项和成员通过 ZAddWithOptions 追加
+var ZSetScoresCmd = RdCmd{
+	Key: "zset:{{keyName}}",
+	CMD: map[Command]RdSubCmd{
+		ZADD:   {},
+		ZRANGE: {Params: "{{start}} {{stop}}"},
+	},
+}
+
+// TestRedisClient_ZAddWithOptions 测试 ZADD 选项的参数顺序, 以及 ZRANGE WITHSCORES 解析成 ZSlice
+func TestRedisClient_ZAddWithOptions(t *testing.T) {
+	var mu sync.Mutex
+	scores := map[string]float64{}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "ZADD":
+			i, incr, xx := 2, false, false
+			for ; i < len(args); i++ {
+				if args[i] == "INCR" {
+					incr = true
+				} else if args[i] == "XX" {
+					xx = true
+				} else if _, err := strconv.ParseFloat(args[i], 64); err == nil {
+					break
+				}
+			}
+			added := 0
+			for ; i+1 < len(args); i += 2 {
+				score, _ := strconv.ParseFloat(args[i], 64)
+				_, exists := scores[args[i+1]]
+				if xx && !exists {
+					continue
+				}
+				if incr {
+					scores[args[i+1]] += score
+					return scores[args[i+1]]
+				}
+				if !exists {
+					added++
+				}
+				scores[args[i+1]] = score
+			}
+			return added
+		case "ZRANGE":
+			members := make([]string, 0, len(scores))
+			for m := range scores {
+				members = append(members, m)
+			}
+			sort.Slice(members, func(i, j int) bool { return scores[members[i]] < scores[members[j]] })
+			res := []any{}
+			for _, m := range members {
+				res = append(res, m)
+				if args[len(args)-1] == "WITHSCORES" {
+					res = append(res, scores[m])
+				}
+			}
+			return res
+		}
+		return nil
+	})
+	ctx := context.Background()
+	key := map[string]any{"keyName": "rank"}
+
+	added := client.ZAddWithOptions(ctx, ZSetScoresCmd, key, ZAddOptions{Mode: SetModeNX}, redis.Z{Score: 3, Member: "c"}, redis.Z{Score: 1.5, Member: "a"}).Int()
+	if added.Err() != nil || added.Val() != 2 {
+		t.Fatalf("ZAddWithOptions expected 2, got %d %v", added.Val(), added.Err())
+	}
+	updated := client.ZAddWithOptions(ctx, ZSetScoresCmd, key, ZAddOptions{Mode: SetModeXX, Compare: ZAddGT, CH: true}, redis.Z{Score: 4, Member: "c"}, redis.Z{Score: 9, Member: "x"}).Int()
+	if updated.Err() != nil {
+		t.Fatalf("ZAddWithOptions failed: %v", updated.Err())
+	}
+	incr := client.ZAddWithOptions(ctx, ZSetScoresCmd, key, ZAddOptions{Incr: true}, redis.Z{Score: 0.5, Member: "a"}).Float()
+	if incr.Err() != nil || incr.Val() != 2 {
+		t.Errorf("ZAddWithOptions INCR expected 2, got %v %v", incr.Val(), incr.Err())
+	}
+
+	zs := client.ZRangeWithScores(ctx, ZSetScoresCmd, map[string]any{"keyName": "rank", "start": 0, "stop": -1}).ZSlice()
+	want := []redis.Z{{Score: 2, Member: "a"}, {Score: 4, Member: "c"}}
+	if zs.Err() != nil || !reflect.DeepEqual(zs.Val(), want) {
+		t.Errorf("Expected %v, got %v %v", want, zs.Val(), zs.Err())
+	}
+
+	wantCmds := [][]string{
+		{"ZADD", "zset:rank", "NX", "3", "c", "1.5", "a"},
+		{"ZADD", "zset:rank", "XX", "GT", "CH", "4", "c", "9", "x"},
+		{"ZADD", "zset:rank", "INCR", "0.5", "a"},
+		{"ZRANGE", "zset:rank", "0", "-1", "WITHSCORES"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, wantCmds) {
+		t.Errorf("Expected %v, got %v", wantCmds, got)
+	}
+}
+
+// TestRedisClient_ZScan 测试 ZScan 解析成员和分数, 分数不合法时返回错误
+func TestRedisClient_ZScan(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] != "ZSCAN" {
+			return nil
+		}
+		if args[1] == "rank:bad" {
+			return []any{"0", []any{"a", "x"}}
+		}
+		if args[2] == "0" {
+			return []any{"3", []any{"a", "1.5", "b", "-2"}}
+		}
+		return []any{"0", []any{"c", "inf"}}
+	})
+	cmd := RdCmd{Key: "rank:{{id}}"}
+	ctx := context.Background()
+
+	var entries []ZSetEntry
+	iter := client.ZScan(ctx, cmd, map[string]any{"id": 1}, "", 0)
+	for iter.Next() {
+		entries = append(entries, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("ZScan failed: %v", err)
+	}
+	want := []ZSetEntry{{"a", 1.5}, {"b", -2}, {"c", math.Inf(1)}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Expected %v, got %v", want, entries)
+	}
+	wantCmds := [][]string{{"ZSCAN", "rank:1", "0"}, {"ZSCAN", "rank:1", "3"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, wantCmds) {
+		t.Errorf("Expected %v, got %v", wantCmds, got)
+	}
+
+	iter = client.ZScan(ctx, cmd, map[string]any{"id": "bad"}, "", 0)
+	if iter.Next() || iter.Err() == nil {
+		t.Errorf("Expected invalid score error")
+	}
+}
+
+// TestRedisClient_ZMPop 测试 ZMPOP 的 numkeys 和 COUNT 参数以及 key 和成员的解析
+func TestRedisClient_ZMPop(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "ZMPOP" {
+			return []any{"rank:b", []any{[]any{"tom", "1.5"}, []any{"amy", "2"}}}
+		}
+		return nil
+	})
+
+	cmd := RdCmd{
+		CMD: map[Command]RdSubCmd{
+			ZMPOP: {Params: "{{numkeys}} {{keys}} MIN COUNT {{count}}", NoUseKey: true},
+		},
+	}
+
+	key, members, err := client.ZMPop(context.Background(), cmd, map[string]any{"numkeys": 2, "keys": []string{"rank:a", "rank:b"}, "count": 2}).ZSliceWithKey().Result()
+	if err != nil {
+		t.Fatalf("ZMPop failed: %v", err)
+	}
+	want := []redis.Z{{Member: "tom", Score: 1.5}, {Member: "amy", Score: 2}}
+	if key != "rank:b" || !reflect.DeepEqual(members, want) {
+		t.Errorf("Expected rank:b %v, got %s %v", want, key, members)
+	}
+	wantCmds := [][]string{{"ZMPOP", "2", "rank:a", "rank:b", "MIN", "COUNT", "2"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, wantCmds) {
+		t.Errorf("Expected %v, got %v", wantCmds, got)
+	}
+}
+
+// TestRedisClient_BZPopMin 测试 BZPOPMIN/BZPOPMAX 的参数顺序和结果解析
+func TestRedisClient_BZPopMin(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "BZPOPMIN" || args[0] == "BZPOPMAX" {
+			return []any{"rank:a", "tom", "1.5"}
+		}
+		return nil
+	})
+	ctx := context.Background()
+	cmd := RdCmd{
+		Key: "rank:{{name}}",
+		CMD: map[Command]RdSubCmd{
+			BZPOPMIN: {Params: "{{keys}} {{timeout}}", NoUseKey: true},
+			BZPOPMAX: {Params: "{{timeout}}"},
+		},
+	}
+
+	z, err := client.BZPopMin(ctx, cmd, map[string]any{"keys": []string{"rank:a", "rank:b"}, "timeout": 2}).ZWithKey().Result()
+	if err != nil {
+		t.Fatalf("BZPopMin failed: %v", err)
+	}
+	if z.Key != "rank:a" || z.Member != "tom" || z.Score != 1.5 {
+		t.Errorf("Unexpected result: %+v", z)
+	}
+	if err := client.BZPopMax(ctx, cmd, map[string]any{"name": "a", "timeout": 0}).ZWithKey().Err(); err != nil {
+		t.Fatalf("BZPopMax failed: %v", err)
+	}
+	want := [][]string{
+		{"BZPOPMIN", "rank:a", "rank:b", "2"},
+		{"BZPOPMAX", "rank:a", "0"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
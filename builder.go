@@ -3,37 +3,218 @@ package rdb
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // RedisCmdDef 代表一个 Redis 命令的配置结构体
 type RdSubCmd struct {
 	CmdName        string //真正的 命令名, 当这个存在的时候就不会使用上层map的key作为命令名; 作用是检出同一个key对于同一个命令的不同参数的应对
-	Params         string // 这里的数据 最后都会转化为 字符串数组， 数字也会变成字符串的， 一定要注意下
+	Params         string // 这里的数据 最后都会转化为 字符串数组， 数字也会变成字符串的， 一定要注意下; 单独的 {{xxx}} 的值是 slice/map 时会展开成多个参数; {{xxx|json}}、{{xxx|base64}}、{{xxx|hex}} 会先编码, {{xxx|prec:N}}、{{xxx|f:N}}、{{xxx|g}} 控制浮点数的格式
 	Exp            func() time.Duration
-	DefaultParams  map[string]any // 设置默认的参数
-	NoUseKey       bool           // 不使用外层的key
-	ReturnNilError bool           // 是否返回 redis的nil错误， 这个可以用来判断字段是不是在redis中， 批量操作的指令是不会有redis.nil错误的
+	ExpCondition   ExpireCondition // Exp 发送的 EXPIRE 的条件 NX/XX/GT/LT, 空表示不带条件
+	DefaultParams  map[string]any  // 设置默认的参数
+	NoUseKey       bool            // 不使用外层的key, 同一个 RdCmd 的 Key 不为空也不会加到命令中, 也不会发送 Exp 的 EXPIRE; 这时 Params 中单独的 {{key}} 会替换成外层的 key(Exp 对它生效), 用于 key 不在命令名之后的命令, 如 OBJECT ENCODING {{key}}
+	ReturnNilError bool            // 是否返回 redis的nil错误， 这个可以用来判断字段是不是在redis中， 批量操作的指令是不会有redis.nil错误的
+	StrictArgs     bool            // 严格模式, 模板中有未提供的参数时构建失败, 而不是把 {{xxx}} 原样发送到 redis; 等同于 OnMissing: MissingError
+	OnMissing      MissingPolicy   // args 和 DefaultParams 都没有提供占位符的值时的处理方式, 默认原样发送 {{xxx}}
+	RequiredParams []string        // 必须提供的参数, 合并默认参数之后 args 中还缺少时构建失败, 错误中列出所有缺少的参数
+	IncludeArity   int             // 大于 0 时 includeArgs 的个数必须是它的整数倍, 如 field value 成对传入时为 2, 个数不对时构建失败; 0 不校验
+	Timeout        time.Duration   // 大于 0 时在调用方的 ctx 上再加一个超时, 只对直接执行的命令生效, pipeline 中使用 Exec 的 ctx; 自己传入 redis.Options 时需要开启 ContextTimeoutEnabled
+	AtomicExpire   bool            // 设置了 Exp 时, 主命令和 EXPIRE 放在同一个 MULTI/EXEC 中一次发送, 默认是主命令执行之后再单独发送 EXPIRE; 事务不会按 RedisClient.Retry 重试
+	// Fallback 可选, 用于读命令的降级: 直接执行的命令被熔断(ErrCircuitOpen)或者超时时调用, 返回值作为命令的结果
+	// 返回 nil 值相当于 redis 返回了 nil; Fallback 返回错误时保留原来的错误
+	Fallback func(ctx context.Context, key string) (any, error)
+}
+
+// MissingPolicy 模板中的占位符没有提供值时的处理方式
+type MissingPolicy int
+
+const (
+	MissingLiteral MissingPolicy = iota // 原样保留 {{xxx}}
+	MissingEmpty                        // 替换成空字符串
+	MissingError                        // 构建失败, 和 StrictArgs 一样
+)
+
+// missingPolicy 返回子命令实际使用的 MissingPolicy, StrictArgs 优先
+func (s RdSubCmd) missingPolicy() MissingPolicy {
+	if s.StrictArgs {
+		return MissingError
+	}
+	return s.OnMissing
 }
 
 // RedisCmdBuilder 用于构建 Redis 命令的结构体
+// Key 中需要 cluster hash tag 时用三层大括号, 如: "user:{{{id}}}:cart" 构造成 "user:{1}:cart", 外层的 { } 原样保留; Params 中同样适用
 type RdCmd struct {
 	Key string
 	CMD map[Command]RdSubCmd
+	// HashTag 可选, Key 中作为 cluster hash tag 的占位符名, 构造时它的值两边加上 { }, 如: Key: "user:{{id}}:cart", HashTag: "id" 构造成 "user:{1}:cart"
+	// 和三层大括号的写法等价, 同一个 tag 的 key 在同一个 slot, 可以在集群中一起用于 MGET、事务和 Lua 脚本
+	HashTag string
+}
+
+// keyTemplate 返回实际使用的 key 模板, 设置了 HashTag 时把 Key 中第一个 {{HashTag}} 换成 {{{HashTag}}}
+func (c RdCmd) keyTemplate() string {
+	if c.HashTag == "" {
+		return c.Key
+	}
+	placeholder := "{{" + c.HashTag + "}}"
+	if strings.Contains(c.Key, "{"+placeholder+"}") {
+		return c.Key
+	}
+	return strings.Replace(c.Key, placeholder, "{"+placeholder+"}", 1)
 }
 
-// Build 构造 Redis 命令参数
+// withCmd 复制 c 并把 CMD 换成只有 cmdName 一个子命令, Key 和 HashTag 保持不变, 用于辅助方法从调用方的 cmd 派生命令
+func (c RdCmd) withCmd(cmdName Command, sub RdSubCmd) RdCmd {
+	c.CMD = map[Command]RdSubCmd{cmdName: sub}
+	return c
+}
+
+// ErrUnknownCommand RdCmd.CMD 中没有要构建的子命令, Build 等会 panic 的方法 panic 的值也是它
+// 可以通过 errors.As 或者 recover 之后的类型断言取出缺少的命令名
+type ErrUnknownCommand struct {
+	Command Command
+}
+
+func (e ErrUnknownCommand) Error() string {
+	return fmt.Sprintf("unknown command: %s", e.Command)
+}
+
+// Build 构造 Redis 命令参数, 构建失败时会 panic
 func Build(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) ([]any, string, RdSubCmd) {
-	if args == nil {
-		args = map[string]any{}
+	cmdList, keyStr, subCmd, err := TryBuild(ctx, cmd, cmdName, args, includeArgs...)
+	if err != nil {
+		panic(err)
 	}
+	return cmdList, keyStr, subCmd
+}
+
+// TryBuild 构造 Redis 命令参数, 和 Build 一样, 但是构建失败时返回 error 而不是 panic
+// StrictArgs 的子命令在模板中有没有提供的参数时会返回错误, 错误中列出所有未解析的占位符
+func TryBuild(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) ([]any, string, RdSubCmd, error) {
+	subCmd, ok := cmd.CMD[cmdName]
+	if !ok {
+		return nil, "", subCmd, ErrUnknownCommand{Command: cmdName}
+	}
+	args = mergeContextArgs(ctx, args)
+	cmdArgs, keyStr, err := buildWithTokens(cmd, cmdName, subCmd, tokenizeParams(subCmd.Params), args, includeArgs)
+	if err != nil {
+		return nil, "", subCmd, err
+	}
+	return cmdArgs, keyStr, subCmd, nil
+}
+
+// defaultArgsKey context 中保存默认参数的 key
+type defaultArgsKey struct{}
+
+// WithDefaultArgs 返回带有默认参数的 context, 用这个 context 构造命令时, 没有在 args 中提供的参数使用这里的值
+// 优先级: args > context 中的默认参数 > RdSubCmd.DefaultParams; 多次调用时合并, 内层的值覆盖外层的值
+// 适合每个请求都相同的参数, 如: 租户 ID, 不需要每次调用都传入
+func WithDefaultArgs(ctx context.Context, defaults map[string]any) context.Context {
+	if parent, ok := ctx.Value(defaultArgsKey{}).(map[string]any); ok {
+		merged := maps.Clone(parent)
+		maps.Copy(merged, defaults)
+		defaults = merged
+	} else {
+		defaults = maps.Clone(defaults)
+	}
+	return context.WithValue(ctx, defaultArgsKey{}, defaults)
+}
+
+// mergeContextArgs 合并 context 中的默认参数, 返回新的 map, 不会修改 args; context 中没有默认参数时直接返回 args
+func mergeContextArgs(ctx context.Context, args map[string]any) map[string]any {
+	if ctx == nil {
+		return args
+	}
+	defaults, ok := ctx.Value(defaultArgsKey{}).(map[string]any)
+	if !ok || len(defaults) == 0 {
+		return args
+	}
+	merged := maps.Clone(defaults)
+	maps.Copy(merged, args)
+	return merged
+}
+
+// BuildMany 用同一个子命令批量构造命令参数, Params 模板只切分一次, argsList 中的每个参数 map 对应一条命令
+// 返回每条命令的参数和 key, 构建失败时和 Build 一样会 panic
+func BuildMany(ctx context.Context, cmd RdCmd, cmdName Command, argsList []map[string]any) ([][]any, []string, RdSubCmd) {
 	subCmd, ok := cmd.CMD[cmdName]
 	if !ok {
-		panic(fmt.Errorf("unknown command: %s", cmdName))
+		panic(ErrUnknownCommand{Command: cmdName})
+	}
+	tokens := tokenizeParams(subCmd.Params)
+	cmdLists := make([][]any, 0, len(argsList))
+	keys := make([]string, 0, len(argsList))
+	for _, args := range argsList {
+		cmdArgs, keyStr, err := buildWithTokens(cmd, cmdName, subCmd, tokens, mergeContextArgs(ctx, args), nil)
+		if err != nil {
+			panic(err)
+		}
+		cmdLists = append(cmdLists, cmdArgs)
+		keys = append(keys, keyStr)
+	}
+	return cmdLists, keys, subCmd
+}
+
+// paramToken 切分之后的一段 Params 模板
+type paramToken struct {
+	text        string
+	placeholder bool // 是否包含 {{xxx}} 占位符, 不包含时原样使用
+}
+
+// paramTokenCacheSize paramTokenCache 最多缓存的模板数量, 超过之后新的模板每次都重新切分, 不再缓存
+const paramTokenCacheSize = 1024
+
+// paramTokenCache Params 模板 => []paramToken, Params 一般是静态定义的, 切分结果缓存之后只读共享
+// 动态拼出来的 Params 会让 key 无限增长, 所以缓存数量由 paramTokenCount 限制在 paramTokenCacheSize 以内
+var (
+	paramTokenCache sync.Map
+	paramTokenCount atomic.Int64
+)
+
+// tokenizeParams 按空格切分 Params 模板, 同一个模板只会切分一次, 返回的切片不能修改
+func tokenizeParams(params string) []paramToken {
+	if params == "" {
+		return nil
+	}
+	if cached, ok := paramTokenCache.Load(params); ok {
+		return cached.([]paramToken)
+	}
+	texts := strings.Split(replaceMultiSpaceWithSingle(params), " ")
+	tokens := make([]paramToken, len(texts))
+	for i, text := range texts {
+		tokens[i] = paramToken{text: text, placeholder: strings.Contains(text, "{{")}
+	}
+	if paramTokenCount.Add(1) > paramTokenCacheSize {
+		paramTokenCount.Add(-1)
+		return tokens
+	}
+	cached, loaded := paramTokenCache.LoadOrStore(params, tokens)
+	if loaded {
+		paramTokenCount.Add(-1)
+	}
+	return cached.([]paramToken)
+}
+
+// buildWithTokens 使用切分好的模板构造命令参数
+func buildWithTokens(cmd RdCmd, cmdName Command, subCmd RdSubCmd, tokens []paramToken, args map[string]any, includeArgs []any) ([]any, string, error) {
+	if subCmd.IncludeArity > 0 && len(includeArgs)%subCmd.IncludeArity != 0 {
+		return nil, "", fmt.Errorf("rdb: %s expects includeArgs in groups of %d, got %d", cmdName, subCmd.IncludeArity, len(includeArgs))
+	}
+	if args == nil {
+		args = map[string]any{}
 	}
 	// 填充默认参数
 	for k, v := range subCmd.DefaultParams {
@@ -42,30 +223,147 @@ func Build(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any,
 		}
 	}
 
-	paramsStr := []any{}
-	if subCmd.Params != "" {
-		tempData := strings.Split(replaceMultiSpaceWithSingle(subCmd.Params), " ")
-		for _, v := range tempData {
-			paramsStr = append(paramsStr, string(highPerfReplace([]byte(v), args)))
-		}
+	if missing := missingRequired(subCmd, func(name string) bool { _, ok := args[name]; return ok }); len(missing) > 0 {
+		return nil, "", fmt.Errorf("rdb: %s missing required params: %s", cmdName, strings.Join(missing, ", "))
 	}
 
-	// 构造 key
-	keyStr := cmd.Key
-	if !subCmd.NoUseKey {
-		keyStr = string(highPerfReplace([]byte(cmd.Key), args))
+	policy := subCmd.missingPolicy()
+	if policy == MissingEmpty {
+		args = fillMissingArgs(cmd, subCmd, tokens, args)
+	}
+
+	// 构造 key, NoUseKey 时不使用外层的 key, 即使 RdCmd.Key 不为空也不加到命令中, key 通过 Params 传入
+	var unresolved []string
+	keyStr := ""
+	bindKey := keyInParams(cmd, subCmd, tokens)
+	if !subCmd.NoUseKey || bindKey {
+		key, missing, err := replaceTemplate([]byte(cmd.keyTemplate()), args)
+		if err != nil {
+			return nil, "", fmt.Errorf("rdb: %s key: %w", cmdName, err)
+		}
+		keyStr = string(key)
+		unresolved = append(unresolved, missing...)
 	}
 
 	// 构造参数
-	cmdArgs := []any{string(cmdName)}
-	if keyStr != "" {
+	cmdArgs := make([]any, 0, 2+len(tokens)+len(includeArgs))
+	cmdArgs = append(cmdArgs, string(cmdName))
+	if keyStr != "" && !bindKey {
 		cmdArgs = append(cmdArgs, keyStr)
 	}
-	cmdArgs = append(cmdArgs, paramsStr...)
-	if len(includeArgs) > 0 {
-		cmdArgs = append(cmdArgs, includeArgs...)
+	for _, token := range tokens {
+		if !token.placeholder {
+			cmdArgs = append(cmdArgs, token.text)
+			continue
+		}
+		if bindKey && token.text == keyPlaceholder {
+			cmdArgs = append(cmdArgs, keyStr)
+			continue
+		}
+		if params, missing, ok := expandParam(token.text, args); ok {
+			cmdArgs = append(cmdArgs, params...)
+			unresolved = append(unresolved, missing...)
+			continue
+		}
+		param, missing, err := replaceTemplate([]byte(token.text), args)
+		if err != nil {
+			return nil, "", fmt.Errorf("rdb: %s: %w", cmdName, err)
+		}
+		cmdArgs = append(cmdArgs, string(param))
+		unresolved = append(unresolved, missing...)
+	}
+	if policy == MissingError && len(unresolved) > 0 {
+		return nil, "", fmt.Errorf("rdb: %s has unresolved placeholders: %s", cmdName, strings.Join(unresolved, ", "))
+	}
+	cmdArgs = append(cmdArgs, includeArgs...)
+	return cmdArgs, keyStr, nil
+}
+
+// templatePart 编译后的模板片段, key 为空时是常量文本, 否则是 {{key}} 占位符
+type templatePart struct {
+	text string
+	key  string
+}
+
+// compileTemplate 把模板切分成常量文本和占位符, 切分规则和 replaceTemplate 一致
+func compileTemplate(template string) []templatePart {
+	var parts []templatePart
+	for template != "" {
+		start := strings.Index(template, "{{")
+		if start == -1 {
+			break
+		}
+		for start+2 < len(template) && template[start+2] == '{' {
+			start++ // 多余的 '{' 是常量文本, 如 cluster hash tag {{{id}}}
+		}
+		end := strings.Index(template[start:], "}}")
+		if end == -1 {
+			break
+		}
+		if start > 0 {
+			parts = append(parts, templatePart{text: template[:start]})
+		}
+		parts = append(parts, templatePart{key: template[start+2 : start+end]})
+		template = template[start+end+2:]
+	}
+	if template != "" {
+		parts = append(parts, templatePart{text: template})
 	}
-	return cmdArgs, keyStr, subCmd
+	return parts
+}
+
+// fillMissingArgs MissingEmpty 时给 key 和 Params 模板中没有提供值的占位符填充空字符串, 返回新的 map
+func fillMissingArgs(cmd RdCmd, subCmd RdSubCmd, tokens []paramToken, args map[string]any) map[string]any {
+	filled := maps.Clone(args)
+	fill := func(template string) {
+		for _, part := range compileTemplate(template) {
+			if part.key == "" {
+				continue
+			}
+			name := placeholderName(part.key)
+			if _, ok := filled[name]; !ok {
+				filled[name] = ""
+			}
+		}
+	}
+	bindKey := keyInParams(cmd, subCmd, tokens)
+	if !subCmd.NoUseKey || bindKey {
+		fill(cmd.keyTemplate())
+	}
+	for _, token := range tokens {
+		if token.placeholder && !(bindKey && token.text == keyPlaceholder) {
+			fill(token.text)
+		}
+	}
+	return filled
+}
+
+// missingRequired 返回 RequiredParams 中没有提供的参数
+func missingRequired(subCmd RdSubCmd, provided func(name string) bool) []string {
+	var missing []string
+	for _, name := range subCmd.RequiredParams {
+		if !provided(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// keyPlaceholder NoUseKey 时 Params 中代表外层 key 的占位符
+const keyPlaceholder = "{{key}}"
+
+// keyInParams NoUseKey 的子命令是否在 Params 中用单独的 {{key}} 指定了外层 key 的位置
+// 外层 Key 为空时 {{key}} 还是从 args 中取值
+func keyInParams(cmd RdCmd, subCmd RdSubCmd, tokens []paramToken) bool {
+	if !subCmd.NoUseKey || cmd.Key == "" {
+		return false
+	}
+	for _, token := range tokens {
+		if token.text == keyPlaceholder {
+			return true
+		}
+	}
+	return false
 }
 
 func replaceMultiSpaceWithSingle(s string) string {
@@ -76,55 +374,33 @@ func replaceMultiSpaceWithSingle(s string) string {
 }
 
 func highPerfReplace(template []byte, replacements map[string]any) []byte {
+	result, _, _ := replaceTemplate(template, replacements)
+	return result
+}
+
+// replaceTemplate 替换模板中的 {{xxx}} 占位符, 同时返回没有被替换的占位符名
+// 占位符可以带修饰符, 如 {{payload|json}}, 修饰符处理失败时返回 error
+func replaceTemplate(template []byte, replacements map[string]any) ([]byte, []string, error) {
 	var result []byte
-	buf := make([]byte, 0, 16)
+	var missing []string
 
 	i := 0
 	for i < len(template) {
-		// 查找 '{{' 和 '}}' 分隔的占位符
-		if i+1 < len(template) && template[i] == '{' && template[i+1] == '{' {
+		// 查找 '{{' 和 '}}' 分隔的占位符, 连续多个 '{' 时只有最后两个是占位符的开始, 前面的原样保留, 用于 cluster hash tag
+		if i+1 < len(template) && template[i] == '{' && template[i+1] == '{' && (i+2 >= len(template) || template[i+2] != '{') {
 			end := bytes.Index(template[i:], []byte("}}"))
 			if end == -1 {
 				result = append(result, template[i:]...)
 				break
 			}
 			key := string(template[i+2 : i+end])
-			if val, found := replacements[key]; found {
-				// 根据类型进行处理
-				switch v := val.(type) {
-				case string:
-					result = append(result, []byte(v)...)
-				case int:
-					result = append(result, []byte(strconv.Itoa(v))...)
-				case int64:
-					result = append(result, []byte(strconv.FormatInt(v, 10))...)
-				case int32:
-					result = append(result, []byte(strconv.FormatInt(int64(v), 10))...)
-				case float64:
-					result = append(result, strconv.AppendFloat(buf[:0], float64(v), 'f', -1, 64)...)
-				case float32:
-					result = append(result, strconv.AppendFloat(buf[:0], float64(v), 'f', -1, 64)...)
-				case bool:
-					result = append(result, []byte(strconv.FormatBool(v))...)
-				case []int:
-					result = append(result, []byte(IntSliceToString(v, " "))...)
-				case []int64:
-					result = append(result, []byte(IntSliceToString(v, " "))...)
-				case []int32:
-					result = append(result, []byte(IntSliceToString(v, " "))...)
-				case []string:
-					result = append(result, []byte(StringSliceToString(v, " "))...)
-				case []float32:
-					result = append(result, []byte(FloatSliceToString(v, " ", -1))...)
-				case []float64:
-					result = append(result, []byte(FloatSliceToString(v, " ", -1))...)
-				default:
-					// 如果类型不匹配，保留原始占位符
-					result = append(result, []byte(fmt.Sprintf("{{%s}}", key))...)
-				}
-			} else {
-				// 如果没有找到对应的值，则保留原始占位符
-				result = append(result, template[i:i+end+4]...)
+			val, found := replacements[placeholderName(key)]
+			var ok bool
+			var err error
+			if result, ok, err = appendPlaceholder(result, key, val, found); err != nil {
+				return nil, nil, err
+			} else if !ok {
+				missing = append(missing, key)
 			}
 			i += end + 2 // 跳过 '}}'
 		} else {
@@ -132,7 +408,235 @@ func highPerfReplace(template []byte, replacements map[string]any) []byte {
 			i++
 		}
 	}
-	return result
+	return result, missing, nil
+}
+
+// placeholderName 去掉占位符的修饰符, 如 payload|json => payload
+func placeholderName(key string) string {
+	name, _, _ := strings.Cut(key, "|")
+	return name
+}
+
+// appendPlaceholder 把占位符 key 的值追加到 dst, 没有找到值或者类型不支持时保留原始占位符并返回 false
+// key 带修饰符时先按修饰符转换值:
+//
+//	json: json.Marshal 之后的内容, 如 {{payload|json}}
+//	base64: string 或 []byte 按 base64.StdEncoding 编码, 如 {{data|base64}}
+//	hex: string 或 []byte 按小写十六进制编码, 如 {{data|hex}}
+//	prec:N: 浮点数和浮点数 slice 保留 N 位小数, 不会使用科学计数法, 如 {{coords|prec:6}}; 其他类型的值不受影响
+//	f:N、e:N、g:N: 浮点数按 strconv.FormatFloat 的格式和精度输出, 如 {{score|f:2}}; 省略 :N 时使用最短的精度, 如 {{big|g}}
+func appendPlaceholder(dst []byte, key string, val any, found bool) ([]byte, bool, error) {
+	if found {
+		_, modifier, _ := strings.Cut(key, "|")
+		switch modifier {
+		case "":
+			if buf, ok := appendParamValue(dst, val); ok {
+				return buf, true, nil
+			}
+		case "json":
+			data, err := json.Marshal(val)
+			if err != nil {
+				return dst, false, fmt.Errorf("marshal {{%s}}: %w", key, err)
+			}
+			return append(dst, data...), true, nil
+		case "base64", "hex":
+			var data []byte
+			switch v := val.(type) {
+			case []byte:
+				data = v
+			case string:
+				data = []byte(v)
+			default:
+				return dst, false, fmt.Errorf("{{%s}} needs a string or []byte value, got %T", key, val)
+			}
+			if modifier == "hex" {
+				return hex.AppendEncode(dst, data), true, nil
+			}
+			return base64.StdEncoding.AppendEncode(dst, data), true, nil
+		default:
+			format, prec, ok := floatFormat(modifier)
+			if !ok {
+				return dst, false, fmt.Errorf("unknown modifier in {{%s}}", key)
+			}
+			if buf, ok := appendFloatParam(dst, val, format, prec); ok {
+				return buf, true, nil
+			}
+		}
+	}
+	dst = append(dst, "{{"...)
+	dst = append(dst, key...)
+	return append(dst, "}}"...), false, nil
+}
+
+// floatFormat 解析浮点数格式的修饰符, prec:N 等同于 f:N
+func floatFormat(modifier string) (byte, int, bool) {
+	name, n, hasPrec := strings.Cut(modifier, ":")
+	var format byte
+	switch name {
+	case "prec", "f":
+		format = 'f'
+	case "e", "g":
+		format = name[0]
+	default:
+		return 0, 0, false
+	}
+	if !hasPrec {
+		// prec 必须指定位数
+		return format, -1, name != "prec"
+	}
+	prec, err := strconv.Atoi(n)
+	if err != nil || prec < 0 {
+		return 0, 0, false
+	}
+	return format, prec, true
+}
+
+// appendFloatParam 按照指定的格式和精度追加浮点数以及浮点数 slice, 其他类型和 appendParamValue 一样
+func appendFloatParam(dst []byte, val any, format byte, prec int) ([]byte, bool) {
+	switch v := val.(type) {
+	case float64:
+		return strconv.AppendFloat(dst, v, format, prec, 64), true
+	case float32:
+		return strconv.AppendFloat(dst, float64(v), format, prec, 64), true
+	case []float32:
+		return appendFloatSlice(dst, v, format, prec), true
+	case []float64:
+		return appendFloatSlice(dst, v, format, prec), true
+	}
+	return appendParamValue(dst, val)
+}
+
+// appendFloatSlice 用空格拼接浮点数 slice
+func appendFloatSlice[T float32 | float64](dst []byte, slice []T, format byte, prec int) []byte {
+	for i, v := range slice {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = strconv.AppendFloat(dst, float64(v), format, prec, 64)
+	}
+	return dst
+}
+
+// appendParamValue 根据类型把参数值追加到 dst, 不支持的类型返回 false
+func appendParamValue(dst []byte, val any) ([]byte, bool) {
+	switch v := val.(type) {
+	case string:
+		return append(dst, v...), true
+	case []byte:
+		// redis 的值是二进制安全的, 原样追加
+		return append(dst, v...), true
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(dst, v, 10), true
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10), true
+	case float64:
+		return strconv.AppendFloat(dst, v, 'f', -1, 64), true
+	case float32:
+		return strconv.AppendFloat(dst, float64(v), 'f', -1, 64), true
+	case bool:
+		return strconv.AppendBool(dst, v), true
+	case []int:
+		return append(dst, IntSliceToString(v, " ")...), true
+	case []int64:
+		return append(dst, IntSliceToString(v, " ")...), true
+	case []int32:
+		return append(dst, IntSliceToString(v, " ")...), true
+	case []string:
+		return append(dst, StringSliceToString(v, " ")...), true
+	case []float32:
+		return append(dst, FloatSliceToString(v, " ", -1)...), true
+	case []float64:
+		return append(dst, FloatSliceToString(v, " ", -1)...), true
+	}
+	return dst, false
+}
+
+// expandParam 参数模板只有一个占位符且值是 slice 或者 map 时, 展开成多个参数
+// slice 按顺序展开, 如: {{fields}} + []string{"a", "b"} => "a" "b"
+// map 按 key 排序后展开成 key value 对, 如: {{pairs}} + map[string]any{"b": 2, "a": 1} => "a" "1" "b" "2"
+// 其他情况返回 false, 按普通模板处理; 占位符前后有其他字符时 slice 仍然用空格拼接成一个参数
+func expandParam(token string, args map[string]any) ([]any, []string, bool) {
+	key, ok := wholePlaceholder(token)
+	if !ok || !expandableModifier(key) {
+		return nil, nil, false
+	}
+	val, found := args[placeholderName(key)]
+	if !found {
+		return nil, nil, false
+	}
+	return expandValue(token, key, val)
+}
+
+// expandableModifier 占位符没有修饰符或者是浮点数格式的修饰符时, slice/map 的值可以展开成多个参数, 每个元素分别格式化
+// json 之类的修饰符作用于整个值, 不展开
+func expandableModifier(key string) bool {
+	_, modifier, ok := strings.Cut(key, "|")
+	if !ok {
+		return true
+	}
+	_, _, ok = floatFormat(modifier)
+	return ok
+}
+
+// wholePlaceholder 参数模板是单独的一个 {{xxx}} 时返回占位符名
+func wholePlaceholder(token string) (string, bool) {
+	if !strings.HasPrefix(token, "{{") || !strings.HasSuffix(token, "}}") || strings.ContainsAny(token[2:len(token)-2], "{}") {
+		return "", false
+	}
+	return token[2 : len(token)-2], true
+}
+
+// expandValue 把 slice 或者 map 类型的占位符值展开成多个参数, 其他类型返回 false
+func expandValue(token, key string, val any) ([]any, []string, bool) {
+	var items []any
+	switch v := val.(type) {
+	case []string:
+		items = toAnySlice(v)
+	case []any:
+		items = v
+	case []int:
+		items = toAnySlice(v)
+	case []int64:
+		items = toAnySlice(v)
+	case []int32:
+		items = toAnySlice(v)
+	case []float64:
+		items = toAnySlice(v)
+	case []float32:
+		items = toAnySlice(v)
+	case map[string]string:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			items = append(items, k, v[k])
+		}
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			items = append(items, k, v[k])
+		}
+	default:
+		return nil, nil, false
+	}
+
+	params := make([]any, 0, len(items))
+	var missing []string
+	for _, item := range items {
+		param, ok, _ := appendPlaceholder(nil, key, item, true)
+		if !ok {
+			param = []byte(token)
+			missing = append(missing, key)
+		}
+		params = append(params, string(param))
+	}
+	return params, missing, true
+}
+
+func toAnySlice[T any](values []T) []any {
+	items := make([]any, len(values))
+	for i, v := range values {
+		items[i] = v
+	}
+	return items
 }
 
 // 快速版本：[]int → string
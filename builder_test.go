@@ -1,7 +1,14 @@
 package rdb
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -23,3 +30,662 @@ func Test_highPerfReplace(t *testing.T) {
 	// 输出替换结果
 	fmt.Println(string(result))
 }
+
+// TestTryBuild_StrictArgs 测试严格模式下未提供的占位符会导致构建失败
+func TestTryBuild_StrictArgs(t *testing.T) {
+	var StrictCmd = RdCmd{
+		Key: "strict:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {
+				Params:     "{{value}} EX {{seconds}}",
+				StrictArgs: true,
+			},
+			GET: {},
+		},
+	}
+
+	_, _, _, err := TryBuild(context.Background(), StrictCmd, SET, map[string]any{"value": "v"})
+	if err == nil {
+		t.Fatalf("Expected error for unresolved placeholders")
+	}
+	if !strings.Contains(err.Error(), "seconds") || !strings.Contains(err.Error(), "keyName") {
+		t.Errorf("error should list all unresolved placeholders: %v", err)
+	}
+
+	cmdList, _, _, err := TryBuild(context.Background(), StrictCmd, SET, map[string]any{"keyName": "a", "value": "v", "seconds": 10})
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	if !reflect.DeepEqual(cmdList, []any{"SET", "strict:a", "v", "EX", "10"}) {
+		t.Errorf("unexpected command: %v", cmdList)
+	}
+
+	// 非严格模式保留原始占位符
+	cmdList, _, _, err = TryBuild(context.Background(), StrictCmd, GET, nil)
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	if !reflect.DeepEqual(cmdList, []any{"GET", "strict:{{keyName}}"}) {
+		t.Errorf("unexpected command: %v", cmdList)
+	}
+
+	if _, _, _, err := TryBuild(context.Background(), StrictCmd, DEL, nil); err == nil {
+		t.Errorf("Expected error for unknown command")
+	}
+}
+
+// TestExecuteCmd_StrictArgs 测试严格模式下构建失败的命令不会发送到 redis
+func TestExecuteCmd_StrictArgs(t *testing.T) {
+	client, fake := newFakeClient(t, nil)
+
+	var StrictCmd = RdCmd{
+		Key: "strict:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			GET: {StrictArgs: true},
+		},
+	}
+	cmd := client.Get(context.Background(), StrictCmd, nil).String()
+	if cmd.Err() == nil || !strings.Contains(cmd.Err().Error(), "keyName") {
+		t.Errorf("Expected unresolved placeholder error, got %v", cmd.Err())
+	}
+	if len(fake.Commands()) != 0 {
+		t.Errorf("command should not be sent: %v", fake.Commands())
+	}
+}
+
+// Test_highPerfReplace_Missing 测试没有提供的占位符原样保留
+func Test_highPerfReplace_Missing(t *testing.T) {
+	if got := string(highPerfReplace([]byte("{{foo}}bar"), nil)); got != "{{foo}}bar" {
+		t.Errorf("unexpected result: %q", got)
+	}
+	if got := string(highPerfReplace([]byte("a:{{foo}}"), nil)); got != "a:{{foo}}" {
+		t.Errorf("unexpected result: %q", got)
+	}
+}
+
+// TestTryBuild_ExpandParam 测试整段占位符的 slice/map 值展开成多个参数
+func TestTryBuild_ExpandParam(t *testing.T) {
+	cmd := RdCmd{
+		Key: "k:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			HMGET: {Params: "{{fields}}"},
+			HSET:  {Params: "{{pairs}}"},
+			SADD:  {Params: "prefix:{{members}}"},
+		},
+	}
+	ctx := context.Background()
+	tests := []struct {
+		name    Command
+		args    map[string]any
+		want    []any
+		missing bool
+	}{
+		{HMGET, map[string]any{"id": 1, "fields": []string{"a", "b"}}, []any{"HMGET", "k:1", "a", "b"}, false},
+		{HMGET, map[string]any{"id": 1, "fields": []int64{1, 2, 3}}, []any{"HMGET", "k:1", "1", "2", "3"}, false},
+		{HMGET, map[string]any{"id": 1, "fields": []any{"a", 1, 1.5}}, []any{"HMGET", "k:1", "a", "1", "1.5"}, false},
+		{HMGET, map[string]any{"id": 1, "fields": []string{}}, []any{"HMGET", "k:1"}, false},
+		{HMGET, map[string]any{"id": 1, "fields": []any{struct{}{}}}, []any{"HMGET", "k:1", "{{fields}}"}, true},
+		{HSET, map[string]any{"id": 1, "pairs": map[string]string{"b": "2", "a": "1"}}, []any{"HSET", "k:1", "a", "1", "b", "2"}, false},
+		{HSET, map[string]any{"id": 1, "pairs": map[string]any{"n": 1, "m": true}}, []any{"HSET", "k:1", "m", "true", "n", "1"}, false},
+		// 占位符前后有其他字符时不展开, 保持原来用空格拼接的行为
+		{SADD, map[string]any{"id": 1, "members": []string{"a", "b"}}, []any{"SADD", "k:1", "prefix:a b"}, false},
+	}
+	for _, tt := range tests {
+		cmd.CMD[tt.name] = RdSubCmd{Params: cmd.CMD[tt.name].Params, StrictArgs: true}
+		got, _, _, err := TryBuild(ctx, cmd, tt.name, tt.args)
+		if tt.missing {
+			if err == nil {
+				t.Errorf("%s %v: expected unresolved placeholder error", tt.name, tt.args)
+			}
+			cmd.CMD[tt.name] = RdSubCmd{Params: cmd.CMD[tt.name].Params}
+			got, _, _, _ = TryBuild(ctx, cmd, tt.name, tt.args)
+		} else if err != nil {
+			t.Errorf("%s %v: unexpected error %v", tt.name, tt.args, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %v: expected %v, got %v", tt.name, tt.args, tt.want, got)
+		}
+	}
+}
+
+// TestBuildMany 测试批量构造的结果和逐条 Build 一致
+func TestBuildMany(t *testing.T) {
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			HSET: {Params: "name {{name}}  age {{age}}"},
+		},
+	}
+	ctx := context.Background()
+	argsList := make([]map[string]any, 0, 10)
+	for i := 0; i < 10; i++ {
+		argsList = append(argsList, map[string]any{"id": i, "name": fmt.Sprintf("u%d", i), "age": 20 + i})
+	}
+
+	cmdLists, keys, subCmd := BuildMany(ctx, cmd, HSET, argsList)
+	if subCmd.Params != cmd.CMD[HSET].Params {
+		t.Errorf("unexpected sub command: %+v", subCmd)
+	}
+	for i, args := range argsList {
+		want, key, _ := Build(ctx, cmd, HSET, args)
+		if !reflect.DeepEqual(cmdLists[i], want) || keys[i] != key {
+			t.Errorf("command %d: expected %v %s, got %v %s", i, want, key, cmdLists[i], keys[i])
+		}
+	}
+}
+
+func benchmarkArgsList(n int) []map[string]any {
+	argsList := make([]map[string]any, 0, n)
+	for i := 0; i < n; i++ {
+		argsList = append(argsList, map[string]any{"id": i, "name": "user", "age": i % 100})
+	}
+	return argsList
+}
+
+var benchmarkBuildCmd = RdCmd{
+	Key: "user:{{id}}",
+	CMD: map[Command]RdSubCmd{
+		HSET: {Params: "name {{name}} age {{age}} source import"},
+	},
+}
+
+// BenchmarkBuildMany 批量构造 500 条命令, 模板只切分一次
+func BenchmarkBuildMany(b *testing.B) {
+	ctx := context.Background()
+	argsList := benchmarkArgsList(500)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		BuildMany(ctx, benchmarkBuildCmd, HSET, argsList)
+	}
+}
+
+// BenchmarkBuildLoop 循环调用 Build 构造 500 条命令, 用于和 BenchmarkBuildMany 对比
+func BenchmarkBuildLoop(b *testing.B) {
+	ctx := context.Background()
+	argsList := benchmarkArgsList(500)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, args := range argsList {
+			Build(ctx, benchmarkBuildCmd, HSET, args)
+		}
+	}
+}
+
+// TestTokenizeParams_Cache 测试同一个 Params 模板只切分一次并复用缓存结果
+func TestTokenizeParams_Cache(t *testing.T) {
+	params := "field  {{name}} EX {{ttl}}"
+	first := tokenizeParams(params)
+	want := []paramToken{{"field", false}, {"{{name}}", true}, {"EX", false}, {"{{ttl}}", true}}
+	if !reflect.DeepEqual(first, want) {
+		t.Fatalf("Expected %v, got %v", want, first)
+	}
+	if second := tokenizeParams(params); &second[0] != &first[0] {
+		t.Errorf("Expected cached tokens to be reused")
+	}
+	if tokenizeParams("") != nil {
+		t.Errorf("Expected nil tokens for empty params")
+	}
+}
+
+// TestTokenizeParams_CacheBound 测试缓存的模板数量不超过 paramTokenCacheSize, 超过之后仍然返回正确的切分结果
+func TestTokenizeParams_CacheBound(t *testing.T) {
+	for i := 0; i < paramTokenCacheSize+10; i++ {
+		params := fmt.Sprintf("bound%d {{v}}", i)
+		want := []paramToken{{fmt.Sprintf("bound%d", i), false}, {"{{v}}", true}}
+		if got := tokenizeParams(params); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+	if n := paramTokenCount.Load(); n > paramTokenCacheSize {
+		t.Errorf("Expected at most %d cached templates, got %d", paramTokenCacheSize, n)
+	}
+	cached := 0
+	paramTokenCache.Range(func(_, _ any) bool {
+		cached++
+		return true
+	})
+	if cached > paramTokenCacheSize {
+		t.Errorf("Expected at most %d cached templates, got %d", paramTokenCacheSize, cached)
+	}
+}
+
+// TestTryBuild_JSONModifier 测试 {{xxx|json}} 修饰符对 struct 和 map 的序列化以及序列化失败时的错误
+func TestTryBuild_JSONModifier(t *testing.T) {
+	type profile struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET:  {Params: "{{payload|json}}"},
+			HSET: {Params: "meta {{meta|json}} raw {{meta}}"},
+		},
+	}
+	ctx := context.Background()
+
+	args, _, _, err := TryBuild(ctx, cmd, SET, map[string]any{"id": 1, "payload": profile{Name: "tom", Tags: []string{"a b", "c"}}})
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	want := []any{"SET", "user:1", `{"name":"tom","tags":["a b","c"]}`}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected %v, got %v", want, args)
+	}
+
+	meta := map[string]any{"b": 2, "a": "x"}
+	args, _, _, err = TryBuild(ctx, cmd, HSET, map[string]any{"id": 2, "meta": meta})
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	want = []any{"HSET", "user:2", "meta", `{"a":"x","b":2}`, "raw", "a", "x", "b", "2"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected %v, got %v", want, args)
+	}
+	prepared, _ := PrepareCmd(cmd, HSET)
+	if got, _ := prepared.Build(context.Background(), map[string]any{"id": 2, "meta": meta}); !reflect.DeepEqual(got, want) {
+		t.Errorf("PreparedCmd expected %v, got %v", want, got)
+	}
+
+	_, _, _, err = TryBuild(ctx, cmd, SET, map[string]any{"id": 3, "payload": map[string]any{"ch": make(chan int)}})
+	if err == nil || !strings.Contains(err.Error(), "payload|json") {
+		t.Errorf("Expected marshal error, got %v", err)
+	}
+	if _, _, err := prepared.TryBuild(context.Background(), map[string]any{"id": 3, "meta": func() {}}); err == nil {
+		t.Errorf("Expected marshal error from PreparedCmd")
+	}
+}
+
+// TestTryBuild_EncodingModifier 测试 {{xxx|base64}} 和 {{xxx|hex}} 编码二进制数据后可以还原
+func TestTryBuild_EncodingModifier(t *testing.T) {
+	cmd := RdCmd{
+		Key: "blob:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET:  {Params: "{{data|base64}}"},
+			HSET: {Params: "digest {{data|hex}} name {{name|base64}}"},
+		},
+	}
+	ctx := context.Background()
+	data := []byte{0x00, 0xff, 0x10, '\n', ' ', 0x80, 'a'}
+
+	args, _, _, err := TryBuild(ctx, cmd, SET, map[string]any{"id": 1, "data": data})
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(args[2].(string))
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("base64 round trip failed: %v %v", decoded, err)
+	}
+
+	args, _, _, err = TryBuild(ctx, cmd, HSET, map[string]any{"id": 2, "data": data, "name": "二进制"})
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	if decoded, err := hex.DecodeString(args[3].(string)); err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("hex round trip failed: %v %v", decoded, err)
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(args[5].(string)); err != nil || string(decoded) != "二进制" {
+		t.Errorf("base64 string round trip failed: %q %v", decoded, err)
+	}
+
+	if _, _, _, err := TryBuild(ctx, cmd, SET, map[string]any{"id": 3, "data": 42}); err == nil {
+		t.Errorf("Expected error for non-binary value")
+	}
+}
+
+// TestTryBuild_PrecisionModifier 测试 {{xxx|prec:N}} 按固定小数位数格式化浮点数, slice 仍然展开成多个参数
+func TestTryBuild_PrecisionModifier(t *testing.T) {
+	cmd := RdCmd{
+		Key: "geo:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			RPUSH: {Params: "{{coords|prec:6}} {{member}}"},
+			SET:   {Params: "{{coords|prec:2}}"},
+			ZADD:  {Params: "{{pairs|prec:1}}"},
+			HSET:  {Params: "score {{score|prec:x}}"},
+		},
+	}
+	ctx := context.Background()
+	args := map[string]any{"id": 1, "coords": []float64{13.361389, 38.1155557}, "member": "Palermo"}
+
+	got, _, _, err := TryBuild(ctx, cmd, RPUSH, args)
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	want := []any{"RPUSH", "geo:1", "13.361389", "38.115556", "Palermo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	prepared, err := PrepareCmd(cmd, RPUSH)
+	if err != nil {
+		t.Fatalf("PrepareCmd failed: %v", err)
+	}
+	if got, _, err := prepared.TryBuild(ctx, args); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("PreparedCmd expected %v, got %v %v", want, got, err)
+	}
+
+	got, _, _, _ = TryBuild(ctx, cmd, SET, map[string]any{"id": 2, "coords": []float32{1, 2.5}})
+	if !reflect.DeepEqual(got, []any{"SET", "geo:2", "1.00", "2.50"}) {
+		t.Errorf("Unexpected float32 slice: %v", got)
+	}
+	// map 中不是浮点数的值不受影响
+	got, _, _, _ = TryBuild(ctx, cmd, ZADD, map[string]any{"id": 3, "pairs": map[string]any{"a": 1e21, "b": 7}})
+	if !reflect.DeepEqual(got, []any{"ZADD", "geo:3", "a", "1000000000000000000000.0", "b", "7"}) {
+		t.Errorf("Unexpected map expansion: %v", got)
+	}
+	if _, _, _, err := TryBuild(ctx, cmd, HSET, map[string]any{"id": 4, "score": 1.5}); err == nil {
+		t.Errorf("Expected error for invalid precision")
+	}
+}
+
+// TestTryBuild_ContextDefaultArgs 测试 context 中的默认参数在没有提供时填充占位符, 优先级在 args 和 DefaultParams 之间
+func TestTryBuild_ContextDefaultArgs(t *testing.T) {
+	cmd := RdCmd{
+		Key: "tenant:{{tenant}}:user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			HSET: {Params: "locale {{locale}}", DefaultParams: map[string]any{"locale": "en", "tenant": "default"}},
+		},
+	}
+	ctx := WithDefaultArgs(context.Background(), map[string]any{"tenant": "acme", "locale": "zh"})
+
+	args := map[string]any{"id": 1}
+	got, _, _, err := TryBuild(ctx, cmd, HSET, args)
+	if err != nil {
+		t.Fatalf("TryBuild failed: %v", err)
+	}
+	if want := []any{"HSET", "tenant:acme:user:1", "locale", "zh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if len(args) != 1 {
+		t.Errorf("Expected args not to be modified, got %v", args)
+	}
+
+	// 显式的参数优先, 内层的默认参数覆盖外层的
+	inner := WithDefaultArgs(ctx, map[string]any{"locale": "fr"})
+	got, _, _, _ = TryBuild(inner, cmd, HSET, map[string]any{"id": 2, "tenant": "other"})
+	if want := []any{"HSET", "tenant:other:user:2", "locale", "fr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	// 没有 context 默认参数时使用 DefaultParams
+	got, _, _, _ = TryBuild(context.Background(), cmd, HSET, map[string]any{"id": 3})
+	if want := []any{"HSET", "tenant:default:user:3", "locale", "en"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestTryBuild_FloatFormatModifier 测试单个浮点数的 f:N、g、e:N 格式
+func TestTryBuild_FloatFormatModifier(t *testing.T) {
+	tests := []struct {
+		params string
+		val    any
+		want   string
+	}{
+		{"{{v}}", 1.0 / 3, "0.3333333333333333"},
+		{"{{v|f:2}}", 1.0 / 3, "0.33"},
+		{"{{v|f:0}}", float32(2.5), "2"},
+		{"{{v|f}}", 1e21, "1000000000000000000000"},
+		{"{{v|g}}", 1e21, "1e+21"},
+		{"{{v|g:3}}", 3.14159, "3.14"},
+		{"{{v|e:2}}", 1234.5, "1.23e+03"},
+		{"score:{{v|f:1}}", 99.95, "score:100.0"},
+		{"{{v|f:2}}", 7, "7"},
+		{"{{v|g}}", []float64{1e-7, 2}, "1e-07 2"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		cmd := RdCmd{Key: "k", CMD: map[Command]RdSubCmd{SET: {Params: tt.params}}}
+		got, _, _, err := TryBuild(ctx, cmd, SET, map[string]any{"v": tt.val})
+		if err != nil {
+			t.Errorf("%s: TryBuild failed: %v", tt.params, err)
+			continue
+		}
+		if joined := strings.Join(toStrings(got[2:]), " "); joined != tt.want {
+			t.Errorf("%s with %v: expected %q, got %q", tt.params, tt.val, tt.want, joined)
+		}
+	}
+
+	for _, params := range []string{"{{v|prec}}", "{{v|f:-1}}", "{{v|x:2}}"} {
+		cmd := RdCmd{Key: "k", CMD: map[Command]RdSubCmd{SET: {Params: params}}}
+		if _, _, _, err := TryBuild(ctx, cmd, SET, map[string]any{"v": 1.5}); err == nil {
+			t.Errorf("%s: expected error", params)
+		}
+	}
+}
+
+func toStrings(args []any) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = fmt.Sprint(arg)
+	}
+	return out
+}
+
+// TestTryBuild_OnMissing 测试没有提供占位符的值时 OnMissing 的三种处理方式, PreparedCmd 的结果一致
+func TestTryBuild_OnMissing(t *testing.T) {
+	tests := []struct {
+		policy  MissingPolicy
+		want    []any
+		wantErr bool
+	}{
+		{MissingLiteral, []any{"HSET", "user:{{id}}", "name", "{{name}}", "tag", "t:{{tag|hex}}"}, false},
+		{MissingEmpty, []any{"HSET", "user:", "name", "", "tag", "t:"}, false},
+		{MissingError, nil, true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		cmd := RdCmd{
+			Key: "user:{{id}}",
+			CMD: map[Command]RdSubCmd{HSET: {Params: "name {{name}} tag t:{{tag|hex}}", OnMissing: tt.policy}},
+		}
+		got, _, _, err := TryBuild(ctx, cmd, HSET, nil)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %d: expected %v (err %v), got %v %v", tt.policy, tt.want, tt.wantErr, got, err)
+		}
+		prepared, err := PrepareCmd(cmd, HSET)
+		if err != nil {
+			t.Fatalf("PrepareCmd failed: %v", err)
+		}
+		got, _, err = prepared.TryBuild(context.Background(), nil)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("prepared policy %d: expected %v (err %v), got %v %v", tt.policy, tt.want, tt.wantErr, got, err)
+		}
+	}
+
+	// 提供的参数和 DefaultParams 不受影响
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{HSET: {Params: "name {{name}} tag {{tag}}", OnMissing: MissingEmpty, DefaultParams: map[string]any{"tag": "x"}}},
+	}
+	got, _, _, _ := TryBuild(ctx, cmd, HSET, map[string]any{"id": 1})
+	if want := []any{"HSET", "user:1", "name", "", "tag", "x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestTryBuild_IncludeArity 测试 IncludeArity 校验 includeArgs 的个数
+func TestTryBuild_IncludeArity(t *testing.T) {
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{HSET: {IncludeArity: 2}},
+	}
+	tests := []struct {
+		includeArgs []any
+		wantErr     bool
+	}{
+		{nil, false},
+		{[]any{"name", "alice"}, false},
+		{[]any{"name", "alice", "age"}, true},
+		{[]any{"name", "alice", "age", 30}, false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		got, _, _, err := TryBuild(ctx, cmd, HSET, map[string]any{"id": 1}, tt.includeArgs...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("includeArgs %v: expected error %v, got %v", tt.includeArgs, tt.wantErr, err)
+		}
+		if err == nil && len(got) != 2+len(tt.includeArgs) {
+			t.Errorf("includeArgs %v: unexpected command %v", tt.includeArgs, got)
+		}
+	}
+}
+
+// TestTryBuild_KeyPlaceholder 测试 NoUseKey 时 Params 中的 {{key}} 替换成外层 key, key 可以放在任意位置
+func TestTryBuild_KeyPlaceholder(t *testing.T) {
+	cmd := RdCmd{
+		Key: "list:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			OBJECT:  {Params: "ENCODING {{key}}", NoUseKey: true},
+			LINSERT: {Params: "{{key}} BEFORE {{pivot}} {{element}}", NoUseKey: true},
+			LREM:    {Params: "{{key}}x {{element}}", NoUseKey: true},
+		},
+	}
+	tests := []struct {
+		cmdName Command
+		args    map[string]any
+		want    []any
+		wantKey string
+	}{
+		{OBJECT, map[string]any{"id": 1}, []any{"OBJECT", "ENCODING", "list:1"}, "list:1"},
+		{LINSERT, map[string]any{"id": 2, "pivot": "b", "element": "a"}, []any{"LINSERT", "list:2", "BEFORE", "b", "a"}, "list:2"},
+		// 只有单独的 {{key}} 代表外层 key, 其他情况还是从 args 中取值
+		{LREM, map[string]any{"id": 3, "key": "k", "element": "a"}, []any{"LREM", "kx", "a"}, ""},
+	}
+	for _, tt := range tests {
+		got, key, _, err := TryBuild(context.Background(), cmd, tt.cmdName, tt.args)
+		if err != nil {
+			t.Fatalf("%s: TryBuild failed: %v", tt.cmdName, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) || key != tt.wantKey {
+			t.Errorf("%s: expected %v %q, got %v %q", tt.cmdName, tt.want, tt.wantKey, got, key)
+		}
+	}
+
+	// 外层 Key 为空时 {{key}} 从 args 中取值
+	noKey := RdCmd{CMD: map[Command]RdSubCmd{SET: {Params: "{{key}} {{value}}", NoUseKey: true}}}
+	got, key, _, _ := TryBuild(context.Background(), noKey, SET, map[string]any{"key": "a", "value": 1})
+	if want := []any{"SET", "a", "1"}; !reflect.DeepEqual(got, want) || key != "" {
+		t.Errorf("Expected %v with empty key, got %v %q", want, got, key)
+	}
+}
+
+// TestTryBuild_SharedNoUseKey 测试同一个 RdCmd 中使用 key 和 NoUseKey 的子命令, NoUseKey 的子命令不带外层的 key
+func TestTryBuild_SharedNoUseKey(t *testing.T) {
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			GET:    {},
+			DBSIZE: {NoUseKey: true},
+			MGET:   {Params: "{{keys}}", NoUseKey: true},
+		},
+	}
+	args := map[string]any{"id": 1, "keys": []string{"a", "b"}}
+	tests := []struct {
+		cmdName Command
+		want    []any
+		wantKey string
+	}{
+		{GET, []any{"GET", "user:1"}, "user:1"},
+		{DBSIZE, []any{"DBSIZE"}, ""},
+		{MGET, []any{"MGET", "a", "b"}, ""},
+	}
+	for _, tt := range tests {
+		got, key, _, err := TryBuild(context.Background(), cmd, tt.cmdName, args)
+		if err != nil {
+			t.Fatalf("%s: TryBuild failed: %v", tt.cmdName, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) || key != tt.wantKey {
+			t.Errorf("%s: expected %v %q, got %v %q", tt.cmdName, tt.want, tt.wantKey, got, key)
+		}
+	}
+}
+
+// TestTryBuild_HashTag 测试三层大括号和 HashTag 都会在 key 中保留 cluster hash tag 的 { }, PreparedCmd 的结果一致
+func TestTryBuild_HashTag(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  RdCmd
+		want []any
+		key  string
+	}{
+		{"triple braces", RdCmd{Key: "user:{{{id}}}:cart", CMD: map[Command]RdSubCmd{GET: {}}}, []any{"GET", "user:{7}:cart"}, "user:{7}:cart"},
+		{"HashTag", RdCmd{Key: "user:{{id}}:cart", HashTag: "id", CMD: map[Command]RdSubCmd{GET: {}}}, []any{"GET", "user:{7}:cart"}, "user:{7}:cart"},
+		{"HashTag only first", RdCmd{Key: "{{id}}:{{id}}", HashTag: "id", CMD: map[Command]RdSubCmd{GET: {}}}, []any{"GET", "{7}:7"}, "{7}:7"},
+		{"HashTag with triple braces", RdCmd{Key: "user:{{{id}}}", HashTag: "id", CMD: map[Command]RdSubCmd{GET: {}}}, []any{"GET", "user:{7}"}, "user:{7}"},
+		{"Params", RdCmd{CMD: map[Command]RdSubCmd{GET: {Params: "order:{{{id}}}:items", NoUseKey: true}}}, []any{"GET", "order:{7}:items"}, ""},
+		{"Params slice", RdCmd{CMD: map[Command]RdSubCmd{GET: {Params: "{{{ids}}}", NoUseKey: true}}}, []any{"GET", "{1 2}"}, ""},
+	}
+	args := map[string]any{"id": 7, "ids": []int{1, 2}}
+	for _, tt := range tests {
+		got, key, _, err := TryBuild(context.Background(), tt.cmd, GET, args)
+		if err != nil {
+			t.Fatalf("%s: TryBuild failed: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) || key != tt.key {
+			t.Errorf("%s: expected %v %q, got %v %q", tt.name, tt.want, tt.key, got, key)
+		}
+		prepared, err := PrepareCmd(tt.cmd, GET)
+		if err != nil {
+			t.Fatalf("%s: PrepareCmd failed: %v", tt.name, err)
+		}
+		if got, key := prepared.Build(context.Background(), args); !reflect.DeepEqual(got, tt.want) || key != tt.key {
+			t.Errorf("%s: PreparedCmd expected %v %q, got %v %q", tt.name, tt.want, tt.key, got, key)
+		}
+	}
+}
+
+// TestTryBuild_RequiredParams 测试缺少 RequiredParams 时构建失败, DefaultParams 和 context 中的默认参数也算作已提供
+func TestTryBuild_RequiredParams(t *testing.T) {
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			HSET: {Params: "name {{name}} age {{age}}", RequiredParams: []string{"id", "name", "age"}, DefaultParams: map[string]any{"age": 18}},
+		},
+	}
+	tests := []struct {
+		ctx     context.Context
+		args    map[string]any
+		wantErr string
+	}{
+		{context.Background(), map[string]any{"id": 1, "name": "tom"}, ""},
+		{context.Background(), map[string]any{}, "rdb: HSET missing required params: id, name"},
+		{WithDefaultArgs(context.Background(), map[string]any{"id": 2}), map[string]any{"name": "amy"}, ""},
+	}
+	for _, tt := range tests {
+		_, _, _, err := TryBuild(tt.ctx, cmd, HSET, tt.args)
+		if gotErr := fmt.Sprint(err); (tt.wantErr == "" && err != nil) || (tt.wantErr != "" && gotErr != tt.wantErr) {
+			t.Errorf("args %v: expected error %q, got %v", tt.args, tt.wantErr, err)
+		}
+	}
+
+	prepared, err := PrepareCmd(cmd, HSET)
+	if err != nil {
+		t.Fatalf("PrepareCmd failed: %v", err)
+	}
+	if _, _, err := prepared.TryBuild(context.Background(), map[string]any{"id": 1}); fmt.Sprint(err) != "rdb: HSET missing required params: name" {
+		t.Errorf("Expected missing name error, got %v", err)
+	}
+}
+
+// TestErrUnknownCommand 测试子命令不存在时返回和 panic 的都是 ErrUnknownCommand
+func TestErrUnknownCommand(t *testing.T) {
+	cmd := RdCmd{Key: "user:{{id}}", CMD: map[Command]RdSubCmd{GET: {}}}
+
+	_, _, _, err := TryBuild(context.Background(), cmd, DEL, nil)
+	var unknown ErrUnknownCommand
+	if !errors.As(err, &unknown) || unknown.Command != DEL {
+		t.Fatalf("Expected ErrUnknownCommand{DEL}, got %v", err)
+	}
+	if err.Error() != "unknown command: DEL" {
+		t.Errorf("unexpected error message: %s", err)
+	}
+	if _, err := PrepareCmd(cmd, HSET); !errors.As(err, &unknown) || unknown.Command != HSET {
+		t.Errorf("PrepareCmd: expected ErrUnknownCommand{HSET}, got %v", err)
+	}
+
+	defer func() {
+		r := recover()
+		if e, ok := r.(ErrUnknownCommand); !ok || e.Command != SET {
+			t.Errorf("Expected panic with ErrUnknownCommand{SET}, got %v", r)
+		}
+	}()
+	Build(context.Background(), cmd, SET, nil)
+}
@@ -4,29 +4,35 @@ type Command string
 
 var (
 	// Keys
-	DEL       Command = "DEL"
-	DUMP      Command = "DUMP"
-	EXISTS    Command = "EXISTS"
-	EXPIRE    Command = "EXPIRE"
-	EXPIREAT  Command = "EXPIREAT"
-	KEYS      Command = "KEYS"
-	MOVE      Command = "MOVE"
-	PERSIST   Command = "PERSIST"
-	PEXPIRE   Command = "PEXPIRE"
-	PEXPIREAT Command = "PEXPIREAT"
-	RENAME    Command = "RENAME"
-	RENAMENX  Command = "RENAMENX"
-	TOUCH     Command = "TOUCH"
-	TTL       Command = "TTL"
-	PTTL      Command = "PTTL"
-	TYPE      Command = "TYPE"
-	UNLINK    Command = "UNLINK"
-	SCAN      Command = "SCAN"
+	DEL        Command = "DEL"
+	COPY       Command = "COPY"
+	DUMP       Command = "DUMP"
+	EXISTS     Command = "EXISTS"
+	EXPIRE     Command = "EXPIRE"
+	EXPIREAT   Command = "EXPIREAT"
+	EXPIRETIME Command = "EXPIRETIME"
+	KEYS       Command = "KEYS"
+	MOVE       Command = "MOVE"
+	OBJECT     Command = "OBJECT"
+	PERSIST    Command = "PERSIST"
+	PEXPIRE    Command = "PEXPIRE"
+	PEXPIREAT  Command = "PEXPIREAT"
+	RENAME     Command = "RENAME"
+	RENAMENX   Command = "RENAMENX"
+	RESTORE    Command = "RESTORE"
+	TOUCH      Command = "TOUCH"
+	TTL        Command = "TTL"
+	PTTL       Command = "PTTL"
+	TYPE       Command = "TYPE"
+	UNLINK     Command = "UNLINK"
+	SCAN       Command = "SCAN"
 
 	// Strings
 	SET         Command = "SET"
 	GET         Command = "GET"
 	GETSET      Command = "GETSET"
+	GETDEL      Command = "GETDEL"
+	GETEX       Command = "GETEX"
 	SETRANGE    Command = "SETRANGE"
 	GETRANGE    Command = "GETRANGE"
 	MGET        Command = "MGET"
@@ -58,15 +64,26 @@ var (
 	HSTRLEN      Command = "HSTRLEN"
 	HVALS        Command = "HVALS"
 	HSCAN        Command = "HSCAN"
+	HEXPIRE      Command = "HEXPIRE"
+	HPEXPIRE     Command = "HPEXPIRE"
+	HTTL         Command = "HTTL"
+	HPTTL        Command = "HPTTL"
+	HPERSIST     Command = "HPERSIST"
+	HGETEX       Command = "HGETEX"
+	HGETDEL      Command = "HGETDEL"
 
 	// Lists
 	BLPOP      Command = "BLPOP"
 	BRPOP      Command = "BRPOP"
+	BLMOVE     Command = "BLMOVE"
 	BRPOPLPUSH Command = "BRPOPLPUSH"
 	LINDEX     Command = "LINDEX"
 	LINSERT    Command = "LINSERT"
 	LLEN       Command = "LLEN"
+	LMOVE      Command = "LMOVE"
+	LMPOP      Command = "LMPOP"
 	LPOP       Command = "LPOP"
+	LPOS       Command = "LPOS"
 	LPUSH      Command = "LPUSH"
 	LPUSHX     Command = "LPUSHX"
 	LRANGE     Command = "LRANGE"
@@ -84,9 +101,11 @@ var (
 	SDIFF       Command = "SDIFF"
 	SDIFFSTORE  Command = "SDIFFSTORE"
 	SINTER      Command = "SINTER"
+	SINTERCARD  Command = "SINTERCARD"
 	SINTERSTORE Command = "SINTERSTORE"
 	SISMEMBER   Command = "SISMEMBER"
 	SMEMBERS    Command = "SMEMBERS"
+	SMISMEMBER  Command = "SMISMEMBER"
 	SMOVE       Command = "SMOVE"
 	SPOP        Command = "SPOP"
 	SRANDMEMBER Command = "SRANDMEMBER"
@@ -96,11 +115,14 @@ var (
 	SSCAN       Command = "SSCAN"
 
 	// Sorted Sets
+	BZPOPMAX         Command = "BZPOPMAX"
+	BZPOPMIN         Command = "BZPOPMIN"
 	ZADD             Command = "ZADD"
 	ZCARD            Command = "ZCARD"
 	ZCOUNT           Command = "ZCOUNT"
 	ZINCRBY          Command = "ZINCRBY"
 	ZINTER           Command = "ZINTER"
+	ZINTERCARD       Command = "ZINTERCARD"
 	ZINTERSTORE      Command = "ZINTERSTORE"
 	ZLEXCOUNT        Command = "ZLEXCOUNT"
 	ZMPOP            Command = "ZMPOP"
@@ -138,6 +160,14 @@ var (
 	GETBIT   Command = "GETBIT"
 	SETBIT   Command = "SETBIT"
 
+	// Geo
+	GEOADD            Command = "GEOADD"
+	GEODIST           Command = "GEODIST"
+	GEOPOS            Command = "GEOPOS"
+	GEORADIUS         Command = "GEORADIUS"
+	GEORADIUSBYMEMBER Command = "GEORADIUSBYMEMBER"
+	GEOSEARCH         Command = "GEOSEARCH"
+
 	// Streams
 	XADD       Command = "XADD"
 	XDEL       Command = "XDEL"
@@ -199,4 +229,21 @@ var (
 	SLOWLOG      Command = "SLOWLOG"
 	SYNC         Command = "SYNC"
 	TIME         Command = "TIME"
+	WAIT         Command = "WAIT"
+
+	// RedisBloom
+	BFADD     Command = "BF.ADD"
+	BFEXISTS  Command = "BF.EXISTS"
+	BFMADD    Command = "BF.MADD"
+	BFMEXISTS Command = "BF.MEXISTS"
+	BFRESERVE Command = "BF.RESERVE"
+
+	// RedisJSON
+	JSONARRAPPEND Command = "JSON.ARRAPPEND"
+	JSONDEL       Command = "JSON.DEL"
+	JSONGET       Command = "JSON.GET"
+	JSONSET       Command = "JSON.SET"
+
+	// RediSearch
+	FTSEARCH Command = "FT.SEARCH"
 )
@@ -2,8 +2,19 @@ package rdb
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // CommandBuilder 命令构建器，支持链式调用
@@ -16,7 +27,10 @@ type CommandBuilder struct {
 	cmdName     Command
 	args        map[string]any
 	includeArgs []any
-	cmder       redis.Cmder // 缓存的 cmder，用于实现 redis.Cmder 接口
+	cmder       redis.Cmder    // 缓存的 cmder，用于实现 redis.Cmder 接口
+	redact      []int          // CommandString 中需要隐藏的参数位置
+	expireCmd   *redis.BoolCmd // Pipeline 中设置过期时间的 cmder
+	pipeOpts    pipelineOpts   // pipeline 中的命令使用的选项
 }
 
 // 实现 redis.Cmder 接口，以便 CommandBuilder 可以直接作为 redis.Cmder 使用
@@ -31,7 +45,16 @@ func (cb *CommandBuilder) Args() []interface{} {
 	if cb.cmder != nil {
 		return cb.cmder.Args()
 	}
-	cmdList, _, _ := Build(cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+	cmdList, key, _ := Build(cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+	transform, version, prefix := cb.pipeOpts.argTransform, cb.pipeOpts.version, cb.pipeOpts.keyPrefix
+	if cb.client != nil {
+		transform, version, prefix = cb.client.ArgTransform, cb.client.version, cb.client.KeyPrefix
+	}
+	cmdList, key = version.apply(cb.cmdName, cmdList, key)
+	cmdList, _ = applyKeyPrefix(prefix, cb.cmdName, cmdList, key)
+	if transform != nil {
+		cmdList = transform(cb.cmdName, cmdList)
+	}
 	return cmdList
 }
 
@@ -46,37 +69,7 @@ func (cb *CommandBuilder) SetErr(err error) {
 func (cb *CommandBuilder) Err() error {
 	// 如果还未执行，使用默认的 *redis.Cmd 执行
 	if cb.cmder == nil {
-		cmdList, key, subCmd := Build(cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
-		cmder := redis.NewCmd(cb.ctx, cmdList...)
-
-		if cb.pipeliner != nil {
-			_ = cb.pipeliner.Process(cb.ctx, cmder)
-			if subCmd.Exp != nil {
-				exp := subCmd.Exp()
-				cb.pipeliner.Expire(cb.ctx, key, exp)
-			}
-			cb.cmder = cmder
-		} else {
-			processErr := cb.client.Client.Process(cb.ctx, cmder)
-			cmdErr := cmder.Err()
-			if processErr != nil {
-				cmdErr = processErr
-			}
-			if !subCmd.ReturnNilError && errors.Is(cmdErr, redis.Nil) {
-				cmdErr = nil
-			}
-			if cmdErr != nil {
-				cmder.SetErr(cmdErr)
-			}
-			if subCmd.Exp != nil {
-				exp := subCmd.Exp()
-				expireCmd := cb.client.Client.Expire(cb.ctx, key, exp)
-				if expireCmd.Err() != nil {
-					// 记录错误但不影响主命令
-				}
-			}
-			cb.cmder = cmder
-		}
+		cb.execDefault()
 	}
 	if cb.cmder != nil {
 		return cb.cmder.Err()
@@ -87,37 +80,7 @@ func (cb *CommandBuilder) Err() error {
 func (cb *CommandBuilder) Val() interface{} {
 	// 如果还未执行，使用默认的 *redis.Cmd 执行
 	if cb.cmder == nil {
-		cmdList, key, subCmd := Build(cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
-		cmder := redis.NewCmd(cb.ctx, cmdList...)
-
-		if cb.pipeliner != nil {
-			_ = cb.pipeliner.Process(cb.ctx, cmder)
-			if subCmd.Exp != nil {
-				exp := subCmd.Exp()
-				cb.pipeliner.Expire(cb.ctx, key, exp)
-			}
-			cb.cmder = cmder
-		} else {
-			processErr := cb.client.Client.Process(cb.ctx, cmder)
-			cmdErr := cmder.Err()
-			if processErr != nil {
-				cmdErr = processErr
-			}
-			if !subCmd.ReturnNilError && errors.Is(cmdErr, redis.Nil) {
-				cmdErr = nil
-			}
-			if cmdErr != nil {
-				cmder.SetErr(cmdErr)
-			}
-			if subCmd.Exp != nil {
-				exp := subCmd.Exp()
-				expireCmd := cb.client.Client.Expire(cb.ctx, key, exp)
-				if expireCmd.Err() != nil {
-					// 记录错误但不影响主命令
-				}
-			}
-			cb.cmder = cmder
-		}
+		cb.execDefault()
 	}
 	if cb.cmder != nil {
 		if valProvider, ok := cb.cmder.(interface{ Val() interface{} }); ok {
@@ -127,6 +90,77 @@ func (cb *CommandBuilder) Val() interface{} {
 	return nil
 }
 
+// execDefault 没有指定返回类型时, 使用默认的 *redis.Cmd 执行
+func (cb *CommandBuilder) execDefault() {
+	if cb.pipeliner != nil {
+		cb.cmder = inPipeline[*redis.Cmd](cb)
+	} else {
+		cb.cmder = ExecuteCmd[*redis.Cmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+	}
+}
+
+// Redact 设置 CommandString 中需要隐藏的参数位置(命令名的位置是 0), 用于 AUTH 之类带密码的命令
+func (cb *CommandBuilder) Redact(positions ...int) *CommandBuilder {
+	cb.redact = append(cb.redact, positions...)
+	return cb
+}
+
+// CommandString 返回构建出的命令字符串，方便日志和调试，如: SET mykey value
+// 过长的参数会被截断，二进制参数会被转义，Redact 设置的位置显示为 ***
+func (cb *CommandBuilder) CommandString() string {
+	return formatCommand(cb.Args(), cb.redact)
+}
+
+// ExpireCmd 返回 Pipeline 中设置了 Exp 的命令对应的 EXPIRE cmder, Exec 之后可以通过它确认过期时间是否设置成功
+// 没有设置 Exp、命令还没有加入 Pipeline 或者不在 Pipeline 中时返回 nil
+func (cb *CommandBuilder) ExpireCmd() *redis.BoolCmd {
+	return cb.expireCmd
+}
+
+// commandStringMaxArgLen CommandString 中单个参数最多显示的字节数, 截断时不会拆开 UTF-8 字符
+const commandStringMaxArgLen = 64
+
+func formatCommand(args []any, redact []int) string {
+	var b strings.Builder
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if slices.Contains(redact, i) {
+			b.WriteString("***")
+			continue
+		}
+		b.WriteString(formatCommandArg(arg))
+	}
+	return b.String()
+}
+
+func formatCommandArg(arg any) string {
+	var s string
+	switch v := arg.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+	suffix := ""
+	if len(s) > commandStringMaxArgLen {
+		suffix = fmt.Sprintf("...(%d bytes)", len(s))
+		// 在字符边界截断, 避免把多字节的 UTF-8 字符截成一半之后被当作二进制转义
+		cut := commandStringMaxArgLen
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut]
+	}
+	if !utf8.ValidString(s) || strings.IndexFunc(s, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		s = strconv.Quote(s)
+	}
+	return s + suffix
+}
+
 // NewCommandBuilder 创建命令构建器
 func NewCommandBuilder(client *RedisClient, ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) *CommandBuilder {
 	return &CommandBuilder{
@@ -151,13 +185,219 @@ func NewPipelineCommandBuilder(pipeliner redis.Pipeliner, ctx context.Context, c
 	}
 }
 
+// Raw 直接使用 args 作为命令参数, 不经过模板, args[0] 是命令名, 如:
+//
+//	client.Raw(ctx, "OBJECT", "FREQ", "user:1").Int()
+//
+// 用于没有必要定义 RdCmd 的命令, 同样支持 String()/Int() 等结果方法, 在 Pipeline 中同样只排队
+// ArgTransform 仍然生效; 没有 key 模板, 所以不会加上 CacheVersion 的前缀
+func (b builder) Raw(ctx context.Context, args ...any) *CommandBuilder {
+	if len(args) == 0 {
+		// 没有命令名, 执行时和没有定义的子命令一样 panic ErrUnknownCommand
+		return b(ctx, RdCmd{}, "", nil)
+	}
+	cmdName := Command(fmt.Sprint(args[0]))
+	cmd := RdCmd{CMD: map[Command]RdSubCmd{cmdName: {NoUseKey: true}}}
+	return b(ctx, cmd, cmdName, nil, args[1:]...)
+}
+
 // BuildCmd 构建 Redis 命令但不执行，返回构建好的 redis.Cmder
 // 这个方法可以让你构建命令，然后自己决定如何执行
 func (rdm RedisClient) BuildCmd(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) redis.Cmder {
-	cmdList, _, _ := Build(ctx, cmd, cmdName, args, includeArgs...)
+	cmdList, key, _ := Build(ctx, cmd, cmdName, args, includeArgs...)
+	cmdList, _ = rdm.finalizeCmd(cmdName, cmdList, key)
 	return redis.NewCmd(ctx, cmdList...)
 }
 
+// finalizeCmd 依次加上 CacheVersion 的前缀、KeyPrefix, 最后调用 ArgTransform, 得到和 ExecuteCmd 实际发送的一样的参数
+func (rdm *RedisClient) finalizeCmd(cmdName Command, cmdList []any, key string) ([]any, string) {
+	cmdList, key = rdm.version.apply(cmdName, cmdList, key)
+	cmdList, key = applyKeyPrefix(rdm.KeyPrefix, cmdName, cmdList, key)
+	if rdm.ArgTransform != nil {
+		cmdList = rdm.ArgTransform(cmdName, cmdList)
+	}
+	return cmdList, key
+}
+
+// BuildMany 用同一个子命令批量构建命令但不执行, argsList 中的每个参数 map 对应一条命令
+// 适合批量生成 pipeline 的数据, 和 BuildCmd 一样会加上 CacheVersion、KeyPrefix 并调用 ArgTransform; 和 TryBuild 一样, 任何一条命令构建失败时返回 error, 不会 panic
+func (rdm *RedisClient) BuildMany(ctx context.Context, cmd RdCmd, cmdName Command, argsList []map[string]any) ([][]any, error) {
+	subCmd, ok := cmd.CMD[cmdName]
+	if !ok {
+		return nil, ErrUnknownCommand{Command: cmdName}
+	}
+	tokens := tokenizeParams(subCmd.Params)
+	cmdLists := make([][]any, 0, len(argsList))
+	for i, args := range argsList {
+		cmdList, key, err := buildWithTokens(cmd, cmdName, subCmd, tokens, mergeContextArgs(ctx, args), nil)
+		if err != nil {
+			return nil, fmt.Errorf("rdb: BuildMany argsList[%d]: %w", i, err)
+		}
+		cmdList, _ = rdm.finalizeCmd(cmdName, cmdList, key)
+		cmdLists = append(cmdLists, cmdList)
+	}
+	return cmdLists, nil
+}
+
+// CommandHash 返回构造出的命令参数的 sha1, 相同的命令和参数得到相同的结果, 可以用作结果缓存的 key
+// includeArgs 中的 slice 会被展开, map 会按照 key 排序后展开, 保证每次运行的结果都一样
+// hash 的是实际发送的参数, 包括 CacheVersion、KeyPrefix 和 ArgTransform, 修改 CacheVersion 之后旧的结果自然失效
+func (rdm *RedisClient) CommandHash(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) (string, error) {
+	cmdList, key, _, err := TryBuild(ctx, cmd, cmdName, args, includeArgs...)
+	if err != nil {
+		return "", err
+	}
+	cmdList, _ = rdm.finalizeCmd(cmdName, cmdList, key)
+	return hashArgs(cmdList), nil
+}
+
+// CommandString 构造命令并返回可以直接粘贴到 redis-cli 中执行的命令字符串, 方便复现问题
+// 和 CommandBuilder.CommandString 不同, 参数不会被截断; 包含空格、引号或者不可见字符的参数会加上双引号并转义
+// AUTH、HELLO AUTH、MIGRATE AUTH 和 CONFIG SET requirepass 之类的密码显示为 ***; 构造失败时返回命令名和错误
+func (rdm *RedisClient) CommandString(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) string {
+	cmdList, key, _, err := TryBuild(ctx, cmd, cmdName, args, includeArgs...)
+	if err != nil {
+		return fmt.Sprintf("%s (build failed: %v)", cmdName, err)
+	}
+	cmdList, _ = rdm.finalizeCmd(cmdName, cmdList, key)
+	redact := sensitivePositions(cmdList)
+	var b strings.Builder
+	for i, arg := range cmdList {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if slices.Contains(redact, i) {
+			b.WriteString("***")
+			continue
+		}
+		b.WriteString(quoteCliArg(arg))
+	}
+	return b.String()
+}
+
+// sensitivePositions 返回命令中密码参数的位置
+func sensitivePositions(args []any) []int {
+	str := func(i int) string {
+		if i >= len(args) {
+			return ""
+		}
+		return strings.ToUpper(fmt.Sprint(args[i]))
+	}
+	var positions []int
+	switch str(0) {
+	case "AUTH":
+		for i := 1; i < len(args); i++ {
+			positions = append(positions, i)
+		}
+	case "HELLO", "MIGRATE":
+		for i := 1; i < len(args); i++ {
+			switch str(i) {
+			case "AUTH":
+				// HELLO AUTH username password, MIGRATE AUTH password
+				if str(0) == "HELLO" {
+					positions = append(positions, i+2)
+				} else {
+					positions = append(positions, i+1)
+				}
+			case "AUTH2":
+				positions = append(positions, i+2)
+			}
+		}
+	case "CONFIG":
+		if str(1) == "SET" {
+			for i := 2; i+1 < len(args); i += 2 {
+				if name := str(i); name == "REQUIREPASS" || name == "MASTERAUTH" {
+					positions = append(positions, i+1)
+				}
+			}
+		}
+	}
+	return positions
+}
+
+// quoteCliArg 按照 redis-cli 的规则转义参数, 不需要转义时原样返回
+func quoteCliArg(arg any) string {
+	var s string
+	switch v := arg.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r > '~' || r == '"' || r == '\'' || r == '\\'
+	}) {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// hashArgs 计算展开后的参数的 sha1
+func hashArgs(cmdList []any) string {
+	h := sha1.New()
+	for _, arg := range flattenSortedArgs(nil, cmdList) {
+		// 每个参数带上长度前缀, 避免 "ab","c" 和 "a","bc" 得到相同的结果
+		fmt.Fprintf(h, "%d:%s", len(arg), arg)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// flattenSortedArgs 把参数展开成字符串, slice 按顺序展开, map 按 key 排序展开
+func flattenSortedArgs(dst []string, args []any) []string {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			dst = append(dst, v)
+			continue
+		case []byte:
+			dst = append(dst, string(v))
+			continue
+		}
+		rv := reflect.ValueOf(arg)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			items := make([]any, rv.Len())
+			for i := range items {
+				items[i] = rv.Index(i).Interface()
+			}
+			dst = flattenSortedArgs(dst, items)
+		case reflect.Map:
+			keys := rv.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+			})
+			for _, k := range keys {
+				dst = flattenSortedArgs(dst, []any{k.Interface(), rv.MapIndex(k).Interface()})
+			}
+		default:
+			dst = append(dst, fmt.Sprint(arg))
+		}
+	}
+	return dst
+}
+
 // ExecuteCmd 执行命令并返回具体的类型
 // 这是一个泛型方法，根据泛型类型 T 自动创建对应的 redis.Cmder
 // 错误通过返回的 Cmder 的 Err() 方法获取
@@ -170,69 +410,103 @@ func (rdm RedisClient) BuildCmd(ctx context.Context, cmd RdCmd, cmdName Command,
 //	val, _ := cmd.Result()
 func ExecuteCmd[T redis.Cmder](rdm *RedisClient, ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) T {
 	var zero T
-	cmdList, key, subCmd := Build(ctx, cmd, cmdName, args, includeArgs...)
+	cmdList, key, subCmd, buildErr := TryBuild(ctx, cmd, cmdName, args, includeArgs...)
+	if errors.As(buildErr, &ErrUnknownCommand{}) {
+		// 没有定义的子命令是代码错误, 和 Build 一样 panic
+		panic(buildErr)
+	}
+	if buildErr != nil {
+		cmdList = []any{string(cmdName)}
+	} else {
+		cmdList, key = rdm.version.apply(cmdName, cmdList, key)
+		cmdList, key = applyKeyPrefix(rdm.KeyPrefix, cmdName, cmdList, key)
+		if rdm.ArgTransform != nil {
+			cmdList = rdm.ArgTransform(cmdName, cmdList)
+		}
+	}
+	if key == "" {
+		// NoUseKey 的命令没有 key, 设置了 Exp 也不发送 EXPIRE
+		subCmd.Exp = nil
+	}
+
+	parentCtx := ctx // Fallback 使用调用方的 ctx, 不受命令自己的超时影响
+	if buildErr == nil && subCmd.Timeout > 0 {
+		// 命令自己的超时, 执行完之后 cancel, 避免 context 泄漏
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, subCmd.Timeout)
+		defer cancel()
+	}
 
 	// 根据泛型类型 T 创建对应的 redis.Cmder
-	var cmder redis.Cmder
-	switch any(zero).(type) {
-	case *redis.StringCmd:
-		cmder = redis.NewStringCmd(ctx, cmdList...)
-	case *redis.IntCmd:
-		cmder = redis.NewIntCmd(ctx, cmdList...)
-	case *redis.SliceCmd:
-		cmder = redis.NewSliceCmd(ctx, cmdList...)
-	case *redis.FloatCmd:
-		cmder = redis.NewFloatCmd(ctx, cmdList...)
-	case *redis.BoolCmd:
-		cmder = redis.NewBoolCmd(ctx, cmdList...)
-	case *redis.MapStringIntCmd:
-		cmder = redis.NewMapStringIntCmd(ctx, cmdList...)
-	case *redis.MapStringStringCmd:
-		cmder = redis.NewMapStringStringCmd(ctx, cmdList...)
-	case *redis.StringSliceCmd:
-		cmder = redis.NewStringSliceCmd(ctx, cmdList...)
-	case *redis.IntSliceCmd:
-		cmder = redis.NewIntSliceCmd(ctx, cmdList...)
-	case *redis.FloatSliceCmd:
-		cmder = redis.NewFloatSliceCmd(ctx, cmdList...)
-	case *redis.BoolSliceCmd:
-		cmder = redis.NewBoolSliceCmd(ctx, cmdList...)
-	case *redis.KeyValueSliceCmd:
-		cmder = redis.NewKeyValueSliceCmd(ctx, cmdList...)
-	case *redis.MapStringInterfaceCmd:
-		cmder = redis.NewMapStringInterfaceCmd(ctx, cmdList...)
-	case *redis.MapStringStringSliceCmd:
-		cmder = redis.NewMapStringStringSliceCmd(ctx, cmdList...)
-	case *redis.MapStringInterfaceSliceCmd:
-		cmder = redis.NewMapStringInterfaceSliceCmd(ctx, cmdList...)
-	case *redis.MapStringSliceInterfaceCmd:
-		cmder = redis.NewMapStringSliceInterfaceCmd(ctx, cmdList...)
-	case *redis.MapMapStringInterfaceCmd:
-		cmder = redis.NewMapMapStringInterfaceCmd(ctx, cmdList...)
-	case *redis.ZSliceCmd:
-		cmder = redis.NewZSliceCmd(ctx, cmdList...)
-	case *redis.ZSliceWithKeyCmd:
-		cmder = redis.NewZSliceWithKeyCmd(ctx, cmdList...)
-	case *redis.ZWithKeyCmd:
-		cmder = redis.NewZWithKeyCmd(ctx, cmdList...)
-	default:
-		cmder = redis.NewCmd(ctx, cmdList...)
+	cmder := newCmder[T](ctx, cmdName, cmdList, func(ctx context.Context, cmd redis.Cmder) error {
+		return rdm.Client.Process(ctx, cmd)
+	})
+
+	if buildErr != nil {
+		// 构建失败的命令不会发送到 redis
+		cmder.SetErr(buildErr)
+		result, _ := cmder.(T)
+		return result
 	}
 
-	processErr := rdm.Client.Process(ctx, cmder)
+	var processor Processor = rdm.Client
+	var processErr error
+	stub, stubbed := rdm.stubs[cmdName]
+	offline := stubbed || rdm.replay != nil // stub 和回放都不访问 redis, 也不设置过期时间
+	atomicExpire := subCmd.Exp != nil && subCmd.AtomicExpire
+	if timeout, ok := blockingTimeout(cmdName, cmdList); ok && !offline {
+		if err := checkBlockingDeadline(ctx, cmdName, timeout); err != nil {
+			cmder.SetErr(err)
+			result, _ := cmder.(T)
+			return result
+		}
+		created, err := blockingCmder(ctx, rdm.Client, cmdName, cmder, timeout)
+		if err != nil {
+			cmder.SetErr(err)
+			result, _ := cmder.(T)
+			return result
+		}
+		cmder = created
+	}
+	switch {
+	case stubbed:
+		processErr = runStub(cmder, stub, cmdList)
+	case rdm.replay != nil:
+		processErr = rdm.replay.reply(cmder)
+	case atomicExpire:
+		// 主命令和 EXPIRE 在同一个事务中执行, EXEC 失败时主命令的 cmder 上也会设置错误
+		// 不使用 Retry: 失败时事务可能已经执行, 重新发送会重复执行主命令的副作用
+		_, processErr = rdm.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Process(ctx, cmder)
+			processExpire(ctx, pipe, key, subCmd.Exp(), subCmd.ExpCondition)
+			return nil
+		})
+	default:
+		// 过期时间在重试结束之后才设置, 重试不会重复执行 EXPIRE
+		processErr = processWithRetry(ctx, rdm.Retry, cmdName, cmder, func() error {
+			return processor.Process(ctx, cmder)
+		})
+	}
 	cmdErr := cmder.Err()
 	if processErr != nil {
 		cmdErr = processErr
 	}
+	fallback := subCmd.Fallback != nil && !offline && shouldFallback(cmdErr)
+	if fallback {
+		if val, err := subCmd.Fallback(parentCtx, key); err == nil {
+			cmder.SetErr(nil)
+			cmdErr = setCmderVal(cmder, val)
+		}
+	}
 	if !subCmd.ReturnNilError && errors.Is(cmdErr, redis.Nil) {
 		cmdErr = nil
 	}
 	cmder.SetErr(cmdErr)
 
 	// 设置过期时间
-	if subCmd.Exp != nil {
+	if subCmd.Exp != nil && !offline && !atomicExpire && !fallback {
 		exp := subCmd.Exp()
-		expireCmd := rdm.Client.Expire(ctx, key, exp)
+		expireCmd := processExpire(ctx, processor, key, exp, subCmd.ExpCondition)
 		if expireCmd.Err() != nil {
 			// 记录错误但不影响主命令
 		}
@@ -264,87 +538,106 @@ func (cb *CommandBuilder) String() *redis.StringCmd {
 
 	// 如果在 Pipeline 中，使用 Pipeline 模式
 	if cb.pipeliner != nil {
-		cmdList, key, subCmd := Build(cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
-		cmder := redis.NewStringCmd(cb.ctx, cmdList...)
-		_ = cb.pipeliner.Process(cb.ctx, cmder)
-		if subCmd.Exp != nil {
-			exp := subCmd.Exp()
-			cb.pipeliner.Expire(cb.ctx, key, exp)
-		}
-		cb.cmder = cmder
-		return cmder
+		strCmd := inPipeline[*redis.StringCmd](cb)
+		cb.cmder = strCmd
+		return strCmd
 	}
 
 	return ExecuteCmd[*redis.StringCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
 }
 
+// inPipeline 把 cb 的命令加入 Pipeline, 同时记录设置过期时间的 cmder
+func inPipeline[T redis.Cmder](cb *CommandBuilder) T {
+	cmder, expireCmd := executeCmdInPipeline[T](cb.pipeliner, cb.pipeOpts, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+	cb.expireCmd = expireCmd
+	return cmder
+}
+
 // executeCmdInPipeline 在 Pipeline 中执行命令的通用方法（辅助函数）
 // 根据期望的返回类型创建对应的 redis.Cmder
 // 错误通过返回的 Cmder 的 Err() 方法获取（在 Pipeline Exec() 后）
-func executeCmdInPipeline[T redis.Cmder](pipeliner redis.Pipeliner, ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) T {
+// 设置了 Exp 时同时返回 EXPIRE 的 cmder, 否则返回 nil
+func executeCmdInPipeline[T redis.Cmder](pipeliner Processor, opts pipelineOpts, ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) (T, *redis.BoolCmd) {
 	var zero T
-	cmdList, key, subCmd := Build(ctx, cmd, cmdName, args, includeArgs...)
+	cmdList, key, subCmd, buildErr := TryBuild(ctx, cmd, cmdName, args, includeArgs...)
+	if errors.As(buildErr, &ErrUnknownCommand{}) {
+		// 没有定义的子命令是代码错误, 和 Build 一样 panic
+		panic(buildErr)
+	}
+	if buildErr != nil {
+		cmdList = []any{string(cmdName)}
+	} else {
+		cmdList, key = opts.version.apply(cmdName, cmdList, key)
+		cmdList, key = applyKeyPrefix(opts.keyPrefix, cmdName, cmdList, key)
+		if opts.argTransform != nil {
+			cmdList = opts.argTransform(cmdName, cmdList)
+		}
+	}
+	if key == "" {
+		// NoUseKey 的命令没有 key, 设置了 Exp 也不发送 EXPIRE
+		subCmd.Exp = nil
+	}
+
+	// 开启了合并时, 相同的读命令直接返回之前排队的 cmder
+	var dedupKey string
+	if opts.dedup != nil && buildErr == nil {
+		if readOnlyCommands[cmdName] && subCmd.Exp == nil {
+			dedupKey = fmt.Sprintf("%T:%s", zero, hashArgs(cmdList))
+			if queued, ok := opts.dedup.get(dedupKey); ok {
+				if result, ok := queued.(T); ok {
+					return result, nil
+				}
+			}
+		} else {
+			// 写命令之后相同的读命令可能得到不同的结果, 不能再合并之前的读命令
+			opts.dedup.reset()
+		}
+	}
 
 	// 根据泛型类型 T 创建对应的 redis.Cmder
-	var cmder redis.Cmder
-	switch any(zero).(type) {
-	case *redis.StringCmd:
-		cmder = redis.NewStringCmd(ctx, cmdList...)
-	case *redis.IntCmd:
-		cmder = redis.NewIntCmd(ctx, cmdList...)
-	case *redis.SliceCmd:
-		cmder = redis.NewSliceCmd(ctx, cmdList...)
-	case *redis.FloatCmd:
-		cmder = redis.NewFloatCmd(ctx, cmdList...)
-	case *redis.BoolCmd:
-		cmder = redis.NewBoolCmd(ctx, cmdList...)
-	case *redis.MapStringIntCmd:
-		cmder = redis.NewMapStringIntCmd(ctx, cmdList...)
-	case *redis.MapStringStringCmd:
-		cmder = redis.NewMapStringStringCmd(ctx, cmdList...)
-	case *redis.StringSliceCmd:
-		cmder = redis.NewStringSliceCmd(ctx, cmdList...)
-	case *redis.IntSliceCmd:
-		cmder = redis.NewIntSliceCmd(ctx, cmdList...)
-	case *redis.FloatSliceCmd:
-		cmder = redis.NewFloatSliceCmd(ctx, cmdList...)
-	case *redis.BoolSliceCmd:
-		cmder = redis.NewBoolSliceCmd(ctx, cmdList...)
-	case *redis.KeyValueSliceCmd:
-		cmder = redis.NewKeyValueSliceCmd(ctx, cmdList...)
-	case *redis.MapStringInterfaceCmd:
-		cmder = redis.NewMapStringInterfaceCmd(ctx, cmdList...)
-	case *redis.MapStringStringSliceCmd:
-		cmder = redis.NewMapStringStringSliceCmd(ctx, cmdList...)
-	case *redis.MapStringInterfaceSliceCmd:
-		cmder = redis.NewMapStringInterfaceSliceCmd(ctx, cmdList...)
-	case *redis.MapStringSliceInterfaceCmd:
-		cmder = redis.NewMapStringSliceInterfaceCmd(ctx, cmdList...)
-	case *redis.MapMapStringInterfaceCmd:
-		cmder = redis.NewMapMapStringInterfaceCmd(ctx, cmdList...)
-	case *redis.ZSliceCmd:
-		cmder = redis.NewZSliceCmd(ctx, cmdList...)
-	case *redis.ZSliceWithKeyCmd:
-		cmder = redis.NewZSliceWithKeyCmd(ctx, cmdList...)
-	case *redis.ZWithKeyCmd:
-		cmder = redis.NewZWithKeyCmd(ctx, cmdList...)
-	default:
-		cmder = redis.NewCmd(ctx, cmdList...)
+	cmder := newCmder[T](ctx, cmdName, cmdList, scanInPipeline)
+
+	if buildErr != nil {
+		// 构建失败的命令不会加入 pipeline
+		cmder.SetErr(buildErr)
+		result, _ := cmder.(T)
+		return result, nil
 	}
 
 	_ = pipeliner.Process(ctx, cmder)
+	if dedupKey != "" {
+		opts.dedup.put(dedupKey, cmder)
+	}
+	var expireCmd *redis.BoolCmd
 	if subCmd.Exp != nil {
 		exp := subCmd.Exp()
-		pipeliner.Expire(ctx, key, exp)
+		expireCmd = processExpire(ctx, pipeliner, key, exp, subCmd.ExpCondition)
 	}
 
 	result, ok := cmder.(T)
 	if !ok {
 		// 如果类型不匹配，返回零值
 		// 这种情况理论上不应该发生，因为我们在 switch 中已经创建了正确的类型
-		return zero
+		return zero, expireCmd
 	}
-	return result
+	return result, expireCmd
+}
+
+// Status 执行命令并返回 *redis.StatusCmd, 用于 SET/RENAME 等返回 OK 的命令
+// 如果在 Pipeline 中，命令会被添加到 Pipeline，结果需要在 Exec() 后获取
+// 错误通过返回的 Cmder 的 Err() 方法获取
+func (cb *CommandBuilder) Status() *redis.StatusCmd {
+	if cb.cmder != nil {
+		if statusCmd, ok := cb.cmder.(*redis.StatusCmd); ok {
+			return statusCmd
+		}
+	}
+	if cb.pipeliner != nil {
+		statusCmd := inPipeline[*redis.StatusCmd](cb)
+		cb.cmder = statusCmd
+		return statusCmd
+	}
+	return ExecuteCmd[*redis.StatusCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
 }
 
 // Int 执行命令并返回 *redis.IntCmd
@@ -357,7 +650,7 @@ func (cb *CommandBuilder) Int() *redis.IntCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		intCmd := executeCmdInPipeline[*redis.IntCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		intCmd := inPipeline[*redis.IntCmd](cb)
 		cb.cmder = intCmd
 		return intCmd
 	}
@@ -374,7 +667,7 @@ func (cb *CommandBuilder) Slice() *redis.SliceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		sliceCmd := executeCmdInPipeline[*redis.SliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		sliceCmd := inPipeline[*redis.SliceCmd](cb)
 		cb.cmder = sliceCmd
 		return sliceCmd
 	}
@@ -391,7 +684,7 @@ func (cb *CommandBuilder) Float() *redis.FloatCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		floatCmd := executeCmdInPipeline[*redis.FloatCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		floatCmd := inPipeline[*redis.FloatCmd](cb)
 		cb.cmder = floatCmd
 		return floatCmd
 	}
@@ -408,7 +701,7 @@ func (cb *CommandBuilder) Bool() *redis.BoolCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		boolCmd := executeCmdInPipeline[*redis.BoolCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		boolCmd := inPipeline[*redis.BoolCmd](cb)
 		cb.cmder = boolCmd
 		return boolCmd
 	}
@@ -425,7 +718,7 @@ func (cb *CommandBuilder) MapStringInt() *redis.MapStringIntCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		mapCmd := executeCmdInPipeline[*redis.MapStringIntCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		mapCmd := inPipeline[*redis.MapStringIntCmd](cb)
 		cb.cmder = mapCmd
 		return mapCmd
 	}
@@ -442,7 +735,7 @@ func (cb *CommandBuilder) MapStringString() *redis.MapStringStringCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		mapCmd := executeCmdInPipeline[*redis.MapStringStringCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		mapCmd := inPipeline[*redis.MapStringStringCmd](cb)
 		cb.cmder = mapCmd
 		return mapCmd
 	}
@@ -459,7 +752,7 @@ func (cb *CommandBuilder) StringSlice() *redis.StringSliceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		strSliceCmd := executeCmdInPipeline[*redis.StringSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		strSliceCmd := inPipeline[*redis.StringSliceCmd](cb)
 		cb.cmder = strSliceCmd
 		return strSliceCmd
 	}
@@ -476,7 +769,7 @@ func (cb *CommandBuilder) IntSlice() *redis.IntSliceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		intSliceCmd := executeCmdInPipeline[*redis.IntSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		intSliceCmd := inPipeline[*redis.IntSliceCmd](cb)
 		cb.cmder = intSliceCmd
 		return intSliceCmd
 	}
@@ -493,7 +786,7 @@ func (cb *CommandBuilder) FloatSlice() *redis.FloatSliceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		floatSliceCmd := executeCmdInPipeline[*redis.FloatSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		floatSliceCmd := inPipeline[*redis.FloatSliceCmd](cb)
 		cb.cmder = floatSliceCmd
 		return floatSliceCmd
 	}
@@ -510,7 +803,7 @@ func (cb *CommandBuilder) BoolSlice() *redis.BoolSliceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		boolSliceCmd := executeCmdInPipeline[*redis.BoolSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		boolSliceCmd := inPipeline[*redis.BoolSliceCmd](cb)
 		cb.cmder = boolSliceCmd
 		return boolSliceCmd
 	}
@@ -527,13 +820,30 @@ func (cb *CommandBuilder) KeyValueSlice() *redis.KeyValueSliceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		kvSliceCmd := executeCmdInPipeline[*redis.KeyValueSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		kvSliceCmd := inPipeline[*redis.KeyValueSliceCmd](cb)
 		cb.cmder = kvSliceCmd
 		return kvSliceCmd
 	}
 	return ExecuteCmd[*redis.KeyValueSliceCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
 }
 
+// KeyValues 执行命令并返回 *redis.KeyValuesCmd, 用于 LMPOP/BLMPOP 之类返回 key 和多个元素的命令
+// 如果在 Pipeline 中，命令会被添加到 Pipeline，结果需要在 Exec() 后获取
+// 错误通过返回的 Cmder 的 Err() 方法获取
+func (cb *CommandBuilder) KeyValues() *redis.KeyValuesCmd {
+	if cb.cmder != nil {
+		if kvCmd, ok := cb.cmder.(*redis.KeyValuesCmd); ok {
+			return kvCmd
+		}
+	}
+	if cb.pipeliner != nil {
+		kvCmd := inPipeline[*redis.KeyValuesCmd](cb)
+		cb.cmder = kvCmd
+		return kvCmd
+	}
+	return ExecuteCmd[*redis.KeyValuesCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+}
+
 // MapStringInterface 执行命令并返回 *redis.MapStringInterfaceCmd
 // 如果在 Pipeline 中，命令会被添加到 Pipeline，结果需要在 Exec() 后获取
 // 错误通过返回的 Cmder 的 Err() 方法获取
@@ -544,7 +854,7 @@ func (cb *CommandBuilder) MapStringInterface() *redis.MapStringInterfaceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		mapCmd := executeCmdInPipeline[*redis.MapStringInterfaceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		mapCmd := inPipeline[*redis.MapStringInterfaceCmd](cb)
 		cb.cmder = mapCmd
 		return mapCmd
 	}
@@ -561,7 +871,7 @@ func (cb *CommandBuilder) MapStringStringSlice() *redis.MapStringStringSliceCmd
 		}
 	}
 	if cb.pipeliner != nil {
-		mapCmd := executeCmdInPipeline[*redis.MapStringStringSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		mapCmd := inPipeline[*redis.MapStringStringSliceCmd](cb)
 		cb.cmder = mapCmd
 		return mapCmd
 	}
@@ -578,7 +888,7 @@ func (cb *CommandBuilder) MapStringInterfaceSlice() *redis.MapStringInterfaceSli
 		}
 	}
 	if cb.pipeliner != nil {
-		mapCmd := executeCmdInPipeline[*redis.MapStringInterfaceSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		mapCmd := inPipeline[*redis.MapStringInterfaceSliceCmd](cb)
 		cb.cmder = mapCmd
 		return mapCmd
 	}
@@ -595,7 +905,7 @@ func (cb *CommandBuilder) MapStringSliceInterface() *redis.MapStringSliceInterfa
 		}
 	}
 	if cb.pipeliner != nil {
-		mapCmd := executeCmdInPipeline[*redis.MapStringSliceInterfaceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		mapCmd := inPipeline[*redis.MapStringSliceInterfaceCmd](cb)
 		cb.cmder = mapCmd
 		return mapCmd
 	}
@@ -612,7 +922,7 @@ func (cb *CommandBuilder) MapMapStringInterface() *redis.MapMapStringInterfaceCm
 		}
 	}
 	if cb.pipeliner != nil {
-		mapCmd := executeCmdInPipeline[*redis.MapMapStringInterfaceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		mapCmd := inPipeline[*redis.MapMapStringInterfaceCmd](cb)
 		cb.cmder = mapCmd
 		return mapCmd
 	}
@@ -629,7 +939,7 @@ func (cb *CommandBuilder) ZSlice() *redis.ZSliceCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		zSliceCmd := executeCmdInPipeline[*redis.ZSliceCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		zSliceCmd := inPipeline[*redis.ZSliceCmd](cb)
 		cb.cmder = zSliceCmd
 		return zSliceCmd
 	}
@@ -646,7 +956,7 @@ func (cb *CommandBuilder) ZSliceWithKey() *redis.ZSliceWithKeyCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		zSliceCmd := executeCmdInPipeline[*redis.ZSliceWithKeyCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		zSliceCmd := inPipeline[*redis.ZSliceWithKeyCmd](cb)
 		cb.cmder = zSliceCmd
 		return zSliceCmd
 	}
@@ -663,9 +973,155 @@ func (cb *CommandBuilder) ZWithKey() *redis.ZWithKeyCmd {
 		}
 	}
 	if cb.pipeliner != nil {
-		zCmd := executeCmdInPipeline[*redis.ZWithKeyCmd](cb.pipeliner, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+		zCmd := inPipeline[*redis.ZWithKeyCmd](cb)
 		cb.cmder = zCmd
 		return zCmd
 	}
 	return ExecuteCmd[*redis.ZWithKeyCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
 }
+
+// Duration 执行命令并返回 *redis.DurationCmd, 用于 TTL/PTTL 之类返回剩余时间的命令
+// PTTL 等以 P 开头的命令按毫秒解析, 其他命令按秒解析
+// 如果在 Pipeline 中，命令会被添加到 Pipeline，结果需要在 Exec() 后获取
+// 错误通过返回的 Cmder 的 Err() 方法获取
+func (cb *CommandBuilder) Duration() *redis.DurationCmd {
+	if cb.cmder != nil {
+		if durationCmd, ok := cb.cmder.(*redis.DurationCmd); ok {
+			return durationCmd
+		}
+	}
+	if cb.pipeliner != nil {
+		durationCmd := inPipeline[*redis.DurationCmd](cb)
+		cb.cmder = durationCmd
+		return durationCmd
+	}
+	return ExecuteCmd[*redis.DurationCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+}
+
+// GeoPos 执行命令并返回 *redis.GeoPosCmd, 用于 GEOPOS
+// 如果在 Pipeline 中，命令会被添加到 Pipeline，结果需要在 Exec() 后获取
+// 错误通过返回的 Cmder 的 Err() 方法获取
+func (cb *CommandBuilder) GeoPos() *redis.GeoPosCmd {
+	if cb.cmder != nil {
+		if geoCmd, ok := cb.cmder.(*redis.GeoPosCmd); ok {
+			return geoCmd
+		}
+	}
+	if cb.pipeliner != nil {
+		geoCmd := inPipeline[*redis.GeoPosCmd](cb)
+		cb.cmder = geoCmd
+		return geoCmd
+	}
+	return ExecuteCmd[*redis.GeoPosCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+}
+
+// GeoSearchLocation 执行命令并返回 *redis.GeoSearchLocationCmd, 用于带 WITHCOORD/WITHDIST/WITHHASH 的 GEOSEARCH
+// 不带这些选项时 GEOSEARCH 只返回成员名, 使用 StringSlice() 获取
+// 如果在 Pipeline 中，命令会被添加到 Pipeline，结果需要在 Exec() 后获取
+// 错误通过返回的 Cmder 的 Err() 方法获取
+func (cb *CommandBuilder) GeoSearchLocation() *redis.GeoSearchLocationCmd {
+	if cb.cmder != nil {
+		if geoCmd, ok := cb.cmder.(*redis.GeoSearchLocationCmd); ok {
+			return geoCmd
+		}
+	}
+	if cb.pipeliner != nil {
+		geoCmd := inPipeline[*redis.GeoSearchLocationCmd](cb)
+		cb.cmder = geoCmd
+		return geoCmd
+	}
+	return ExecuteCmd[*redis.GeoSearchLocationCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+}
+
+// GeoLocation 执行命令并返回 *redis.GeoLocationCmd, 用于 GEORADIUS/GEORADIUSBYMEMBER
+// 如果在 Pipeline 中，命令会被添加到 Pipeline，结果需要在 Exec() 后获取
+// 错误通过返回的 Cmder 的 Err() 方法获取
+func (cb *CommandBuilder) GeoLocation() *redis.GeoLocationCmd {
+	if cb.cmder != nil {
+		if geoCmd, ok := cb.cmder.(*redis.GeoLocationCmd); ok {
+			return geoCmd
+		}
+	}
+	if cb.pipeliner != nil {
+		geoCmd := inPipeline[*redis.GeoLocationCmd](cb)
+		cb.cmder = geoCmd
+		return geoCmd
+	}
+	return ExecuteCmd[*redis.GeoLocationCmd](cb.client, cb.ctx, cb.cmd, cb.cmdName, cb.args, cb.includeArgs...)
+}
+
+// blockingCommands 最后一个参数是阻塞时间(秒)的命令
+var blockingCommands = map[Command]bool{
+	BLPOP: true, BRPOP: true, BLMOVE: true, BRPOPLPUSH: true, BZPOPMIN: true, BZPOPMAX: true,
+}
+
+// blockingTimeout 返回阻塞命令的阻塞时间, 不是阻塞命令或者阻塞时间无法解析时返回 false
+func blockingTimeout(cmdName Command, cmdList []any) (time.Duration, bool) {
+	if !blockingCommands[cmdName] || len(cmdList) < 3 {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(fmt.Sprint(cmdList[len(cmdList)-1]), 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// checkBlockingDeadline ctx 的剩余时间比阻塞时间短时, 连接会在 ctx 到期时被中断, 而不是由 redis 按阻塞时间返回 nil
+// 这种情况直接返回错误, 不发送命令; 阻塞时间为 0(一直阻塞)时由 ctx 控制结束
+func checkBlockingDeadline(ctx context.Context, cmdName Command, timeout time.Duration) error {
+	deadline, ok := ctx.Deadline()
+	if !ok || timeout == 0 {
+		return nil
+	}
+	if left := time.Until(deadline); left < timeout {
+		return fmt.Errorf("rdb: %s blocking timeout %s exceeds the context deadline (%s left)", cmdName, timeout, left.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// blockingCmder 阻塞命令的读超时需要按阻塞时间延长, 否则会被 Options.ReadTimeout 提前打断
+// go-redis 只给 BLPop、BLMove 等方法创建的 cmder 设置读超时, 这里用构建好的 key 调用对应的方法创建 cmder(只放入 pipeline, 不会执行), 参数保持构建好的参数
+// 结果类型需要和 go-redis 的方法一致, 如: BLPOP 使用 StringSlice(), BZPOPMIN 使用 ZWithKey(), BLMOVE 使用 String(), 否则返回错误
+func blockingCmder(ctx context.Context, client redis.UniversalClient, cmdName Command, cmder redis.Cmder, timeout time.Duration) (redis.Cmder, error) {
+	args := cmder.Args()
+	keys := make([]string, 0, len(args)-2)
+	for _, arg := range args[1 : len(args)-1] {
+		keys = append(keys, fmt.Sprint(arg))
+	}
+	if timeout > 0 && timeout < time.Second {
+		timeout = time.Second // 避免 go-redis 打印不足 1 秒的警告, 读超时只会更长
+	}
+	pipe := client.Pipeline()
+	var created redis.Cmder
+	switch {
+	case cmdName == BLPOP:
+		created = pipe.BLPop(ctx, timeout, keys...)
+	case cmdName == BRPOP:
+		created = pipe.BRPop(ctx, timeout, keys...)
+	case cmdName == BZPOPMIN:
+		created = pipe.BZPopMin(ctx, timeout, keys...)
+	case cmdName == BZPOPMAX:
+		created = pipe.BZPopMax(ctx, timeout, keys...)
+	case cmdName == BLMOVE && len(keys) == 4:
+		created = pipe.BLMove(ctx, keys[0], keys[1], keys[2], keys[3], timeout)
+	case cmdName == BRPOPLPUSH && len(keys) == 2:
+		created = pipe.BRPopLPush(ctx, keys[0], keys[1], timeout)
+	default:
+		return nil, fmt.Errorf("rdb: %s has unexpected args %v", cmdName, args)
+	}
+	if reflect.TypeOf(created) != reflect.TypeOf(cmder) {
+		return nil, fmt.Errorf("rdb: %s returns %T, can not be read as %T", cmdName, created, cmder)
+	}
+	// go-redis 的阻塞时间只精确到秒, 保留构建好的参数(小数秒、KeyPrefix 之后的 key)
+	copy(created.Args(), args)
+	return created, nil
+}
+
+// durationPrecision DurationCmd 解析回复时使用的单位
+func durationPrecision(cmdName Command) time.Duration {
+	if strings.HasPrefix(string(cmdName), "P") {
+		return time.Millisecond
+	}
+	return time.Second
+}
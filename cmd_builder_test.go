@@ -2,9 +2,14 @@ package rdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestBuildCmd 测试 BuildCmd 方法 - 构建命令但不执行
@@ -184,7 +189,7 @@ func TestExecuteCmd_Generic(t *testing.T) {
 	}
 
 	// 使用泛型方法 ExecuteCmd
-	cmd := ExecuteCmd[*redis.StringCmd](&client, context.Background(), StringCmd, GET, map[string]any{
+	cmd := ExecuteCmd[*redis.StringCmd](client, context.Background(), StringCmd, GET, map[string]any{
 		"keyName": "test_generic",
 	})
 	if cmd.Err() != nil {
@@ -265,7 +270,7 @@ func TestExecuteCmd_AllTypes(t *testing.T) {
 		"keyName": "test_all_types",
 		"value":   "test",
 	})
-	strCmd, _ := client.Get(context.Background(), StringCmd, map[string]any{"keyName": "test_all_types"}).String()
+	strCmd := client.Get(context.Background(), StringCmd, map[string]any{"keyName": "test_all_types"}).String()
 	fmt.Printf("String(): %T\n", strCmd)
 
 	// 测试 Int()
@@ -281,7 +286,7 @@ func TestExecuteCmd_AllTypes(t *testing.T) {
 		"keyName": "test_all_types_int",
 		"value":   "10",
 	})
-	intCmd, _ := client.Incr(context.Background(), IntCmd, map[string]any{"keyName": "test_all_types_int"}).Int()
+	intCmd := client.Incr(context.Background(), IntCmd, map[string]any{"keyName": "test_all_types_int"}).Int()
 	fmt.Printf("Int(): %T, value: %d\n", intCmd, intCmd.Val())
 
 	// 测试 Slice()
@@ -296,7 +301,7 @@ func TestExecuteCmd_AllTypes(t *testing.T) {
 	client.HMSet(context.Background(), HashCmd, map[string]any{
 		"keyName": "test_all_types_slice",
 	}, "field1", "value1")
-	sliceCmd, _ := client.HGetAll(context.Background(), HashCmd, map[string]any{"keyName": "test_all_types_slice"}).Slice()
+	sliceCmd := client.HGetAll(context.Background(), HashCmd, map[string]any{"keyName": "test_all_types_slice"}).Slice()
 	fmt.Printf("Slice(): %T\n", sliceCmd)
 
 	// 测试 Float()
@@ -312,7 +317,7 @@ func TestExecuteCmd_AllTypes(t *testing.T) {
 		"keyName": "test_all_types_float",
 		"value":   "10.5",
 	})
-	floatCmd, _ := client.IncrByFloat(context.Background(), FloatCmd, map[string]any{
+	floatCmd := client.IncrByFloat(context.Background(), FloatCmd, map[string]any{
 		"keyName":   "test_all_types_float",
 		"increment": 2.5,
 	}).Float()
@@ -327,9 +332,512 @@ func TestExecuteCmd_AllTypes(t *testing.T) {
 			},
 		},
 	}
-	boolCmd, _ := client.SetNx(context.Background(), BoolCmd, map[string]any{
+	boolCmd := client.SetNx(context.Background(), BoolCmd, map[string]any{
 		"keyName": "test_all_types_bool",
 		"value":   "test",
 	}).Bool()
 	fmt.Printf("Bool(): %T, value: %v\n", boolCmd, boolCmd.Val())
 }
+
+// TestRedisClient_BuildMany 测试 BuildMany 方法 - 批量构建命令但不执行
+func TestRedisClient_BuildMany(t *testing.T) {
+	client := &RedisClient{}
+
+	var StringCmd = RdCmd{
+		Key: "string:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {
+				Params: "{{value}} EX {{seconds}}",
+			},
+		},
+	}
+
+	argsList := make([]map[string]any, 0, 100)
+	for i := 0; i < 100; i++ {
+		argsList = append(argsList, map[string]any{
+			"keyName": fmt.Sprintf("user_%d", i),
+			"value":   i * 10,
+			"seconds": 60 + i,
+		})
+	}
+
+	cmdLists, err := client.BuildMany(context.Background(), StringCmd, SET, argsList)
+	if err != nil {
+		t.Fatalf("BuildMany failed: %v", err)
+	}
+	if len(cmdLists) != 100 {
+		t.Fatalf("Expected 100 commands, got %d", len(cmdLists))
+	}
+	for i, cmdList := range cmdLists {
+		want := []any{"SET", fmt.Sprintf("string:user_%d", i), strconv.Itoa(i * 10), "EX", strconv.Itoa(60 + i)}
+		if !reflect.DeepEqual(cmdList, want) {
+			t.Errorf("command %d: expected %v, got %v", i, want, cmdList)
+		}
+	}
+
+	if _, err := client.BuildMany(context.Background(), StringCmd, GET, argsList); err == nil {
+		t.Errorf("Expected error for unknown command")
+	}
+
+	// StrictArgs 缺少参数时返回 error 而不是 panic
+	strict := StringCmd
+	strict.CMD = map[Command]RdSubCmd{SET: {Params: "{{value}} EX {{seconds}}", StrictArgs: true}}
+	missing := []map[string]any{argsList[0], {"keyName": "user_x", "value": 1}}
+	if _, err := client.BuildMany(context.Background(), strict, SET, missing); err == nil || !strings.Contains(err.Error(), "argsList[1]") {
+		t.Errorf("Expected error for argsList[1] with missing seconds, got %v", err)
+	}
+
+	// CacheVersion、KeyPrefix 和 ArgTransform 和 BuildCmd 一样生效
+	scoped := &RedisClient{version: &cacheVersion{}, KeyPrefix: "t1:"}
+	scoped.SetCacheVersion("v1")
+	scoped.ArgTransform = func(cmdName Command, args []any) []any { return append(args, "KEEPTTL") }
+	cmdLists, err = scoped.BuildMany(context.Background(), StringCmd, SET, argsList[:1])
+	if want := []any{"SET", "t1:v1:string:user_0", "0", "EX", "60", "KEEPTTL"}; err != nil || !reflect.DeepEqual(cmdLists[0], want) {
+		t.Errorf("expected %v, got %v %v", want, cmdLists, err)
+	}
+}
+
+// TestCommandBuilder_CommandString 测试 CommandString 输出构建好的命令字符串
+func TestCommandBuilder_CommandString(t *testing.T) {
+	client := &RedisClient{}
+	ctx := context.Background()
+
+	var StringCmd = RdCmd{
+		Key: "string:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {
+				Params: "{{value}}",
+			},
+		},
+	}
+	cb := NewCommandBuilder(client, ctx, StringCmd, SET, map[string]any{"keyName": "k", "value": "v"})
+	if got := cb.CommandString(); got != "SET string:k v" {
+		t.Errorf("unexpected command string: %q", got)
+	}
+
+	long := strings.Repeat("a", 100)
+	cb = NewCommandBuilder(client, ctx, StringCmd, SET, map[string]any{"keyName": "k", "value": long})
+	if got, want := cb.CommandString(), "SET string:k "+long[:64]+"...(100 bytes)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// 多字节字符不会被截断成无效的 UTF-8, 第 22 个 "中" 跨过第 64 个字节, 整个去掉
+	chinese := strings.Repeat("中", 30)
+	cb = NewCommandBuilder(client, ctx, StringCmd, SET, map[string]any{"keyName": "k", "value": chinese})
+	if got, want := cb.CommandString(), "SET string:k "+strings.Repeat("中", 21)+"...(90 bytes)"; got != want {
+		t.Errorf("unexpected command string: %q", got)
+	}
+
+	cb = NewCommandBuilder(client, ctx, StringCmd, SET, map[string]any{"keyName": "k", "value": "a\x00b"})
+	if got := cb.CommandString(); got != `SET string:k "a\x00b"` {
+		t.Errorf("unexpected command string: %q", got)
+	}
+
+	var AuthCmd = RdCmd{
+		CMD: map[Command]RdSubCmd{
+			AUTH: {Params: "{{user}} {{password}}", NoUseKey: true},
+		},
+	}
+	cb = NewCommandBuilder(client, ctx, AuthCmd, AUTH, map[string]any{"user": "admin", "password": "secret"}).Redact(2)
+	if got := cb.CommandString(); got != "AUTH admin ***" {
+		t.Errorf("unexpected command string: %q", got)
+	}
+}
+
+// TestRedisClient_CommandHash 测试相同的参数得到相同的 hash, 不同的参数得到不同的 hash
+func TestRedisClient_CommandHash(t *testing.T) {
+	client := &RedisClient{}
+	ctx := context.Background()
+
+	var HashCmd = RdCmd{
+		Key: "hash:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			HGET:  {Params: "{{field}}"},
+			HMSET: {},
+		},
+	}
+	hash := func(cmdName Command, args map[string]any, includeArgs ...any) string {
+		t.Helper()
+		h, err := client.CommandHash(ctx, HashCmd, cmdName, args, includeArgs...)
+		if err != nil {
+			t.Fatalf("CommandHash failed: %v", err)
+		}
+		return h
+	}
+
+	a := hash(HGET, map[string]any{"keyName": "u1", "field": "name"})
+	if b := hash(HGET, map[string]any{"keyName": "u1", "field": "name"}); a != b {
+		t.Errorf("identical args should produce identical hashes: %s != %s", a, b)
+	}
+	if b := hash(HGET, map[string]any{"keyName": "u1", "field": "age"}); a == b {
+		t.Errorf("different args should produce different hashes")
+	}
+	if b := hash(HGET, map[string]any{"keyName": "u", "field": "1name"}); a == b {
+		t.Errorf("arg boundaries should affect the hash")
+	}
+
+	// map 参数按 key 排序展开, 多次计算结果一致
+	fields := map[string]any{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}
+	m := hash(HMSET, map[string]any{"keyName": "u1"}, fields)
+	for i := 0; i < 20; i++ {
+		if b := hash(HMSET, map[string]any{"keyName": "u1"}, fields); m != b {
+			t.Fatalf("map expansion should be deterministic: %s != %s", m, b)
+		}
+	}
+	if b := hash(HMSET, map[string]any{"keyName": "u1"}, "a", 1, "b", 2, "c", 3, "d", 4, "e", 5); m != b {
+		t.Errorf("sorted map expansion should match the flat args")
+	}
+
+	if _, err := client.CommandHash(ctx, HashCmd, GET, nil); err == nil {
+		t.Errorf("Expected error for unknown command")
+	}
+
+	// hash 的是实际发送的参数, CacheVersion 和 KeyPrefix 不同时结果不同
+	scoped := &RedisClient{version: &cacheVersion{}}
+	scoped.SetCacheVersion("v1")
+	h1, _ := scoped.CommandHash(ctx, HashCmd, HGET, map[string]any{"keyName": "u1", "field": "name"})
+	if h1 == a || h1 != hashArgs([]any{"HGET", "v1:hash:u1", "name"}) {
+		t.Errorf("CacheVersion should be part of the hash")
+	}
+	scoped.SetCacheVersion("v2")
+	if h2, _ := scoped.CommandHash(ctx, HashCmd, HGET, map[string]any{"keyName": "u1", "field": "name"}); h2 == h1 {
+		t.Errorf("changing CacheVersion should change the hash")
+	}
+	scoped.KeyPrefix = "t1:"
+	if h3, _ := scoped.CommandHash(ctx, HashCmd, HGET, map[string]any{"keyName": "u1", "field": "name"}); h3 != hashArgs([]any{"HGET", "t1:v2:hash:u1", "name"}) {
+		t.Errorf("KeyPrefix should be part of the hash")
+	}
+}
+
+// TestRedisClient_ArgTransform 测试 ArgTransform 追加的参数会被发送到 redis, 包括 pipeline 中的命令
+func TestRedisClient_ArgTransform(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "SET" {
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+	var StringCmd = RdCmd{
+		Key: "string:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {Params: "{{value}}"},
+		},
+	}
+	ctx := context.Background()
+
+	// 未设置时原样发送
+	client.Set(ctx, StringCmd, map[string]any{"keyName": "a", "value": "1"}).Status()
+
+	client.ArgTransform = func(cmdName Command, args []any) []any {
+		if cmdName == SET {
+			return append(args, "KEEPTTL")
+		}
+		return args
+	}
+	if err := client.Set(ctx, StringCmd, map[string]any{"keyName": "b", "value": "2"}).Status().Err(); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	cb := client.Set(ctx, StringCmd, map[string]any{"keyName": "c", "value": "3"})
+	if got := cb.CommandString(); got != "SET string:c 3 KEEPTTL" {
+		t.Errorf("unexpected command string: %q", got)
+	}
+
+	pip := client.PipeLine()
+	pip.Set(ctx, StringCmd, map[string]any{"keyName": "d", "value": "4"}).Status()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+
+	want := [][]string{
+		{"SET", "string:a", "1"},
+		{"SET", "string:b", "2", "KEEPTTL"},
+		{"SET", "string:d", "4", "KEEPTTL"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// txRecorder 记录通过 MULTI/EXEC 发送的命令
+type txRecorder struct {
+	txCmds [][]any
+}
+
+func (h *txRecorder) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *txRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+
+func (h *txRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, c := range cmds {
+			h.txCmds = append(h.txCmds, c.Args())
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// TestExecuteCmd_AtomicExpire 测试 AtomicExpire 时主命令和 EXPIRE 在同一个 MULTI/EXEC 中发送, 默认仍然单独发送 EXPIRE
+func TestExecuteCmd_AtomicExpire(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "EXPIRE" {
+			return 1
+		}
+		return fakeStatus("OK")
+	})
+	recorder := &txRecorder{}
+	client.Client.AddHook(recorder)
+	exp := func() time.Duration { return time.Minute }
+	cmd := RdCmd{
+		Key: "session:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET:   {Params: "{{value}}", Exp: exp, AtomicExpire: true},
+			SETNX: {Params: "{{value}}", Exp: exp},
+		},
+	}
+	ctx := context.Background()
+
+	if err := client.Set(ctx, cmd, map[string]any{"id": 1, "value": "a"}).Status().Err(); err != nil {
+		t.Fatalf("atomic Set failed: %v", err)
+	}
+	wantTx := [][]any{
+		{"multi"},
+		{"SET", "session:1", "a"},
+		{"expire", "session:1", int64(60)},
+		{"exec"},
+	}
+	if !reflect.DeepEqual(recorder.txCmds, wantTx) {
+		t.Errorf("Expected %v, got %v", wantTx, recorder.txCmds)
+	}
+	want := [][]string{
+		{"SET", "session:1", "a"},
+		{"EXPIRE", "session:1", "60"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	fake.Reset()
+	recorder.txCmds = nil
+	if err := client.SetNx(ctx, cmd, map[string]any{"id": 2, "value": "b"}).Status().Err(); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if len(recorder.txCmds) != 0 {
+		t.Errorf("Expected no transaction without AtomicExpire, got %v", recorder.txCmds)
+	}
+	want = [][]string{
+		{"SETNX", "session:2", "b"},
+		{"EXPIRE", "session:2", "60"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+}
+
+// TestExecuteCmd_AtomicExpireNoRetry 测试 AtomicExpire 的事务失败时返回错误, 设置了 Retry 也不会重新发送事务
+func TestExecuteCmd_AtomicExpireNoRetry(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "INCR" {
+			return errors.New("LOADING Redis is loading the dataset in memory")
+		}
+		return 1
+	})
+	client.Retry = &RetryPolicy{MaxRetries: 3}
+	cmd := RdCmd{
+		Key: "counter:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			INCR: {Exp: func() time.Duration { return time.Minute }, AtomicExpire: true},
+		},
+	}
+
+	err := client.Incr(context.Background(), cmd, map[string]any{"id": 1}).Int().Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "LOADING") {
+		t.Errorf("Expected LOADING error from the transaction, got %v", err)
+	}
+	want := [][]string{
+		{"INCR", "counter:1"},
+		{"EXPIRE", "counter:1", "60"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected a single transaction %v, got %v", want, got)
+	}
+}
+
+// TestExecuteCmd_Timeout 测试 RdSubCmd.Timeout 只作用在设置了超时的命令上
+func TestExecuteCmd_Timeout(t *testing.T) {
+	client, _ := newFakeClient(t, func(args []string) any {
+		if args[0] == "SINTERSTORE" {
+			time.Sleep(200 * time.Millisecond)
+			return 3
+		}
+		if args[0] == "GET" {
+			return "v"
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "set:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SINTERSTORE: {Params: "{{keys}}", Timeout: 20 * time.Millisecond},
+			GET:         {Timeout: time.Second},
+		},
+	}
+	ctx := context.Background()
+
+	start := time.Now()
+	err := client.SInterStore(ctx, cmd, map[string]any{"id": 1, "keys": []string{"a", "b"}}).Int().Err()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected the command to time out early, took %v", elapsed)
+	}
+	if got, err := client.Get(ctx, cmd, map[string]any{"id": 2}).String().Result(); err != nil || got != "v" {
+		t.Errorf("Expected GET within its timeout to succeed, got %q %v", got, err)
+	}
+}
+
+// TestRedisClient_CommandString 测试 redis-cli 格式的命令字符串
+func TestRedisClient_CommandString(t *testing.T) {
+	client := &RedisClient{}
+	ctx := context.Background()
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET:  {Params: "{{value}}"},
+			AUTH: {Params: "{{user}} {{password}}", NoUseKey: true},
+		},
+	}
+
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{"alice", `SET user:1 alice`},
+		{"hello world", `SET user:1 "hello world"`},
+		{`say "hi"`, `SET user:1 "say \"hi\""`},
+		{"", `SET user:1 ""`},
+		{"a\nb\x00", `SET user:1 "a\nb\x00"`},
+		{"中", `SET user:1 "\xe4\xb8\xad"`},
+	}
+	for _, tt := range tests {
+		if got := client.CommandString(ctx, cmd, SET, map[string]any{"id": 1, "value": tt.value}); got != tt.want {
+			t.Errorf("CommandString(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	if got := client.CommandString(ctx, cmd, AUTH, map[string]any{"user": "admin", "password": "secret pass"}); got != "AUTH *** ***" {
+		t.Errorf("Expected redacted AUTH, got %s", got)
+	}
+	if got := sensitivePositions([]any{"HELLO", "3", "AUTH", "admin", "secret", "SETNAME", "app"}); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("Expected HELLO password redacted, got %v", got)
+	}
+	if got := sensitivePositions([]any{"CONFIG", "SET", "maxmemory", "1gb", "requirepass", "secret"}); !reflect.DeepEqual(got, []int{5}) {
+		t.Errorf("Expected requirepass redacted, got %v", got)
+	}
+	if got := client.CommandString(ctx, cmd, GET, nil); !strings.HasPrefix(got, "GET (build failed:") {
+		t.Errorf("Expected build error, got %s", got)
+	}
+}
+
+// TestBuilder_Raw 测试 Raw 直接使用参数执行命令, 直接执行和 Pipeline 中都可以使用
+func TestBuilder_Raw(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "OBJECT":
+			return 5
+		case "ECHO":
+			return args[1]
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if n, err := client.Raw(ctx, "OBJECT", "FREQ", "{{user}}:1").Int().Result(); err != nil || n != 5 {
+		t.Fatalf("Raw failed: %d %v", n, err)
+	}
+	pip := client.PipeLine()
+	echo := pip.Raw(ctx, "ECHO", []byte("a b")).String()
+	if _, err := pip.Exec(ctx); err != nil || echo.Val() != "a b" {
+		t.Fatalf("Raw in pipeline failed: %q %v", echo.Val(), err)
+	}
+	func() {
+		defer func() {
+			if _, ok := recover().(ErrUnknownCommand); !ok {
+				t.Errorf("Expected ErrUnknownCommand panic without command name")
+			}
+		}()
+		client.Raw(ctx).Err()
+	}()
+	// 参数不经过模板, {{user}} 原样发送
+	want := [][]string{{"OBJECT", "FREQ", "{{user}}:1"}, {"ECHO", "a b"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestExecuteCmd_NoUseKey 测试 NoUseKey 的命令即使 RdCmd.Key 不为空也不带 key, 设置了 Exp 也不发送 EXPIRE
+func TestExecuteCmd_NoUseKey(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		return fakeStatus("PONG")
+	})
+	ctx := context.Background()
+	client.SetCacheVersion("v1")
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			PING: {NoUseKey: true, Exp: func() time.Duration { return time.Minute }},
+		},
+	}
+	args := map[string]any{"id": 1}
+
+	got, key, _, err := TryBuild(ctx, cmd, PING, args)
+	if err != nil || !reflect.DeepEqual(got, []any{"PING"}) || key != "" {
+		t.Fatalf("TryBuild: expected [PING] with empty key, got %v %q, %v", got, key, err)
+	}
+	prepared, err := PrepareCmd(cmd, PING)
+	if err != nil {
+		t.Fatalf("PrepareCmd failed: %v", err)
+	}
+	if got, key := prepared.Build(context.Background(), args); !reflect.DeepEqual(got, []any{"PING"}) || key != "" {
+		t.Errorf("PreparedCmd: expected [PING] with empty key, got %v %q", got, key)
+	}
+
+	if v, err := client.Handler(ctx, cmd, PING, args).Status().Result(); err != nil || v != "PONG" {
+		t.Fatalf("PING: expected PONG, got %q, %v", v, err)
+	}
+	pip := client.PipeLine()
+	pip.Handler(ctx, cmd, PING, args).Status()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+
+	want := [][]string{{"PING"}, {"PING"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
+
+// TestExecuteCmd_UnknownCommand 测试没有定义的子命令和 Build 一样 panic, 直接执行和 pipeline 中都不会发送命令
+func TestExecuteCmd_UnknownCommand(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		return fakeStatus("OK")
+	})
+	ctx := context.Background()
+	cmd := RdCmd{Key: "user:{{id}}", CMD: map[Command]RdSubCmd{GET: {}}}
+	expectPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if e, ok := recover().(ErrUnknownCommand); !ok || e.Command != SET {
+				t.Errorf("%s: expected panic with ErrUnknownCommand{SET}", name)
+			}
+		}()
+		f()
+	}
+
+	expectPanic("client", func() { client.Set(ctx, cmd, map[string]any{"id": 1}).Status() })
+	expectPanic("pipeline", func() { client.PipeLine().Set(ctx, cmd, map[string]any{"id": 1}).Status() })
+	if got := fake.Commands(); len(got) != 0 {
+		t.Errorf("Expected no commands, got %v", got)
+	}
+}
@@ -0,0 +1,149 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"strings"
+)
+
+// DecodePairs 把平铺的 [k1, v1, k2, v2, ...] 回复解析成 map, 适用于 CONFIG GET 之类的命令
+func DecodePairs(cmd *redis.SliceCmd) (map[string]string, error) {
+	vals, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeFieldPairs(vals)
+}
+
+// decodeFieldPairs 把 [field, value, ...] 解析成 map
+func decodeFieldPairs(vals []any) (map[string]string, error) {
+	if len(vals)%2 != 0 {
+		return nil, fmt.Errorf("rdb: decode pairs: got %d elements, want a multiple of 2", len(vals))
+	}
+	pairs := make(map[string]string, len(vals)/2)
+	for i := 0; i < len(vals); i += 2 {
+		key, err := decodeString(vals[i])
+		if err != nil {
+			return nil, err
+		}
+		if pairs[key], err = decodeString(vals[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	return pairs, nil
+}
+
+// DecodeTriples 把平铺的 [a1, b1, c1, a2, b2, c2, ...] 回复按三个一组解析
+func DecodeTriples(cmd *redis.SliceCmd) ([][3]string, error) {
+	vals, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(vals)%3 != 0 {
+		return nil, fmt.Errorf("rdb: decode triples: got %d elements, want a multiple of 3", len(vals))
+	}
+	triples := make([][3]string, 0, len(vals)/3)
+	for i := 0; i < len(vals); i += 3 {
+		var triple [3]string
+		for j := range triple {
+			if triple[j], err = decodeString(vals[i+j]); err != nil {
+				return nil, err
+			}
+		}
+		triples = append(triples, triple)
+	}
+	return triples, nil
+}
+
+// DecodeZSlice 把 ZRANGE WITHSCORES 之类的回复用 fn 逐个转换成自己的类型, 命令出错时直接返回错误, 不会调用 fn
+//
+//	ranks, err := DecodeZSlice(client.ZRangeWithScores(ctx, cmd, args).ZSlice(), func(z redis.Z) Rank {
+//		return Rank{User: z.Member.(string), Score: z.Score}
+//	})
+func DecodeZSlice[T any](cmd *redis.ZSliceCmd, fn func(redis.Z) T) ([]T, error) {
+	zs, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]T, len(zs))
+	for i, z := range zs {
+		out[i] = fn(z)
+	}
+	return out, nil
+}
+
+// decodeString 把回复中的单个元素转换成字符串, nil 转换成空字符串
+func decodeString(val any) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case int64:
+		return fmt.Sprint(v), nil
+	case float64:
+		return fmt.Sprint(v), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("rdb: decode: unexpected element type %T", val)
+	}
+}
+
+// LoadInto 按照 dest 结构体字段的 rdb 标签批量读取, 在一个 pipeline 中对每个字段执行 GET, 再把结果扫描到对应的字段中
+// 标签是 key 模板, 占位符从 args 中取值, 如:
+//
+//	type Profile struct {
+//		Name  string  `rdb:"user:{{id}}:name"`
+//		Age   int     `rdb:"user:{{id}}:age"`
+//		Score float64 `rdb:"user:{{id}}:score"`
+//	}
+//
+// dest 需要是结构体指针, 没有标签或者标签为 "-" 的字段会被忽略; 不存在的 key 对应的字段保持原值; key 会加上 KeyPrefix
+// 字段类型需要是 go-redis Scan 支持的类型(基础类型或者实现了 encoding.BinaryUnmarshaler)
+func (rdm *RedisClient) LoadInto(ctx context.Context, dest any, args map[string]any) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("rdb: LoadInto needs a non-nil struct pointer, got %T", dest)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	type loadField struct {
+		value reflect.Value
+		cmd   *redis.StringCmd
+	}
+	var fields []loadField
+	_, err := rdm.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := 0; i < rt.NumField(); i++ {
+			tag := rt.Field(i).Tag.Get("rdb")
+			if tag == "" || tag == "-" || !rt.Field(i).IsExported() {
+				continue
+			}
+			key, missing, err := replaceTemplate([]byte(tag), args)
+			if err != nil {
+				return fmt.Errorf("rdb: LoadInto %s: %w", rt.Field(i).Name, err)
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("rdb: LoadInto %s has unresolved placeholders: %s", rt.Field(i).Name, strings.Join(missing, ", "))
+			}
+			fields = append(fields, loadField{value: rv.Field(i), cmd: pipe.Get(ctx, rdm.KeyPrefix+string(key))})
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	for _, field := range fields {
+		if errors.Is(field.cmd.Err(), redis.Nil) {
+			continue
+		}
+		if err := field.cmd.Scan(field.value.Addr().Interface()); err != nil {
+			return fmt.Errorf("rdb: LoadInto %s: %w", field.cmd.Args()[1], err)
+		}
+	}
+	return nil
+}
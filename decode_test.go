@@ -0,0 +1,123 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"testing"
+)
+
+// TestDecodePairs 测试平铺的 k/v 回复解析
+func TestDecodePairs(t *testing.T) {
+	cmd := redis.NewSliceCmd(context.Background(), "CONFIG", "GET", "max*")
+	cmd.SetVal([]any{"maxmemory", "0", "maxclients", "10000", "maxmemory-samples", int64(5)})
+
+	pairs, err := DecodePairs(cmd)
+	if err != nil {
+		t.Fatalf("DecodePairs failed: %v", err)
+	}
+	want := map[string]string{"maxmemory": "0", "maxclients": "10000", "maxmemory-samples": "5"}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("Expected %v, got %v", want, pairs)
+	}
+
+	cmd.SetVal([]any{"maxmemory"})
+	if _, err := DecodePairs(cmd); err == nil {
+		t.Errorf("Expected error for odd number of elements")
+	}
+}
+
+// TestDecodeTriples 测试平铺的三元组回复解析
+func TestDecodeTriples(t *testing.T) {
+	cmd := redis.NewSliceCmd(context.Background(), "XPENDING", "stream", "group")
+	cmd.SetVal([]any{"consumer-1", "1-0", int64(3), "consumer-2", "2-0", nil})
+
+	triples, err := DecodeTriples(cmd)
+	if err != nil {
+		t.Fatalf("DecodeTriples failed: %v", err)
+	}
+	want := [][3]string{{"consumer-1", "1-0", "3"}, {"consumer-2", "2-0", ""}}
+	if !reflect.DeepEqual(triples, want) {
+		t.Errorf("Expected %v, got %v", want, triples)
+	}
+
+	cmd.SetVal([]any{"a", "b"})
+	if _, err := DecodeTriples(cmd); err == nil {
+		t.Errorf("Expected error for incomplete triple")
+	}
+	cmd.SetVal([]any{"a", "b", []any{"nested"}})
+	if _, err := DecodeTriples(cmd); err == nil {
+		t.Errorf("Expected error for nested element")
+	}
+}
+
+// TestDecodeZSlice 测试 ZSliceCmd 的结果转换成自定义类型, 命令出错时不调用转换函数
+func TestDecodeZSlice(t *testing.T) {
+	type rank struct {
+		User  string
+		Score float64
+	}
+	toRank := func(z redis.Z) rank {
+		return rank{User: z.Member.(string), Score: z.Score}
+	}
+	cmd := redis.NewZSliceCmd(context.Background(), "ZRANGE", "rank", 0, -1, "WITHSCORES")
+	cmd.SetVal([]redis.Z{{Member: "tom", Score: 1.5}, {Member: "amy", Score: 3}})
+
+	ranks, err := DecodeZSlice(cmd, toRank)
+	if err != nil {
+		t.Fatalf("DecodeZSlice failed: %v", err)
+	}
+	if want := []rank{{"tom", 1.5}, {"amy", 3}}; !reflect.DeepEqual(ranks, want) {
+		t.Errorf("Expected %v, got %v", want, ranks)
+	}
+
+	cmd.SetErr(errors.New("WRONGTYPE"))
+	if ranks, err := DecodeZSlice(cmd, toRank); err == nil || ranks != nil {
+		t.Errorf("Expected error without results, got %v %v", ranks, err)
+	}
+}
+
+// TestRedisClient_LoadInto 测试在一个 pipeline 中读取三个字段并扫描到结构体
+func TestRedisClient_LoadInto(t *testing.T) {
+	values := map[string]string{
+		"user:7:name":  "alice",
+		"user:7:age":   "30",
+		"user:7:score": "98.5",
+	}
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "GET" {
+			if v, ok := values[args[1]]; ok {
+				return v
+			}
+		}
+		return nil
+	})
+	type profile struct {
+		Name   string  `rdb:"user:{{id}}:name"`
+		Age    int     `rdb:"user:{{id}}:age"`
+		Score  float64 `rdb:"user:{{id}}:score"`
+		Level  int     `rdb:"user:{{id}}:level"`
+		Cached bool    `rdb:"-"`
+	}
+	ctx := context.Background()
+
+	p := profile{Level: 1}
+	if err := client.LoadInto(ctx, &p, map[string]any{"id": 7}); err != nil {
+		t.Fatalf("LoadInto failed: %v", err)
+	}
+	want := profile{Name: "alice", Age: 30, Score: 98.5, Level: 1}
+	if p != want {
+		t.Errorf("Expected %+v, got %+v", want, p)
+	}
+	if got := len(fake.Commands()); got != 4 {
+		t.Errorf("Expected 4 GET commands, got %d", got)
+	}
+
+	if err := client.LoadInto(ctx, p, map[string]any{"id": 7}); err == nil {
+		t.Errorf("Expected error for non-pointer dest")
+	}
+	if err := client.LoadInto(ctx, &p, nil); err == nil {
+		t.Errorf("Expected error for unresolved key template")
+	}
+}
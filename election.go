@@ -0,0 +1,135 @@
+package rdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// newToken 生成锁和选主使用的随机 token, 用来区分持有者
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// keepAlive 每 ttl/3 续期一次 key, 直到 stop 被调用或者 ctx 取消
+// key 已经不属于 token 或者连续续期失败超过 ttl 时认为已经失去 key, 调用 onLost 后退出
+func (rdm *RedisClient) keepAlive(ctx context.Context, key, token string, ttl time.Duration, onLost func()) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(max(ttl/3, time.Millisecond))
+		defer ticker.Stop()
+		lastRenew := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			ok, err := rdm.compareAndPExpire(ctx, key, token, ttl)
+			if ctx.Err() != nil {
+				return
+			}
+			switch {
+			case err == nil && ok:
+				lastRenew = time.Now()
+				continue
+			case err == nil:
+				// key 已经过期或者被别人持有
+			case time.Since(lastRenew) < ttl:
+				slog.Warn("rdb: renew key", "key", key, "error", err)
+				continue
+			}
+			onLost()
+			return
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Leadership Campaign 成功之后持有的领导权
+type Leadership struct {
+	key    string
+	token  string
+	client *RedisClient
+	stop   func()
+	lost   chan struct{}
+	once   sync.Once
+}
+
+// Campaign 使用 SET key token NX PX ttl 竞选 key 对应的领导者, 竞选失败(已经有领导者)时返回 nil
+// 成功之后在后台定期续期, 续期失败(key 过期或者被别人持有)时失去领导权, Lost() 返回的 channel 会被关闭
+// ctx 取消时自动 Resign; 设置了 KeyPrefix 时竞选的是 KeyPrefix + key, 续期和放弃通过脚本执行, 同样加上前缀
+func (rdm *RedisClient) Campaign(ctx context.Context, key string, ttl time.Duration) (*Leadership, error) {
+	token := newToken()
+	ok, err := rdm.Client.SetNX(ctx, rdm.KeyPrefix+key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, err
+	}
+	l := &Leadership{key: key, token: token, client: rdm, lost: make(chan struct{})}
+	l.stop = rdm.keepAlive(ctx, key, token, ttl, func() {
+		slog.Warn("rdb: leadership lost", "key", key)
+		l.once.Do(func() { close(l.lost) })
+	})
+	context.AfterFunc(ctx, l.Resign)
+	return l, nil
+}
+
+// Lost 返回失去领导权时关闭的 channel, Resign 之后也会关闭
+func (l *Leadership) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// IsLeader 是否仍然是领导者
+func (l *Leadership) IsLeader() bool {
+	select {
+	case <-l.lost:
+		return false
+	default:
+		return true
+	}
+}
+
+// Resign 放弃领导权: 停止续期并删除 key(只有 key 仍然属于自己时才删除), 其他竞选者可以立即成为领导者; 可以重复调用
+func (l *Leadership) Resign() {
+	l.stop()
+	resigned := false
+	l.once.Do(func() {
+		resigned = true
+		close(l.lost)
+	})
+	if !resigned {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := l.client.compareAndDelete(ctx, l.key, l.token); err != nil {
+		slog.Warn("rdb: resign leadership", "key", l.key, "error", err)
+	}
+}
+
+// Elect 竞选 key 对应的领导者, 是 Campaign 的简化版本, 如:
+//
+//	isLeader, resign, err := client.Elect(ctx, "job:cleanup:leader", 10*time.Second)
+//	if isLeader {
+//		defer resign()
+//		// 执行只能有一个实例运行的任务
+//	}
+//
+// 不是领导者时 resign 什么也不做; 需要知道什么时候失去领导权时使用 Campaign
+func (rdm *RedisClient) Elect(ctx context.Context, key string, ttl time.Duration) (isLeader bool, resign func(), err error) {
+	l, err := rdm.Campaign(ctx, key, ttl)
+	if l == nil {
+		return false, func() {}, err
+	}
+	return true, l.Resign, nil
+}
@@ -0,0 +1,198 @@
+package rdb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLockStore 假服务中模拟锁和选主用到的 SET NX、GET、DEL 以及续期和释放的脚本
+type fakeLockStore struct {
+	mu      sync.Mutex
+	vals    map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeLockStore() *fakeLockStore {
+	return &fakeLockStore{vals: map[string]string{}, expires: map[string]time.Time{}}
+}
+
+// get 返回没有过期的值, 调用方需要持有锁
+func (s *fakeLockStore) get(key string) (string, bool) {
+	if exp, ok := s.expires[key]; ok && time.Now().After(exp) {
+		delete(s.vals, key)
+		delete(s.expires, key)
+	}
+	val, ok := s.vals[key]
+	return val, ok
+}
+
+// Set 直接修改 key 的值, 模拟锁被别人持有
+func (s *fakeLockStore) Set(key, val string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vals[key] = val
+}
+
+// Del 直接删除 key
+func (s *fakeLockStore) Del(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.vals, key)
+	delete(s.expires, key)
+}
+
+// Get 返回 key 当前的值
+func (s *fakeLockStore) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key)
+}
+
+func (s *fakeLockStore) handle(args []string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch args[0] {
+	case "SET":
+		key, val := args[1], args[2]
+		var ttl time.Duration
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "EX", "PX":
+				n, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(n) * time.Millisecond
+				if strings.ToUpper(args[i]) == "EX" {
+					ttl = time.Duration(n) * time.Second
+				}
+				i++
+			}
+		}
+		if _, ok := s.get(key); ok && nx {
+			return nil
+		}
+		s.vals[key] = val
+		delete(s.expires, key)
+		if ttl > 0 {
+			s.expires[key] = time.Now().Add(ttl)
+		}
+		return fakeStatus("OK")
+	case "GET":
+		if val, ok := s.get(args[1]); ok {
+			return val
+		}
+		return nil
+	case "EVALSHA":
+		key, token := args[3], args[4]
+		if val, ok := s.get(key); !ok || val != token {
+			return 0
+		}
+		switch args[1] {
+		case sha1String(COMPARE_AND_PEXPIRE):
+			n, _ := strconv.Atoi(args[5])
+			s.expires[key] = time.Now().Add(time.Duration(n) * time.Millisecond)
+		case sha1String(COMPARE_AND_DELETE):
+			delete(s.vals, key)
+			delete(s.expires, key)
+		}
+		return 1
+	}
+	return nil
+}
+
+// TestRedisClient_Elect 测试多个竞选者中只有一个成为领导者, 领导者会续期, 放弃之后其他竞选者可以成为领导者
+func TestRedisClient_Elect(t *testing.T) {
+	store := newFakeLockStore()
+	client, _ := newFakeClient(t, store.handle)
+	ctx := context.Background()
+	const ttl = 150 * time.Millisecond
+
+	var mu sync.Mutex
+	var resigns []func()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			isLeader, resign, err := client.Elect(ctx, "job:leader", ttl)
+			if err != nil {
+				t.Errorf("Elect failed: %v", err)
+				return
+			}
+			if isLeader {
+				mu.Lock()
+				resigns = append(resigns, resign)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(resigns) != 1 {
+		t.Fatalf("Expected exactly one leader, got %d", len(resigns))
+	}
+
+	// 超过 ttl 之后仍然是领导者
+	time.Sleep(2 * ttl)
+	if isLeader, _, _ := client.Elect(ctx, "job:leader", ttl); isLeader {
+		t.Fatalf("Expected leadership to be renewed")
+	}
+
+	resigns[0]()
+	resigns[0]()
+	if _, ok := store.Get("job:leader"); ok {
+		t.Errorf("Expected key to be deleted after resign")
+	}
+	isLeader, resign, err := client.Elect(ctx, "job:leader", ttl)
+	if err != nil || !isLeader {
+		t.Fatalf("Expected leadership to transfer, got %v %v", isLeader, err)
+	}
+	resign()
+}
+
+// TestRedisClient_Campaign 测试 key 被别人持有时失去领导权, ctx 取消时自动放弃
+func TestRedisClient_Campaign(t *testing.T) {
+	store := newFakeLockStore()
+	client, _ := newFakeClient(t, store.handle)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	const ttl = 90 * time.Millisecond
+
+	l, err := client.Campaign(ctx, "leader", ttl)
+	if err != nil || l == nil {
+		t.Fatalf("Campaign failed: %v", err)
+	}
+	store.Set("leader", "someone-else")
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatalf("Expected leadership to be lost")
+	}
+	if l.IsLeader() {
+		t.Errorf("Expected IsLeader to be false")
+	}
+	l.Resign()
+	if val, _ := store.Get("leader"); val != "someone-else" {
+		t.Errorf("Resign must not delete a key held by someone else, got %q", val)
+	}
+
+	store.Del("leader")
+	l, err = client.Campaign(ctx, "leader", ttl)
+	if err != nil || l == nil {
+		t.Fatalf("Campaign failed: %v", err)
+	}
+	cancel()
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatalf("Expected resign after ctx cancel")
+	}
+	waitUntil(t, "key deleted", func() bool {
+		_, ok := store.Get("leader")
+		return !ok
+	})
+}
@@ -0,0 +1,116 @@
+package rdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"time"
+)
+
+// exportScanCount Export 每次 SCAN 的 count
+const exportScanCount = 500
+
+// Export 把匹配 pattern 的 key 通过 DUMP 导出到 w, 返回导出的 key 数量
+// 每个 key 写成一条记录: key 长度(uint32) key 剩余过期毫秒数(int64, 0 表示不过期) 数据长度(uint32) DUMP 数据, 整数都是大端序
+// 导出过程中被删除的 key 会被跳过; 集群模式下会遍历所有主节点
+// 设置了 KeyPrefix 时只导出这个命名空间下的 key, 记录中的 key 不带 KeyPrefix
+func (rdm *RedisClient) Export(ctx context.Context, pattern string, w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	iter := rdm.ScanMatch(ctx, pattern, exportScanCount)
+	for iter.Next() {
+		key := iter.Val()
+		pipe := rdm.Client.Pipeline()
+		dump := pipe.Dump(ctx, rdm.KeyPrefix+key)
+		pttl := pipe.PTTL(ctx, rdm.KeyPrefix+key)
+		if _, err := pipe.Exec(ctx); err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			return n, fmt.Errorf("rdb: export %s: %w", key, err)
+		}
+		ttl := pttl.Val()
+		if ttl == -2 {
+			continue
+		}
+		if ttl < 0 {
+			ttl = 0
+		}
+		if err := writeExportRecord(bw, key, ttl.Milliseconds(), dump.Val()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := iter.Err(); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// Import 读取 Export 导出的数据, 通过 RESTORE 写入, 返回导入的 key 数量
+// 过期时间按照导出时剩余的时间设置; key 已经存在时返回错误, 之前导入的 key 不会回滚
+// key 会加上当前客户端的 KeyPrefix, 所以从一个命名空间导出、用另一个 KeyPrefix 的客户端导入就是把这些 key 迁移到新的命名空间
+func (rdm *RedisClient) Import(ctx context.Context, r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	for {
+		key, ttl, payload, err := readExportRecord(br)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("rdb: import record %d: %w", n, err)
+		}
+		if err := rdm.Client.Restore(ctx, rdm.KeyPrefix+key, time.Duration(ttl)*time.Millisecond, payload).Err(); err != nil {
+			return n, fmt.Errorf("rdb: import %s: %w", key, err)
+		}
+		n++
+	}
+}
+
+func writeExportRecord(w io.Writer, key string, ttl int64, payload string) error {
+	buf := make([]byte, 0, 16+len(key)+len(payload))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(key)))
+	buf = append(buf, key...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(ttl))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readExportRecord 读取一条记录, 在记录边界结束时返回 io.EOF, 记录不完整时返回 io.ErrUnexpectedEOF
+func readExportRecord(r io.Reader) (key string, ttl int64, payload string, err error) {
+	var size [8]byte
+	if _, err = io.ReadFull(r, size[:4]); err != nil {
+		return
+	}
+	keyBuf := make([]byte, binary.BigEndian.Uint32(size[:4]))
+	if _, err = io.ReadFull(r, keyBuf); err != nil {
+		return "", 0, "", noEOF(err)
+	}
+	if _, err = io.ReadFull(r, size[:8]); err != nil {
+		return "", 0, "", noEOF(err)
+	}
+	ttl = int64(binary.BigEndian.Uint64(size[:8]))
+	if _, err = io.ReadFull(r, size[:4]); err != nil {
+		return "", 0, "", noEOF(err)
+	}
+	payloadBuf := make([]byte, binary.BigEndian.Uint32(size[:4]))
+	if _, err = io.ReadFull(r, payloadBuf); err != nil {
+		return "", 0, "", noEOF(err)
+	}
+	return string(keyBuf), ttl, string(payloadBuf), nil
+}
+
+// noEOF 记录中间遇到的 io.EOF 说明数据被截断
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
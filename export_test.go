@@ -0,0 +1,132 @@
+package rdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestRedisClient_ExportImport 测试导出一部分 key 后导入到新的实例, 过期时间保持不变
+func TestRedisClient_ExportImport(t *testing.T) {
+	source := map[string]struct {
+		payload string
+		pttl    int
+	}{
+		"user:1": {"\x00\x05alice\x0b\x00", -1},
+		"user:2": {"\x00\x03bob\x0b\x00", 60000},
+		"user:3": {"", -2}, // 导出过程中被删除
+	}
+	src, _ := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SCAN":
+			return fakeScanPages(args, [][]string{{"user:1", "user:2"}, {"user:3"}})
+		case "DUMP":
+			if v := source[args[1]]; v.payload != "" {
+				return []byte(v.payload)
+			}
+			return nil
+		case "PTTL":
+			return source[args[1]].pttl
+		}
+		return nil
+	})
+
+	var mu sync.Mutex
+	restored := map[string][]string{}
+	dst, _ := newFakeClient(t, func(args []string) any {
+		if args[0] == "RESTORE" {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := restored[args[1]]; ok {
+				return errors.New("BUSYKEY Target key name already exists.")
+			}
+			restored[args[1]] = args[2:]
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	n, err := src.Export(ctx, "user:*", &buf)
+	if err != nil || n != 2 {
+		t.Fatalf("Export failed: %d %v", n, err)
+	}
+	data := buf.Bytes()
+	n, err = dst.Import(ctx, bytes.NewReader(data))
+	if err != nil || n != 2 {
+		t.Fatalf("Import failed: %d %v", n, err)
+	}
+	want := map[string][]string{
+		"user:1": {"0", source["user:1"].payload},
+		"user:2": {"60000", source["user:2"].payload},
+	}
+	if !reflect.DeepEqual(restored, want) {
+		t.Errorf("Expected %q, got %q", want, restored)
+	}
+
+	// 已经存在的 key 返回错误
+	if n, err := dst.Import(ctx, bytes.NewReader(data)); err == nil || n != 0 {
+		t.Errorf("Expected BUSYKEY error, got %d %v", n, err)
+	}
+	// 被截断的数据
+	restored = map[string][]string{}
+	n, err = dst.Import(ctx, bytes.NewReader(data[:len(data)-3]))
+	if !errors.Is(err, io.ErrUnexpectedEOF) || n != 1 {
+		t.Errorf("Expected ErrUnexpectedEOF after 1 key, got %d %v", n, err)
+	}
+	if _, ok := restored["user:1"]; !ok || len(restored) != 1 {
+		t.Errorf("Expected only user:1 restored, got %q", restored)
+	}
+}
+
+// TestRedisClient_ExportImportNamespace 测试从 KeyPrefix 为 t1: 的命名空间导出一部分 key, 用 KeyPrefix 为 t2: 的客户端导入到新的命名空间
+func TestRedisClient_ExportImportNamespace(t *testing.T) {
+	src, srcFake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SCAN":
+			return fakeScanPages(args, [][]string{{"t1:user:1", "t1:user:2"}})
+		case "DUMP":
+			return []byte("payload:" + args[1])
+		case "PTTL":
+			return -1
+		}
+		return nil
+	})
+	src.KeyPrefix = "t1:"
+	var mu sync.Mutex
+	restored := map[string]string{}
+	dst, _ := newFakeClient(t, func(args []string) any {
+		if args[0] == "RESTORE" {
+			mu.Lock()
+			defer mu.Unlock()
+			restored[args[1]] = args[3]
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+	dst.KeyPrefix = "t2:"
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	if n, err := src.Export(ctx, "user:*", &buf); err != nil || n != 2 {
+		t.Fatalf("Export failed: %d %v", n, err)
+	}
+	if got := srcFake.Commands()[0]; !reflect.DeepEqual(got[:4], []string{"SCAN", "0", "match", "t1:user:*"}) {
+		t.Errorf("Expected SCAN to match only the t1: namespace, got %v", got)
+	}
+	if n, err := dst.Import(ctx, &buf); err != nil || n != 2 {
+		t.Fatalf("Import failed: %d %v", n, err)
+	}
+	want := map[string]string{
+		"t2:user:1": "payload:t1:user:1",
+		"t2:user:2": "payload:t1:user:2",
+	}
+	if !reflect.DeepEqual(restored, want) {
+		t.Errorf("Expected %q, got %q", want, restored)
+	}
+}
@@ -0,0 +1,189 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 部分命令需要的最低 redis 版本, 可以配合 AtLeastVersion 使用
+const (
+	VersionHashFieldTTL = "7.4.0" // HEXPIRE/HPEXPIRE/HTTL/HPTTL/HPERSIST
+	VersionHashGetEx    = "8.0.0" // HGETEX/HGETDEL
+)
+
+// 常用模块在 MODULE LIST 中的名字, 可以配合 HasModule 使用
+const (
+	ModuleBloom  = "bf"     // RedisBloom, BF.* 命令
+	ModuleJSON   = "ReJSON" // RedisJSON, JSON.* 命令
+	ModuleSearch = "search" // RediSearch, FT.* 命令
+)
+
+// ErrModuleNotLoaded 服务端没有加载命令需要的模块
+var ErrModuleNotLoaded = errors.New("rdb: module not loaded")
+
+// featureCache 缓存探测到的服务端信息
+type featureCache struct {
+	mu         sync.Mutex
+	version    string
+	modules    map[string]bool // 小写的模块名, 为 nil 时还没有探测过
+	modulesErr error           // MODULE LIST 返回的服务端错误, 不为 nil 时不再重试
+}
+
+// ServerVersion 通过 INFO server 获取 redis 的版本号, 如: 7.4.1, 获取成功后会缓存, 直到 ResetServerVersion
+// INFO 在锁外执行, 不会因为一次慢的请求阻塞其他调用; 还没有缓存时并发的调用可能各自执行一次 INFO
+func (rdm *RedisClient) ServerVersion(ctx context.Context) (string, error) {
+	if rdm.features != nil {
+		rdm.features.mu.Lock()
+		version := rdm.features.version
+		rdm.features.mu.Unlock()
+		if version != "" {
+			return version, nil
+		}
+	}
+	info, err := rdm.Client.Info(ctx, "server").Result()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(info, "\n") {
+		if version, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:"); ok {
+			if rdm.features != nil {
+				rdm.features.mu.Lock()
+				rdm.features.version = version
+				rdm.features.mu.Unlock()
+			}
+			return version, nil
+		}
+	}
+	return "", errors.New("rdb: redis_version not found in INFO server")
+}
+
+// ResetServerVersion 清除缓存的版本号, 下一次 ServerVersion 重新执行 INFO server; 用于 redis 升级或者主从切换之后
+func (rdm *RedisClient) ResetServerVersion() {
+	if rdm.features == nil {
+		return
+	}
+	rdm.features.mu.Lock()
+	defer rdm.features.mu.Unlock()
+	rdm.features.version = ""
+}
+
+// AtLeastVersion 判断 redis 的版本是否不低于 version, 如: client.AtLeastVersion(ctx, VersionHashFieldTTL)
+func (rdm *RedisClient) AtLeastVersion(ctx context.Context, version string) (bool, error) {
+	current, err := rdm.ServerVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	return compareVersion(current, version) >= 0, nil
+}
+
+// compareVersion 按数字比较 x.y.z 格式的版本号
+func compareVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// HasModule 通过 MODULE LIST 判断服务端是否加载了模块 name(不区分大小写), 如: client.HasModule(ctx, ModuleBloom)
+// 模块列表获取成功后会缓存, 直到 ResetModules; MODULE LIST 在锁外执行, 和 ServerVersion 一样
+// 服务端返回错误(如: 托管的 redis 禁用了 MODULE 命令)时同样缓存这个错误, 之后的调用直接返回它, 不会每次都多一次往返; 网络错误不会缓存
+func (rdm *RedisClient) HasModule(ctx context.Context, name string) (bool, error) {
+	if rdm.features != nil {
+		rdm.features.mu.Lock()
+		modules, modulesErr := rdm.features.modules, rdm.features.modulesErr
+		rdm.features.mu.Unlock()
+		if modulesErr != nil {
+			return false, modulesErr
+		}
+		if modules != nil {
+			return modules[strings.ToLower(name)], nil
+		}
+	}
+	list, err := rdm.Client.Do(ctx, "MODULE", "LIST").Slice()
+	if err != nil {
+		var serverErr redis.Error
+		if rdm.features != nil && errors.As(err, &serverErr) {
+			rdm.features.mu.Lock()
+			rdm.features.modulesErr = err
+			rdm.features.mu.Unlock()
+		}
+		return false, err
+	}
+	modules := map[string]bool{}
+	for _, item := range list {
+		// RESP2 是 [name, xx, ver, xx, ...], RESP3 是 map
+		switch module := item.(type) {
+		case []any:
+			for i := 0; i+1 < len(module); i += 2 {
+				if key, _ := module[i].(string); key == "name" {
+					name, _ := module[i+1].(string)
+					modules[strings.ToLower(name)] = true
+				}
+			}
+		case map[any]any:
+			name, _ := module["name"].(string)
+			modules[strings.ToLower(name)] = true
+		}
+	}
+	if rdm.features != nil {
+		rdm.features.mu.Lock()
+		rdm.features.modules = modules
+		rdm.features.mu.Unlock()
+	}
+	return modules[strings.ToLower(name)], nil
+}
+
+// ResetModules 清除缓存的模块列表和 MODULE LIST 的错误, 下一次 HasModule 重新执行 MODULE LIST; 用于加载模块或者主从切换之后
+func (rdm *RedisClient) ResetModules() {
+	if rdm.features == nil {
+		return
+	}
+	rdm.features.mu.Lock()
+	defer rdm.features.mu.Unlock()
+	rdm.features.modules = nil
+	rdm.features.modulesErr = nil
+}
+
+// requireModule 检查模块是否加载, 没有加载时返回 ErrModuleNotLoaded
+// MODULE LIST 执行失败(如: 没有权限)时不做限制, 交给命令本身报错
+func (rdm *RedisClient) requireModule(ctx context.Context, name string) error {
+	ok, err := rdm.HasModule(ctx, name)
+	if err == nil && !ok {
+		return fmt.Errorf("%w: %s", ErrModuleNotLoaded, name)
+	}
+	return nil
+}
+
+// Module 执行任意模块命令, 同样经过模板和结果类型的处理, 如:
+//
+//	client.Module(ctx, "FOO.BAR {{field}} LIMIT {{n}}", "foo:{{id}}", map[string]any{"id": 1, "field": "a", "n": 10}).Int()
+//
+// command 第一个空格之前是命令名, 之后的部分作为 Params 模板; key 是 key 模板, 为空时不使用 key
+// 没有模块也可以用来执行这个库还没有封装的命令
+func (rdm *RedisClient) Module(ctx context.Context, command string, key string, args map[string]any, includeArgs ...any) *CommandBuilder {
+	name, params, _ := strings.Cut(strings.TrimSpace(command), " ")
+	cmdName := Command(name)
+	cmd := RdCmd{
+		Key: key,
+		CMD: map[Command]RdSubCmd{cmdName: {Params: params, NoUseKey: key == ""}},
+	}
+	return rdm.builder(ctx, cmd, cmdName, args, includeArgs...)
+}
@@ -0,0 +1,160 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRedisClient_AtLeastVersion 测试通过 INFO server 探测版本, 且结果会被缓存
+func TestRedisClient_AtLeastVersion(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "INFO" {
+			return "# Server\r\nredis_version:7.4.1\r\nredis_mode:standalone\r\n"
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	version, err := client.ServerVersion(ctx)
+	if err != nil || version != "7.4.1" {
+		t.Fatalf("unexpected version: %q %v", version, err)
+	}
+	for want, ok := range map[string]bool{VersionHashFieldTTL: true, VersionHashGetEx: false, "7.2": true, "7.4.2": false} {
+		got, err := client.AtLeastVersion(ctx, want)
+		if err != nil || got != ok {
+			t.Errorf("AtLeastVersion(%q) = %v, %v, want %v", want, got, err, ok)
+		}
+	}
+	if n := len(fake.Commands()); n != 1 {
+		t.Errorf("expected server version to be cached, got %d INFO calls", n)
+	}
+
+	// 清除缓存之后重新获取
+	client.ResetServerVersion()
+	if _, err := client.ServerVersion(ctx); err != nil {
+		t.Fatalf("ServerVersion failed: %v", err)
+	}
+	if n := len(fake.Commands()); n != 2 {
+		t.Errorf("expected INFO after ResetServerVersion, got %d INFO calls", n)
+	}
+}
+
+// TestRedisClient_ServerVersionNoLock 测试 INFO 在锁外执行, 慢的 INFO 不会阻塞已经缓存之后的调用
+func TestRedisClient_ServerVersionNoLock(t *testing.T) {
+	release := make(chan struct{})
+	client, _ := newFakeClient(t, func(args []string) any {
+		if args[0] == "INFO" {
+			<-release
+			return "# Server\r\nredis_version:7.4.1\r\n"
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.ServerVersion(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond) // 等 INFO 发出去
+	locked := make(chan struct{})
+	go func() {
+		client.ResetServerVersion()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Errorf("features lock held while INFO is in flight")
+	}
+	close(release)
+	<-done
+}
+
+// Test_compareVersion 测试版本号比较
+func Test_compareVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"7.4.0", "7.4.0", 0},
+		{"7.4", "7.4.0", 0},
+		{"7.10.0", "7.4.0", 1},
+		{"6.2.14", "7.0.0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersion(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestRedisClient_Module 测试任意模块命令经过模板构造参数
+func TestRedisClient_Module(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "FOO.BAR" {
+			return 7
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	n, err := client.Module(ctx, "FOO.BAR {{field}}  LIMIT {{n}}", "foo:{{id}}", map[string]any{"id": 1, "field": "score", "n": 10}, "WITHCOUNT").Int().Result()
+	if err != nil || n != 7 {
+		t.Fatalf("Module failed: %d %v", n, err)
+	}
+	if err := client.Module(ctx, "FOO.BAR {{items}}", "", map[string]any{"items": []string{"a", "b"}}).Err(); err != nil {
+		t.Fatalf("Module without key failed: %v", err)
+	}
+	want := [][]string{
+		{"FOO.BAR", "foo:1", "score", "LIMIT", "10", "WITHCOUNT"},
+		{"FOO.BAR", "a", "b"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_HasModuleCache 测试 MODULE LIST 的结果和服务端错误都会被缓存, ResetModules 之后重新获取
+func TestRedisClient_HasModuleCache(t *testing.T) {
+	var mu sync.Mutex
+	disabled := true
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] != "MODULE" {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if disabled {
+			return errors.New("ERR unknown command 'MODULE'")
+		}
+		return []any{[]any{"name", "bf", "ver", 20612}}
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.HasModule(ctx, ModuleBloom); err == nil {
+			t.Fatalf("Expected the MODULE LIST error")
+		}
+	}
+	if n := len(fake.Commands()); n != 1 {
+		t.Errorf("Expected the MODULE LIST error to be cached, got %d calls", n)
+	}
+
+	mu.Lock()
+	disabled = false
+	mu.Unlock()
+	client.ResetModules()
+	for i := 0; i < 2; i++ {
+		if ok, err := client.HasModule(ctx, ModuleBloom); err != nil || !ok {
+			t.Fatalf("Expected bloom module after ResetModules: %v %v", ok, err)
+		}
+	}
+	if n := len(fake.Commands()); n != 2 {
+		t.Errorf("Expected one MODULE LIST after ResetModules, got %d calls", n)
+	}
+}
@@ -0,0 +1,130 @@
+package rdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keySpec 多 key 命令中 key 所在的位置, 下标从命令名之后的第一个参数开始算 1
+// first 到 last 之间间隔 step 的参数是 key, step 为 0 时没有; last 为负数时从末尾倒数, -1 是最后一个参数
+// numKeys 大于 0 时这个位置上是 numkeys, 紧跟在它后面的 numkeys 个参数也是 key
+type keySpec struct {
+	first, last, step int
+	numKeys           int
+}
+
+// multiKeyCommands KeyPrefix 需要给每个 key 都加前缀的命令, 其他命令只给 RdCmd.Key 构造出的 key 加前缀
+var multiKeyCommands = map[Command]keySpec{
+	DEL:         {first: 1, last: -1, step: 1},
+	EXISTS:      {first: 1, last: -1, step: 1},
+	UNLINK:      {first: 1, last: -1, step: 1},
+	TOUCH:       {first: 1, last: -1, step: 1},
+	WATCH:       {first: 1, last: -1, step: 1},
+	MGET:        {first: 1, last: -1, step: 1},
+	MSET:        {first: 1, last: -1, step: 2},
+	MSETNX:      {first: 1, last: -1, step: 2},
+	RENAME:      {first: 1, last: 2, step: 1},
+	RENAMENX:    {first: 1, last: 2, step: 1},
+	COPY:        {first: 1, last: 2, step: 1},
+	RPOPLPUSH:   {first: 1, last: 2, step: 1},
+	BRPOPLPUSH:  {first: 1, last: 2, step: 1},
+	LMOVE:       {first: 1, last: 2, step: 1},
+	BLMOVE:      {first: 1, last: 2, step: 1},
+	SMOVE:       {first: 1, last: 2, step: 1},
+	BLPOP:       {first: 1, last: -2, step: 1},
+	BRPOP:       {first: 1, last: -2, step: 1},
+	BZPOPMIN:    {first: 1, last: -2, step: 1},
+	BZPOPMAX:    {first: 1, last: -2, step: 1},
+	SDIFF:       {first: 1, last: -1, step: 1},
+	SINTER:      {first: 1, last: -1, step: 1},
+	SUNION:      {first: 1, last: -1, step: 1},
+	SDIFFSTORE:  {first: 1, last: -1, step: 1},
+	SINTERSTORE: {first: 1, last: -1, step: 1},
+	SUNIONSTORE: {first: 1, last: -1, step: 1},
+	PFCOUNT:     {first: 1, last: -1, step: 1},
+	PFMERGE:     {first: 1, last: -1, step: 1},
+	BITOP:       {first: 2, last: -1, step: 1},
+	LMPOP:       {numKeys: 1},
+	ZMPOP:       {numKeys: 1},
+	SINTERCARD:  {numKeys: 1},
+	ZINTERCARD:  {numKeys: 1},
+	ZINTER:      {numKeys: 1},
+	ZUNION:      {numKeys: 1},
+	ZINTERSTORE: {first: 1, last: 1, step: 1, numKeys: 2},
+	ZUNIONSTORE: {first: 1, last: 1, step: 1, numKeys: 2},
+}
+
+// positions 返回 cmdList 中 key 的下标, cmdList[0] 是命令名
+func (s keySpec) positions(cmdList []any) []int {
+	var pos []int
+	if s.step > 0 {
+		last := s.last
+		if last < 0 {
+			last += len(cmdList)
+		}
+		for i := s.first; i <= last && i < len(cmdList); i += s.step {
+			pos = append(pos, i)
+		}
+	}
+	if s.numKeys > 0 && s.numKeys < len(cmdList) {
+		n, _ := strconv.Atoi(fmt.Sprint(cmdList[s.numKeys]))
+		for i := s.numKeys + 1; i <= s.numKeys+n && i < len(cmdList); i++ {
+			pos = append(pos, i)
+		}
+	}
+	return pos
+}
+
+// applyKeyPrefix 给命令中的 key 加上 KeyPrefix, 在 CacheVersion 的前缀之后执行, 所以 KeyPrefix 在最外层
+// multiKeyCommands 中的命令给每个 key 都加前缀, 其他命令替换 cmdList 中第一个和 key 相同的参数; 没有 key 的命令不受影响
+// return 加了前缀的 cmdList 和 key, Exp 的 EXPIRE 使用返回的 key
+func applyKeyPrefix(prefix string, cmdName Command, cmdList []any, key string) ([]any, string) {
+	if prefix == "" {
+		return cmdList, key
+	}
+	if spec, ok := multiKeyCommands[cmdName]; ok {
+		for _, i := range spec.positions(cmdList) {
+			cmdList[i] = prefix + fmt.Sprint(cmdList[i])
+		}
+	} else if key != "" {
+		for i := 1; i < len(cmdList); i++ {
+			if arg, ok := cmdList[i].(string); ok && arg == key {
+				cmdList[i] = prefix + key
+				break
+			}
+		}
+	}
+	if key != "" {
+		key = prefix + key
+	}
+	return cmdList, key
+}
+
+// prefixPattern 给 SCAN 的 MATCH pattern 加上 prefix, prefix 中的 glob 特殊字符会被转义, 只匹配 prefix 本身
+func prefixPattern(prefix, pattern string) string {
+	if prefix == "" {
+		return pattern
+	}
+	var b strings.Builder
+	for _, r := range prefix {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + pattern
+}
+
+// prefixKeys 给 keys 都加上 prefix, 用于直接调用 go-redis 的辅助方法和 lua 脚本的 KEYS, 返回新的切片, 不修改 keys
+func prefixKeys(prefix string, keys []string) []string {
+	if prefix == "" {
+		return keys
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = prefix + key
+	}
+	return prefixed
+}
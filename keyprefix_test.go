@@ -0,0 +1,275 @@
+package rdb
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestApplyKeyPrefix 测试多 key 命令的每个 key 都加前缀, 其他命令只给 RdCmd.Key 构造出的 key 加前缀
+func TestApplyKeyPrefix(t *testing.T) {
+	tests := []struct {
+		cmdName Command
+		cmdList []any
+		key     string
+		want    []any
+		wantKey string
+	}{
+		{GET, []any{"GET", "user:1"}, "user:1", []any{"GET", "t1:user:1"}, "t1:user:1"},
+		{OBJECT, []any{"OBJECT", "ENCODING", "user:1"}, "user:1", []any{"OBJECT", "ENCODING", "t1:user:1"}, "t1:user:1"},
+		{MGET, []any{"MGET", "a", "b"}, "", []any{"MGET", "t1:a", "t1:b"}, ""},
+		{MSET, []any{"MSET", "a", "1", "b", "2"}, "", []any{"MSET", "t1:a", "1", "t1:b", "2"}, ""},
+		{SDIFF, []any{"SDIFF", "set:1", "other"}, "set:1", []any{"SDIFF", "t1:set:1", "t1:other"}, "t1:set:1"},
+		{BLPOP, []any{"BLPOP", "q1", "q2", "1.5"}, "", []any{"BLPOP", "t1:q1", "t1:q2", "1.5"}, ""},
+		{LMPOP, []any{"LMPOP", 2, "q1", "q2", "LEFT", "COUNT", 1}, "", []any{"LMPOP", 2, "t1:q1", "t1:q2", "LEFT", "COUNT", 1}, ""},
+		{ZUNIONSTORE, []any{"ZUNIONSTORE", "dst", "2", "a", "b", "WEIGHTS", "1", "2"}, "dst", []any{"ZUNIONSTORE", "t1:dst", "2", "t1:a", "t1:b", "WEIGHTS", "1", "2"}, "t1:dst"},
+		{BITOP, []any{"BITOP", "AND", "dst", "a"}, "", []any{"BITOP", "AND", "t1:dst", "t1:a"}, ""},
+		{PING, []any{"PING"}, "", []any{"PING"}, ""},
+	}
+	for _, tt := range tests {
+		got, key := applyKeyPrefix("t1:", tt.cmdName, tt.cmdList, tt.key)
+		if !reflect.DeepEqual(got, tt.want) || key != tt.wantKey {
+			t.Errorf("%s: expected %v %q, got %v %q", tt.cmdName, tt.want, tt.wantKey, got, key)
+		}
+	}
+}
+
+// TestRedisClient_KeyPrefix 测试 KeyPrefix 加在 CacheVersion 之前, 对 EXPIRE、pipeline 和多 key 命令生效, 不影响没有 key 的命令
+func TestRedisClient_KeyPrefix(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SET":
+			return fakeStatus("OK")
+		case "DEL", "EXPIRE":
+			return 1
+		}
+		return fakeStatus("PONG")
+	})
+	client.KeyPrefix = "t1:"
+	client.SetCacheVersion("v1")
+	ctx := context.Background()
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET:  {Params: "{{value}}", Exp: func() time.Duration { return time.Minute }},
+			DEL:  {Params: "{{others}}"},
+			PING: {NoUseKey: true},
+		},
+	}
+
+	client.Set(ctx, cmd, map[string]any{"id": 1, "value": "a"}).Status()
+	pip := client.PipeLine()
+	pip.Del(ctx, cmd, map[string]any{"id": 2, "others": []string{"x", "y"}}).Int()
+	pip.Handler(ctx, cmd, PING, nil).Status()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+	if args := client.BuildCmd(ctx, cmd, SET, map[string]any{"id": 3, "value": "b"}).Args(); !reflect.DeepEqual(args, []any{"SET", "t1:v1:user:3", "b"}) {
+		t.Errorf("BuildCmd: unexpected args %v", args)
+	}
+
+	want := [][]string{
+		{"SET", "t1:v1:user:1", "a"},
+		{"EXPIRE", "t1:v1:user:1", "60"},
+		{"DEL", "t1:v1:user:2", "t1:x", "t1:y"},
+		{"PING"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_KeyPrefixHelpers 测试不经过 RdCmd 的辅助方法同样给 key 加上 KeyPrefix, 锁、选主和队列按租户隔离
+func TestRedisClient_KeyPrefixHelpers(t *testing.T) {
+	store := newFakeLockStore()
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "HINCRBY":
+			return 1
+		case "HEXPIRE":
+			return []any{1}
+		case "BLMOVE":
+			return "job-1"
+		case "LREM", "SINTERCARD", "SCARD", "MEMORY":
+			return 1
+		case "TYPE":
+			return fakeStatus("string")
+		case "PTTL":
+			return -1
+		case "OBJECT", "GET":
+			return "v"
+		case "SCAN":
+			return []any{"0", []any{"t1:user:1", "t1:user:2"}}
+		case "EVALSHA":
+			if args[1] != sha1String(COMPARE_AND_PEXPIRE) && args[1] != sha1String(COMPARE_AND_DELETE) {
+				return []any{0, 1}
+			}
+		}
+		return store.handle(args)
+	})
+	client.KeyPrefix = "t1:"
+	ctx := context.Background()
+	evalKeys := func(cmds [][]string) [][]string {
+		var keys [][]string
+		for _, cmd := range cmds {
+			if cmd[0] == "EVALSHA" {
+				n, _ := strconv.Atoi(cmd[2])
+				keys = append(keys, cmd[3:3+n])
+			}
+		}
+		return keys
+	}
+
+	t.Run("ExecScript", func(t *testing.T) {
+		fake.Reset()
+		if err := client.Swap(ctx, "a", "b"); err != nil {
+			t.Fatalf("Swap failed: %v", err)
+		}
+		pip := client.PipeLine()
+		pip.ExecScript(ctx, decrAndMaybeDeleteScript, map[string]string{"key": "ref"}, nil)
+		if _, err := pip.Exec(ctx); err != nil {
+			t.Fatalf("pipeline Exec failed: %v", err)
+		}
+		if got, want := evalKeys(fake.Commands()), [][]string{{"t1:a", "t1:b"}, {"t1:ref"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected KEYS %v, got %v", want, got)
+		}
+	})
+
+	t.Run("TryLock", func(t *testing.T) {
+		fake.Reset()
+		ok, lock, err := client.TryLock(ctx, "lock:order:1", time.Second)
+		if err != nil || !ok {
+			t.Fatalf("TryLock failed: %v %v", ok, err)
+		}
+		if _, held := store.Get("t1:lock:order:1"); !held || lock.Key() != "lock:order:1" {
+			t.Errorf("Expected lock stored under t1:lock:order:1, commands %v", fake.Commands())
+		}
+		if err := lock.Refresh(ctx, time.Second); err != nil {
+			t.Errorf("Refresh failed: %v", err)
+		}
+		if err := lock.Unlock(ctx); err != nil {
+			t.Errorf("Unlock failed: %v", err)
+		}
+		if got, want := evalKeys(fake.Commands()), [][]string{{"t1:lock:order:1"}, {"t1:lock:order:1"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected KEYS %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Campaign", func(t *testing.T) {
+		fake.Reset()
+		l, err := client.Campaign(ctx, "job:leader", time.Second)
+		if err != nil || l == nil {
+			t.Fatalf("Campaign failed: %v %v", l, err)
+		}
+		if got := fake.Commands()[0]; got[0] != "SET" || got[1] != "t1:job:leader" {
+			t.Errorf("Unexpected SET command: %v", got)
+		}
+		l.Resign()
+		if _, held := store.Get("t1:job:leader"); held {
+			t.Errorf("Expected t1:job:leader to be deleted after Resign")
+		}
+	})
+
+	t.Run("ReliablePop", func(t *testing.T) {
+		fake.Reset()
+		_, ack, err := client.ReliablePop(ctx, "jobs", "jobs:processing", time.Second)
+		if err != nil {
+			t.Fatalf("ReliablePop failed: %v", err)
+		}
+		if err := ack(ctx); err != nil {
+			t.Fatalf("ack failed: %v", err)
+		}
+		want := [][]string{
+			{"BLMOVE", "t1:jobs", "t1:jobs:processing", "RIGHT", "LEFT", "1"},
+			{"LREM", "t1:jobs:processing", "1", "job-1"},
+		}
+		if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("HIncrByWithFieldTTL", func(t *testing.T) {
+		fake.Reset()
+		if _, err := client.HIncrByWithFieldTTL(ctx, RdCmd{Key: "hash:{{name}}"}, map[string]any{"name": "counter"}, "clicks", 1, time.Minute); err != nil {
+			t.Fatalf("HIncrByWithFieldTTL failed: %v", err)
+		}
+		want := [][]string{
+			{"HINCRBY", "t1:hash:counter", "clicks", "1"},
+			{"HEXPIRE", "t1:hash:counter", "60", "FIELDS", "1", "clicks"},
+		}
+		if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Overlap", func(t *testing.T) {
+		fake.Reset()
+		if _, err := client.Overlap(ctx, "a", "b"); err != nil {
+			t.Fatalf("Overlap failed: %v", err)
+		}
+		want := [][]string{
+			{"SINTERCARD", "2", "t1:a", "t1:b", "limit", "0"},
+			{"SCARD", "t1:a"},
+			{"SCARD", "t1:b"},
+		}
+		if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("Inspect", func(t *testing.T) {
+		fake.Reset()
+		if _, err := client.Inspect(ctx, "k"); err != nil {
+			t.Fatalf("Inspect failed: %v", err)
+		}
+		for _, cmd := range fake.Commands() {
+			if cmd[len(cmd)-1] != "t1:k" {
+				t.Errorf("Expected key t1:k, got %v", cmd)
+			}
+		}
+	})
+
+	t.Run("LoadInto", func(t *testing.T) {
+		fake.Reset()
+		var dest struct {
+			Name string `rdb:"user:{{id}}:name"`
+		}
+		if err := client.LoadInto(ctx, &dest, map[string]any{"id": 1}); err != nil {
+			t.Fatalf("LoadInto failed: %v", err)
+		}
+		if got, want := fake.Commands(), [][]string{{"GET", "t1:user:1:name"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("EvalCached", func(t *testing.T) {
+		fake.Reset()
+		client.EvalCached(ctx, "deadbeef", []string{"a", "b"})
+		if got, want := evalKeys(fake.Commands()), [][]string{{"t1:a", "t1:b"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected KEYS %v, got %v", want, got)
+		}
+	})
+
+	t.Run("ScanMatch", func(t *testing.T) {
+		fake.Reset()
+		var keys []string
+		iter := client.ScanMatch(ctx, "user:*", 10)
+		for iter.Next() {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			t.Fatalf("ScanMatch failed: %v", err)
+		}
+		if want := []string{"user:1", "user:2"}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("Expected %v, got %v", want, keys)
+		}
+		if got := fake.Commands()[0]; got[3] != "t1:user:*" {
+			t.Errorf("Expected MATCH t1:user:*, got %v", got)
+		}
+		if got := prefixPattern("a*[b]:", "x*"); got != `a\*\[b\]:x*` {
+			t.Errorf("Expected glob characters in the prefix to be escaped, got %q", got)
+		}
+	})
+}
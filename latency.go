@@ -0,0 +1,67 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"time"
+)
+
+// LatencyStats Benchmark 统计的命令往返延迟
+type LatencyStats struct {
+	Count int
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P99   time.Duration
+}
+
+func (s LatencyStats) String() string {
+	return fmt.Sprintf("n=%d min=%s max=%s mean=%s p50=%s p99=%s", s.Count, s.Min, s.Max, s.Mean, s.P50, s.P99)
+}
+
+// Benchmark 顺序执行 n 次同一个命令, 统计每次执行的往返延迟, 用于压测和排查问题
+// 和普通调用一样经过模板、ArgTransform、Exp 等处理, 注意写命令会真正写入 redis
+// 命令执行失败时停止并返回错误, 统计中只包含失败之前的执行
+func (rdm *RedisClient) Benchmark(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, n int) (LatencyStats, error) {
+	if n <= 0 {
+		return LatencyStats{}, fmt.Errorf("rdb: benchmark needs n > 0, got %d", n)
+	}
+	latencies := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		err := ExecuteCmd[*redis.Cmd](rdm, ctx, cmd, cmdName, args).Err()
+		if err != nil {
+			return latencyStats(latencies), fmt.Errorf("rdb: benchmark %s run %d: %w", cmdName, i, err)
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	return latencyStats(latencies), nil
+}
+
+// latencyStats 计算延迟的统计值, 百分位使用 nearest-rank
+func latencyStats(latencies []time.Duration) LatencyStats {
+	if len(latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p int) time.Duration {
+		rank := (p*len(sorted) + 99) / 100
+		return sorted[max(rank, 1)-1]
+	}
+	return LatencyStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(50),
+		P99:   percentile(99),
+	}
+}
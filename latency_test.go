@@ -0,0 +1,72 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestRedisClient_Benchmark 测试延迟统计在有固定延迟的假服务上得到合理的结果
+func TestRedisClient_Benchmark(t *testing.T) {
+	const delay = 2 * time.Millisecond
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "GET":
+			time.Sleep(delay)
+			return "v"
+		case "HGET":
+			return errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "bench:{{id}}",
+		CMD: map[Command]RdSubCmd{GET: {}, HGET: {Params: "f"}},
+	}
+	ctx := context.Background()
+
+	stats, err := client.Benchmark(ctx, cmd, GET, map[string]any{"id": 1}, 20)
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if stats.Count != 20 || len(fake.Commands()) != 20 {
+		t.Errorf("Expected 20 runs, got %d (%d commands)", stats.Count, len(fake.Commands()))
+	}
+	if stats.Min < delay || stats.Min > stats.P50 || stats.P50 > stats.P99 || stats.P99 > stats.Max {
+		t.Errorf("Unexpected stats: %s", stats)
+	}
+	if stats.Mean < stats.Min || stats.Mean > stats.Max {
+		t.Errorf("Mean out of range: %s", stats)
+	}
+
+	if _, err := client.Benchmark(ctx, cmd, HGET, map[string]any{"id": 1}, 5); err == nil {
+		t.Errorf("Expected error from failing command")
+	}
+	if _, err := client.Benchmark(ctx, cmd, GET, nil, 0); err == nil {
+		t.Errorf("Expected error for n <= 0")
+	}
+}
+
+// TestLatencyStats 测试百分位的计算
+func TestLatencyStats(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(100-i) * time.Millisecond
+	}
+	want := LatencyStats{
+		Count: 100,
+		Min:   time.Millisecond,
+		Max:   100 * time.Millisecond,
+		Mean:  50500 * time.Microsecond,
+		P50:   50 * time.Millisecond,
+		P99:   99 * time.Millisecond,
+	}
+	if got := latencyStats(latencies); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if got := latencyStats(nil); got != (LatencyStats{}) {
+		t.Errorf("Expected zero stats, got %s", got)
+	}
+}
@@ -0,0 +1,110 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrLockNotHeld 释放或者续期锁时锁已经过期或者被别人持有
+var ErrLockNotHeld = errors.New("rdb: lock not held")
+
+// lockCmd TryLock 使用的 SET key token PX ttl NX
+// key 通过 Params 传入, 不加 CacheVersion 的前缀; NoUseKey 的命令不会自动加 KeyPrefix, 由 TryLock 加上, 和释放锁的脚本使用同一个 key
+var lockCmd = RdCmd{
+	CMD: map[Command]RdSubCmd{
+		SET: {Params: "{{key}} {{token}} PX {{ttl}} NX", NoUseKey: true, StrictArgs: true, ReturnNilError: true},
+	},
+}
+
+// Lock TryLock 获取到的分布式锁, 只能由持有者释放
+type Lock struct {
+	client *RedisClient
+	key    string
+	token  string
+	ttl    time.Duration
+	mu     sync.Mutex
+	stop   func() // KeepAlive 启动的续期
+	lost   chan struct{}
+}
+
+// TryLock 使用 SET key token PX ttl NX 尝试获取锁, 锁已经被别人持有时返回 false, 不会等待
+// 获取成功之后需要调用 Unlock 释放; 执行时间可能超过 ttl 时使用 KeepAlive 自动续期
+// ttl 至少是 1 毫秒, 否则直接返回错误, 不会发送命令; 设置了 KeyPrefix 时实际的 key 是 KeyPrefix + key, 不同租户的锁互不影响; Lock.Key 返回不带前缀的 key
+//
+//	ok, lock, err := client.TryLock(ctx, "lock:order:1", 10*time.Second)
+//	if err != nil || !ok {
+//		return
+//	}
+//	defer lock.Unlock(ctx)
+func (rdm *RedisClient) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, *Lock, error) {
+	if ttl < time.Millisecond {
+		// PX 按毫秒发送, 不足 1 毫秒时会变成 PX 0, redis 返回 invalid expire time
+		return false, nil, fmt.Errorf("rdb: lock ttl must be at least 1ms, got %s", ttl)
+	}
+	token := newToken()
+	err := ExecuteCmd[*redis.StatusCmd](rdm, ctx, lockCmd, SET, map[string]any{"key": rdm.KeyPrefix + key, "token": token, "ttl": ttl.Milliseconds()}).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, &Lock{client: rdm, key: key, token: token, ttl: ttl}, nil
+}
+
+// Key 返回锁的 key
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Refresh 锁仍然属于自己时把过期时间重新设置为 ttl, 锁已经过期或者被别人持有时返回 ErrLockNotHeld
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	ok, err := l.client.compareAndPExpire(ctx, l.key, l.token, ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// KeepAlive 启动看门狗, 每 ttl/3 把锁续期到 TryLock 时的 ttl, 直到 Unlock 或者 ctx 取消
+// 返回的 channel 在锁丢失(续期时发现锁已经过期或者被别人持有)时关闭, 持有者应该停止受锁保护的操作; 重复调用返回同一个 channel
+func (l *Lock) KeepAlive(ctx context.Context) <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop == nil {
+		lost := make(chan struct{})
+		l.lost = lost
+		l.stop = l.client.keepAlive(ctx, l.key, l.token, l.ttl, func() {
+			slog.Warn("rdb: lock lost", "key", l.key)
+			close(lost)
+		})
+	}
+	return l.lost
+}
+
+// Unlock 释放锁, 通过脚本比较 token, 只有锁仍然属于自己时才删除
+// 锁已经过期(可能已经被别人获取)时返回 ErrLockNotHeld, 不会删除别人的锁
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	if l.stop != nil {
+		l.stop()
+		l.stop = func() {}
+	}
+	l.mu.Unlock()
+	ok, err := l.client.compareAndDelete(ctx, l.key, l.token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
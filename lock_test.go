@@ -0,0 +1,107 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRedisClient_TryLock 测试锁的互斥、只能由持有者释放以及锁过期之后释放的处理
+func TestRedisClient_TryLock(t *testing.T) {
+	store := newFakeLockStore()
+	client, fake := newFakeClient(t, store.handle)
+	ctx := context.Background()
+
+	ok, lock, err := client.TryLock(ctx, "lock:order:1", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("TryLock failed: %v %v", ok, err)
+	}
+	if got := fake.Commands()[0]; len(got) != 6 || got[0] != "SET" || got[1] != "lock:order:1" || got[3] != "PX" || got[4] != "1000" || got[5] != "NX" {
+		t.Errorf("Unexpected SET command: %v", got)
+	}
+	if ok, other, err := client.TryLock(ctx, "lock:order:1", time.Second); err != nil || ok || other != nil {
+		t.Errorf("Expected second TryLock to fail, got %v %v", ok, err)
+	}
+	if err := lock.Refresh(ctx, 2*time.Second); err != nil {
+		t.Errorf("Refresh failed: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := lock.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld for second Unlock, got %v", err)
+	}
+
+	// 锁过期之后被别人获取, 原来的持有者不能释放别人的锁
+	ok, lock, _ = client.TryLock(ctx, "lock:order:2", 50*time.Millisecond)
+	if !ok {
+		t.Fatalf("TryLock failed")
+	}
+	time.Sleep(80 * time.Millisecond)
+	ok, other, _ := client.TryLock(ctx, "lock:order:2", time.Second)
+	if !ok {
+		t.Fatalf("Expected lock to be acquirable after expiry")
+	}
+	if err := lock.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld after expiry, got %v", err)
+	}
+	if err := lock.Refresh(ctx, time.Second); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld for Refresh after expiry, got %v", err)
+	}
+	if err := other.Unlock(ctx); err != nil {
+		t.Errorf("Unlock by new holder failed: %v", err)
+	}
+}
+
+// TestRedisClient_TryLockTTL 测试 ttl 不足 1 毫秒时直接返回错误, 不发送 PX 0
+func TestRedisClient_TryLockTTL(t *testing.T) {
+	client, fake := newFakeClient(t, newFakeLockStore().handle)
+	ctx := context.Background()
+
+	for _, ttl := range []time.Duration{0, -time.Second, 500 * time.Microsecond} {
+		if ok, lock, err := client.TryLock(ctx, "lock:order:1", ttl); err == nil || ok || lock != nil {
+			t.Errorf("ttl %s: expected error, got %v %v", ttl, ok, err)
+		}
+	}
+	if got := fake.Commands(); len(got) != 0 {
+		t.Errorf("Expected no commands, got %v", got)
+	}
+	if ok, _, err := client.TryLock(ctx, "lock:order:1", time.Millisecond); err != nil || !ok {
+		t.Errorf("Expected 1ms ttl to be accepted, got %v %v", ok, err)
+	}
+}
+
+// TestLock_KeepAlive 测试看门狗在执行时间超过 ttl 时续期, 锁被别人持有时通知持有者
+func TestLock_KeepAlive(t *testing.T) {
+	store := newFakeLockStore()
+	client, _ := newFakeClient(t, store.handle)
+	ctx := context.Background()
+	const ttl = 90 * time.Millisecond
+
+	_, lock, err := client.TryLock(ctx, "lock:job", ttl)
+	if err != nil || lock == nil {
+		t.Fatalf("TryLock failed: %v", err)
+	}
+	lost := lock.KeepAlive(ctx)
+	if lock.KeepAlive(ctx) != lost {
+		t.Errorf("Expected KeepAlive to return the same channel")
+	}
+	time.Sleep(3 * ttl)
+	if ok, _, _ := client.TryLock(ctx, "lock:job", ttl); ok {
+		t.Fatalf("Expected lock to be kept alive")
+	}
+
+	store.Set("lock:job", "someone-else")
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected lost notification")
+	}
+	if err := lock.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld, got %v", err)
+	}
+	if val, _ := store.Get("lock:job"); val != "someone-else" {
+		t.Errorf("Unlock must not delete another holder's lock, got %q", val)
+	}
+}
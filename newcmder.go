@@ -0,0 +1,187 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+)
+
+// newCmder 根据泛型类型 T 创建对应的 redis.Cmder, ExecuteCmd 和 executeCmdInPipeline 共用
+// process 只用于 *redis.ScanCmd 的 Iterator 获取下一页; go-redis 没有公开构造函数的类型(如 *redis.JSONCmd、*redis.FTSearchCmd)
+// 创建 *redis.Cmd, 调用方的类型断言会失败, 需要使用 Result() 自己解析
+// XINFO STREAM/GROUPS/CONSUMERS 和 LCS 的 cmder 由 go-redis 根据解析出的参数重新生成命令参数, ArgTransform 对它们只有改写 key 的部分生效
+func newCmder[T redis.Cmder](ctx context.Context, cmdName Command, cmdList []any, process func(ctx context.Context, cmd redis.Cmder) error) redis.Cmder {
+	var zero T
+	var cmder redis.Cmder
+	switch any(zero).(type) {
+	case *redis.StringCmd:
+		cmder = redis.NewStringCmd(ctx, cmdList...)
+	case *redis.StatusCmd:
+		cmder = redis.NewStatusCmd(ctx, cmdList...)
+	case *redis.IntCmd:
+		cmder = redis.NewIntCmd(ctx, cmdList...)
+	case *redis.SliceCmd:
+		cmder = redis.NewSliceCmd(ctx, cmdList...)
+	case *redis.FloatCmd:
+		cmder = redis.NewFloatCmd(ctx, cmdList...)
+	case *redis.BoolCmd:
+		cmder = redis.NewBoolCmd(ctx, cmdList...)
+	case *redis.MapStringIntCmd:
+		cmder = redis.NewMapStringIntCmd(ctx, cmdList...)
+	case *redis.MapStringStringCmd:
+		cmder = redis.NewMapStringStringCmd(ctx, cmdList...)
+	case *redis.StringSliceCmd:
+		cmder = redis.NewStringSliceCmd(ctx, cmdList...)
+	case *redis.IntSliceCmd:
+		cmder = redis.NewIntSliceCmd(ctx, cmdList...)
+	case *redis.FloatSliceCmd:
+		cmder = redis.NewFloatSliceCmd(ctx, cmdList...)
+	case *redis.BoolSliceCmd:
+		cmder = redis.NewBoolSliceCmd(ctx, cmdList...)
+	case *redis.KeyValueSliceCmd:
+		cmder = redis.NewKeyValueSliceCmd(ctx, cmdList...)
+	case *redis.KeyValuesCmd:
+		cmder = redis.NewKeyValuesCmd(ctx, cmdList...)
+	case *redis.MapStringInterfaceCmd:
+		cmder = redis.NewMapStringInterfaceCmd(ctx, cmdList...)
+	case *redis.MapStringStringSliceCmd:
+		cmder = redis.NewMapStringStringSliceCmd(ctx, cmdList...)
+	case *redis.MapStringInterfaceSliceCmd:
+		cmder = redis.NewMapStringInterfaceSliceCmd(ctx, cmdList...)
+	case *redis.MapStringSliceInterfaceCmd:
+		cmder = redis.NewMapStringSliceInterfaceCmd(ctx, cmdList...)
+	case *redis.MapMapStringInterfaceCmd:
+		cmder = redis.NewMapMapStringInterfaceCmd(ctx, cmdList...)
+	case *redis.ZSliceCmd:
+		cmder = redis.NewZSliceCmd(ctx, cmdList...)
+	case *redis.ZSliceWithKeyCmd:
+		cmder = redis.NewZSliceWithKeyCmd(ctx, cmdList...)
+	case *redis.ZWithKeyCmd:
+		cmder = redis.NewZWithKeyCmd(ctx, cmdList...)
+	case *redis.DurationCmd:
+		cmder = redis.NewDurationCmd(ctx, durationPrecision(cmdName), cmdList...)
+	case *redis.GeoPosCmd:
+		cmder = redis.NewGeoPosCmd(ctx, cmdList...)
+	case *redis.GeoSearchLocationCmd:
+		cmder = redis.NewGeoSearchLocationCmd(ctx, geoSearchLocationQuery(cmdList), cmdList...)
+	case *redis.GeoLocationCmd:
+		cmder = newGeoLocationCmd(ctx, cmdList)
+	case *redis.TimeCmd:
+		cmder = redis.NewTimeCmd(ctx, cmdList...)
+	case *redis.ScanCmd:
+		// Iterator 根据小写的命令名判断游标的位置, SCAN 在第 1 个参数, HSCAN/SSCAN/ZSCAN 在第 2 个参数
+		if len(cmdList) > 0 {
+			cmdList[0] = strings.ToLower(fmt.Sprint(cmdList[0]))
+		}
+		cmder = redis.NewScanCmd(ctx, process, cmdList...)
+	case *redis.IntPointerSliceCmd:
+		cmder = redis.NewIntPointerSliceCmd(ctx, cmdList...)
+	case *redis.StringStructMapCmd:
+		cmder = redis.NewStringStructMapCmd(ctx, cmdList...)
+	case *redis.RankWithScoreCmd:
+		cmder = redis.NewRankWithScoreCmd(ctx, cmdList...)
+	case *redis.LCSCmd:
+		cmder = newLCSCmd(ctx, cmdList)
+	case *redis.XMessageSliceCmd:
+		cmder = redis.NewXMessageSliceCmd(ctx, cmdList...)
+	case *redis.XStreamSliceCmd:
+		cmder = redis.NewXStreamSliceCmd(ctx, cmdList...)
+	case *redis.XPendingCmd:
+		cmder = redis.NewXPendingCmd(ctx, cmdList...)
+	case *redis.XPendingExtCmd:
+		cmder = redis.NewXPendingExtCmd(ctx, cmdList...)
+	case *redis.XAutoClaimCmd:
+		cmder = redis.NewXAutoClaimCmd(ctx, cmdList...)
+	case *redis.XAutoClaimJustIDCmd:
+		cmder = redis.NewXAutoClaimJustIDCmd(ctx, cmdList...)
+	case *redis.XInfoStreamCmd:
+		cmder = redis.NewXInfoStreamCmd(ctx, argAt(cmdList, 2))
+	case *redis.XInfoStreamFullCmd:
+		cmder = redis.NewXInfoStreamFullCmd(ctx, cmdList...)
+	case *redis.XInfoGroupsCmd:
+		cmder = redis.NewXInfoGroupsCmd(ctx, argAt(cmdList, 2))
+	case *redis.XInfoConsumersCmd:
+		cmder = redis.NewXInfoConsumersCmd(ctx, argAt(cmdList, 2), argAt(cmdList, 3))
+	case *redis.CommandsInfoCmd:
+		cmder = redis.NewCommandsInfoCmd(ctx, cmdList...)
+	case *redis.KeyFlagsCmd:
+		cmder = redis.NewKeyFlagsCmd(ctx, cmdList...)
+	case *redis.SlowLogCmd:
+		cmder = redis.NewSlowLogCmd(ctx, cmdList...)
+	case *redis.InfoCmd:
+		cmder = redis.NewInfoCmd(ctx, cmdList...)
+	case *redis.ClientInfoCmd:
+		cmder = redis.NewClientInfoCmd(ctx, cmdList...)
+	case *redis.ACLLogCmd:
+		cmder = redis.NewACLLogCmd(ctx, cmdList...)
+	case *redis.ClusterSlotsCmd:
+		cmder = redis.NewClusterSlotsCmd(ctx, cmdList...)
+	case *redis.ClusterShardsCmd:
+		cmder = redis.NewClusterShardsCmd(ctx, cmdList...)
+	case *redis.ClusterLinksCmd:
+		cmder = redis.NewClusterLinksCmd(ctx, cmdList...)
+	case *redis.FunctionListCmd:
+		cmder = redis.NewFunctionListCmd(ctx, cmdList...)
+	case *redis.FunctionStatsCmd:
+		cmder = redis.NewFunctionStatsCmd(ctx, cmdList...)
+	case *redis.JSONSliceCmd:
+		cmder = redis.NewJSONSliceCmd(ctx, cmdList...)
+	case *redis.AggregateCmd:
+		cmder = redis.NewAggregateCmd(ctx, cmdList...)
+	case *redis.FTSynDumpCmd:
+		cmder = redis.NewFTSynDumpCmd(ctx, cmdList...)
+	case *redis.BFInfoCmd:
+		cmder = redis.NewBFInfoCmd(ctx, cmdList...)
+	case *redis.CFInfoCmd:
+		cmder = redis.NewCFInfoCmd(ctx, cmdList...)
+	case *redis.CMSInfoCmd:
+		cmder = redis.NewCMSInfoCmd(ctx, cmdList...)
+	case *redis.TopKInfoCmd:
+		cmder = redis.NewTopKInfoCmd(ctx, cmdList...)
+	case *redis.TDigestInfoCmd:
+		cmder = redis.NewTDigestInfoCmd(ctx, cmdList...)
+	default:
+		cmder = redis.NewCmd(ctx, cmdList...)
+	}
+	return cmder
+}
+
+// scanInPipeline pipeline 中的 *redis.ScanCmd 不能通过 Iterator 继续翻页
+func scanInPipeline(ctx context.Context, cmd redis.Cmder) error {
+	err := errors.New("rdb: ScanCmd iterator is not supported in pipeline")
+	cmd.SetErr(err)
+	return err
+}
+
+// argAt 返回 cmdList[i] 的字符串形式, 不存在时返回空字符串
+func argAt(cmdList []any, i int) string {
+	if i >= len(cmdList) {
+		return ""
+	}
+	return fmt.Sprint(cmdList[i])
+}
+
+// newLCSCmd 用已经构建好的 LCS key1 key2 [LEN] [IDX] [MINMATCHLEN len] [WITHMATCHLEN] 参数创建 LCSCmd
+// LCSCmd 根据 LCSQuery 解析回复, 所以把选项解析回 LCSQuery, 由 go-redis 重新生成参数
+func newLCSCmd(ctx context.Context, cmdList []any) *redis.LCSCmd {
+	q := &redis.LCSQuery{Key1: argAt(cmdList, 1), Key2: argAt(cmdList, 2)}
+	for i := 3; i < len(cmdList); i++ {
+		switch strings.ToUpper(fmt.Sprint(cmdList[i])) {
+		case "LEN":
+			q.Len = true
+		case "IDX":
+			q.Idx = true
+		case "WITHMATCHLEN":
+			q.WithMatchLen = true
+		case "MINMATCHLEN":
+			if i+1 < len(cmdList) {
+				i++
+				q.MinMatchLen, _ = strconv.Atoi(fmt.Sprint(cmdList[i]))
+			}
+		}
+	}
+	return redis.NewLCSCmd(ctx, q)
+}
@@ -3,7 +3,10 @@ package rdb
 import (
 	"context"
 	"fmt"
+	"github.com/redis/go-redis/v9"
+	"reflect"
 	"testing"
+	"time"
 )
 
 // TestExecuteIntCmd_Chain 测试链式调用 ExecuteIntCmd - 直接获取 *redis.IntCmd 类型
@@ -417,3 +420,132 @@ func TestExecute_MultipleCommands(t *testing.T) {
 	sliceVal, _ := hgetallCmd.Result()
 	fmt.Printf("HGETALL returns: %T, value: %v\n", hgetallCmd, sliceVal)
 }
+
+// cmderTypeCase 返回 newCmder[T] 创建的 cmder 的类型和期望的类型
+func cmderTypeCase[T redis.Cmder]() (string, string) {
+	var zero T
+	cmder := newCmder[T](context.Background(), GET, []any{"GET", "k"}, nil)
+	return fmt.Sprintf("%T", cmder), fmt.Sprintf("%T", zero)
+}
+
+// TestNewCmder_Types 测试 newCmder 为 go-redis 中每一种有公开构造函数的 cmder 创建对应的类型
+func TestNewCmder_Types(t *testing.T) {
+	cases := [][2]string{}
+	add := func(got, want string) { cases = append(cases, [2]string{got, want}) }
+	add(cmderTypeCase[*redis.Cmd]())
+	add(cmderTypeCase[*redis.StringCmd]())
+	add(cmderTypeCase[*redis.StatusCmd]())
+	add(cmderTypeCase[*redis.IntCmd]())
+	add(cmderTypeCase[*redis.SliceCmd]())
+	add(cmderTypeCase[*redis.FloatCmd]())
+	add(cmderTypeCase[*redis.BoolCmd]())
+	add(cmderTypeCase[*redis.MapStringIntCmd]())
+	add(cmderTypeCase[*redis.MapStringStringCmd]())
+	add(cmderTypeCase[*redis.StringSliceCmd]())
+	add(cmderTypeCase[*redis.IntSliceCmd]())
+	add(cmderTypeCase[*redis.FloatSliceCmd]())
+	add(cmderTypeCase[*redis.BoolSliceCmd]())
+	add(cmderTypeCase[*redis.KeyValueSliceCmd]())
+	add(cmderTypeCase[*redis.KeyValuesCmd]())
+	add(cmderTypeCase[*redis.MapStringInterfaceCmd]())
+	add(cmderTypeCase[*redis.MapStringStringSliceCmd]())
+	add(cmderTypeCase[*redis.MapStringInterfaceSliceCmd]())
+	add(cmderTypeCase[*redis.MapStringSliceInterfaceCmd]())
+	add(cmderTypeCase[*redis.MapMapStringInterfaceCmd]())
+	add(cmderTypeCase[*redis.ZSliceCmd]())
+	add(cmderTypeCase[*redis.ZSliceWithKeyCmd]())
+	add(cmderTypeCase[*redis.ZWithKeyCmd]())
+	add(cmderTypeCase[*redis.DurationCmd]())
+	add(cmderTypeCase[*redis.GeoPosCmd]())
+	add(cmderTypeCase[*redis.GeoSearchLocationCmd]())
+	add(cmderTypeCase[*redis.GeoLocationCmd]())
+	add(cmderTypeCase[*redis.TimeCmd]())
+	add(cmderTypeCase[*redis.ScanCmd]())
+	add(cmderTypeCase[*redis.IntPointerSliceCmd]())
+	add(cmderTypeCase[*redis.StringStructMapCmd]())
+	add(cmderTypeCase[*redis.RankWithScoreCmd]())
+	add(cmderTypeCase[*redis.LCSCmd]())
+	add(cmderTypeCase[*redis.XMessageSliceCmd]())
+	add(cmderTypeCase[*redis.XStreamSliceCmd]())
+	add(cmderTypeCase[*redis.XPendingCmd]())
+	add(cmderTypeCase[*redis.XPendingExtCmd]())
+	add(cmderTypeCase[*redis.XAutoClaimCmd]())
+	add(cmderTypeCase[*redis.XAutoClaimJustIDCmd]())
+	add(cmderTypeCase[*redis.XInfoStreamCmd]())
+	add(cmderTypeCase[*redis.XInfoStreamFullCmd]())
+	add(cmderTypeCase[*redis.XInfoGroupsCmd]())
+	add(cmderTypeCase[*redis.XInfoConsumersCmd]())
+	add(cmderTypeCase[*redis.CommandsInfoCmd]())
+	add(cmderTypeCase[*redis.KeyFlagsCmd]())
+	add(cmderTypeCase[*redis.SlowLogCmd]())
+	add(cmderTypeCase[*redis.InfoCmd]())
+	add(cmderTypeCase[*redis.ClientInfoCmd]())
+	add(cmderTypeCase[*redis.ACLLogCmd]())
+	add(cmderTypeCase[*redis.ClusterSlotsCmd]())
+	add(cmderTypeCase[*redis.ClusterShardsCmd]())
+	add(cmderTypeCase[*redis.ClusterLinksCmd]())
+	add(cmderTypeCase[*redis.FunctionListCmd]())
+	add(cmderTypeCase[*redis.FunctionStatsCmd]())
+	add(cmderTypeCase[*redis.JSONSliceCmd]())
+	add(cmderTypeCase[*redis.AggregateCmd]())
+	add(cmderTypeCase[*redis.FTSynDumpCmd]())
+	add(cmderTypeCase[*redis.BFInfoCmd]())
+	add(cmderTypeCase[*redis.CFInfoCmd]())
+	add(cmderTypeCase[*redis.CMSInfoCmd]())
+	add(cmderTypeCase[*redis.TopKInfoCmd]())
+	add(cmderTypeCase[*redis.TDigestInfoCmd]())
+	for _, c := range cases {
+		if c[0] != c[1] {
+			t.Errorf("newCmder[%s] created %s", c[1], c[0])
+		}
+	}
+}
+
+// TestExecuteCmd_TimeAndScan 测试新增的 TimeCmd、ScanCmd 可以直接执行, pipeline 中的 ScanCmd 不能翻页
+func TestExecuteCmd_TimeAndScan(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "TIME":
+			return []any{"1700000000", "250000"}
+		case "SCAN":
+			if args[1] == "0" {
+				return []any{"5", []any{"a"}}
+			}
+			return []any{"0", []any{"b"}}
+		}
+		return nil
+	})
+	ctx := context.Background()
+	timeCmd := RdCmd{CMD: map[Command]RdSubCmd{TIME: {NoUseKey: true}}}
+	now, err := ExecuteCmd[*redis.TimeCmd](client, ctx, timeCmd, TIME, nil).Result()
+	if err != nil || !now.Equal(time.Unix(1700000000, 250000000)) {
+		t.Fatalf("TIME: unexpected result %v, %v", now, err)
+	}
+
+	scanCmd := RdCmd{CMD: map[Command]RdSubCmd{SCAN: {Params: "{{cursor}}", NoUseKey: true}}}
+	var keys []string
+	iter := ExecuteCmd[*redis.ScanCmd](client, ctx, scanCmd, SCAN, map[string]any{"cursor": 0}).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil || !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Fatalf("SCAN: expected [a b], got %v, %v", keys, err)
+	}
+
+	pip := client.PipeLine()
+	scan, _ := executeCmdInPipeline[*redis.ScanCmd](pip.Client, pip.opts, ctx, scanCmd, SCAN, map[string]any{"cursor": 0})
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+	pipeIter := scan.Iterator()
+	for pipeIter.Next(ctx) {
+	}
+	if pipeIter.Err() == nil {
+		t.Errorf("Expected pipeline ScanCmd iterator to fail when paging")
+	}
+
+	want := [][]string{{"TIME"}, {"SCAN", "0"}, {"SCAN", "5"}, {"SCAN", "0"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
@@ -2,31 +2,159 @@ package rdb
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"sync"
 )
 
 type RedisPipeline struct {
 	lua
 	builder
 	Client redis.Pipeliner
+	opts   pipelineOpts
+}
+
+// pipelineOpts pipeline 中排队命令时使用的选项
+type pipelineOpts struct {
+	argTransform func(cmdName Command, args []any) []any
+	dedup        *dedupSet     // 不为 nil 时合并相同的读命令
+	version      *cacheVersion // 和创建 pipeline 的客户端共享
+	keyPrefix    string
 }
 
 func newPipeline(client RedisClient) *RedisPipeline {
 	pip := RedisPipeline{
 		Client: client.Client.Pipeline(),
+		opts:   pipelineOpts{argTransform: client.ArgTransform, version: client.version, keyPrefix: client.KeyPrefix},
 	}
 	pip.builder = pip.Handler
 	pip.lua = pip.ExecScript
 	return &pip
 }
 
+// PipelineBuilder Pipelined 回调中使用的 pipeline, 提供和普通客户端一样的命令方法, 命令只排队, 回调返回之后统一执行
+type PipelineBuilder = RedisPipeline
+
+// Pipelined 创建一个 pipeline 交给 fn 排队命令, fn 返回之后执行, 和 go-redis 的 Pipelined 用法一致
+// fn 返回错误时丢弃已经排队的命令, 不会发送到 redis
+// 如:
+//
+//	cmds, err := client.Pipelined(ctx, func(p *PipelineBuilder) error {
+//		p.Get(ctx, cmd, map[string]any{"id": 1}).String()
+//		p.Incr(ctx, cmd, map[string]any{"id": 2}).Int()
+//		return nil
+//	})
+func (rdm RedisClient) Pipelined(ctx context.Context, fn func(p *PipelineBuilder) error) ([]redis.Cmder, error) {
+	pip := newPipeline(rdm)
+	if err := fn(pip); err != nil {
+		pip.Client.Discard()
+		return nil, err
+	}
+	return pip.Exec(ctx)
+}
+
+// PipeEach 用同一个命令定义和 argsList 中的每组参数构造命令, 放到同一个 pipeline 中一次发送, 适合批量写入
+// Params 模板只切分一次; 有命令构建失败时不发送任何命令, 返回第一个构建错误
+// 返回的 cmder 都是 *redis.Cmd, 和 argsList 的顺序一致; 设置了 Exp 时 EXPIRE 也在同一个 pipeline 中, 但不在返回的列表中
+func (rdm RedisClient) PipeEach(ctx context.Context, cmd RdCmd, cmdName Command, argsList []map[string]any) ([]redis.Cmder, error) {
+	cmders := make([]redis.Cmder, 0, len(argsList))
+	_, err := rdm.Pipelined(ctx, func(p *PipelineBuilder) error {
+		for i, args := range argsList {
+			cmder := inPipeline[*redis.Cmd](p.Handler(ctx, cmd, cmdName, args))
+			if err := cmder.Err(); err != nil {
+				cmders = nil
+				return fmt.Errorf("rdb: PipeEach args[%d]: %w", i, err)
+			}
+			cmders = append(cmders, cmder)
+		}
+		return nil
+	})
+	return cmders, err
+}
+
 func (pip RedisPipeline) Handler(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) *CommandBuilder {
 	// 返回 CommandBuilder，支持链式调用
 	// Pipeline 中的命令会在 Exec() 时执行
-	return NewPipelineCommandBuilder(pip.Client, ctx, cmd, cmdName, args, includeArgs...)
+	cb := NewPipelineCommandBuilder(pip.Client, ctx, cmd, cmdName, args, includeArgs...)
+	cb.pipeOpts = pip.opts
+	return cb
+}
+
+// Dedup 开启相同读命令的合并: 构造出的参数完全一样、结果类型也一样的读命令只会发送一次, 所有调用方拿到同一个结果
+// 中间排队了写命令(或者设置了 Exp 的命令)时, 之前的读命令不再参与合并, 保证结果和不合并时一致
+// Exec 返回的 cmder 列表中合并的命令只会出现一次
+func (pip *RedisPipeline) Dedup() *RedisPipeline {
+	pip.opts.dedup = &dedupSet{}
+	pip.builder = pip.Handler
+	pip.lua = pip.ExecScript
+	return pip
 }
 
 // 这一步才是真正的执行命令， 之前的所有步骤都是在往数组中添加命令， 实际没有发送到redis中
 func (pip RedisPipeline) Exec(ctx context.Context) ([]redis.Cmder, error) {
+	if pip.opts.dedup != nil {
+		pip.opts.dedup.reset()
+	}
 	return pip.Client.Exec(ctx)
 }
+
+// ExecPartial 执行 pipeline, 和 Exec 不同的是不会只返回第一个错误:
+// 返回所有命令的 cmder(成功的命令可以正常读取结果), 所有失败命令的错误通过 errors.Join 合并返回, 错误中带有命令的序号和名称
+// redis.Nil 不算失败
+func (pip RedisPipeline) ExecPartial(ctx context.Context) ([]redis.Cmder, error) {
+	cmds, err := pip.Exec(ctx)
+	if err == nil {
+		return cmds, nil
+	}
+	var errs []error
+	for i, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil && !errors.Is(cmdErr, redis.Nil) {
+			errs = append(errs, fmt.Errorf("rdb: pipeline command %d (%s): %w", i, cmd.Name(), cmdErr))
+		}
+	}
+	if len(errs) == 0 && !errors.Is(err, redis.Nil) {
+		// 没有排队的命令或者命令本身没有记录错误
+		return cmds, err
+	}
+	return cmds, errors.Join(errs...)
+}
+
+// dedupSet 记录 pipeline 中已经排队的读命令
+type dedupSet struct {
+	mu     sync.Mutex
+	queued map[string]redis.Cmder
+}
+
+func (d *dedupSet) get(key string) (redis.Cmder, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cmder, ok := d.queued[key]
+	return cmder, ok
+}
+
+func (d *dedupSet) put(key string, cmder redis.Cmder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.queued == nil {
+		d.queued = map[string]redis.Cmder{}
+	}
+	d.queued[key] = cmder
+}
+
+func (d *dedupSet) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queued = nil
+}
+
+// readOnlyCommands 可以合并的只读命令
+var readOnlyCommands = map[Command]bool{
+	EXISTS: true, TTL: true, PTTL: true, TYPE: true, EXPIRETIME: true,
+	GET: true, MGET: true, GETRANGE: true, STRLEN: true,
+	HGET: true, HMGET: true, HGETALL: true, HKEYS: true, HVALS: true, HLEN: true, HEXISTS: true, HSTRLEN: true,
+	LINDEX: true, LLEN: true, LRANGE: true,
+	SCARD: true, SISMEMBER: true, SMISMEMBER: true, SMEMBERS: true, SINTER: true, SUNION: true, SDIFF: true,
+	ZCARD: true, ZCOUNT: true, ZLEXCOUNT: true, ZRANGE: true, ZREVRANGE: true, ZRANGEBYLEX: true,
+	ZRANGEBYSCORE: true, ZREVRANGEBYSCORE: true, ZRANK: true, ZREVRANK: true, ZSCORE: true,
+}
@@ -2,8 +2,14 @@ package rdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestRedisClient_PipeLine(t *testing.T) {
@@ -23,3 +29,267 @@ func TestRedisClient_PipeLine(t *testing.T) {
 	fmt.Println(add.Val())
 	fmt.Println(zer.Val())
 }
+
+// TestRedisPipeline_Dedup 测试 pipeline 中相同的读命令只发送一次, 所有调用方拿到同一个结果
+func TestRedisPipeline_Dedup(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{"user:1": "alice"}
+	client, fake := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "GET":
+			return values[args[1]]
+		case "SET":
+			values[args[1]] = args[2]
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			GET: {},
+			SET: {Params: "{{name}}"},
+		},
+	}
+	ctx := context.Background()
+
+	pip := client.PipeLine().Dedup()
+	a := pip.Get(ctx, cmd, map[string]any{"id": 1}).String()
+	b := pip.Get(ctx, cmd, map[string]any{"id": 1}).String()
+	c := pip.Get(ctx, cmd, map[string]any{"id": 1}).String()
+	// 不同的结果类型不合并
+	d := pip.Get(ctx, cmd, map[string]any{"id": 1})
+	_ = d.Err() // 没有指定结果类型时使用 *redis.Cmd 排队
+	// 写命令之后的读命令重新发送
+	pip.Set(ctx, cmd, map[string]any{"id": 1, "name": "bob"}).Status()
+	e := pip.Get(ctx, cmd, map[string]any{"id": 1}).String()
+	cmds, err := pip.Exec(ctx)
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	for i, got := range []string{a.Val(), b.Val(), c.Val()} {
+		if got != "alice" {
+			t.Errorf("caller %d expected alice, got %q", i, got)
+		}
+	}
+	if d.Val() != "alice" {
+		t.Errorf("unexpected default result: %v %v", d.Val(), d.Err())
+	}
+	if e.Val() != "bob" {
+		t.Errorf("read after write expected bob, got %q", e.Val())
+	}
+	if len(cmds) != 4 {
+		t.Errorf("expected 4 queued commands, got %d", len(cmds))
+	}
+
+	want := [][]string{
+		{"GET", "user:1"},
+		{"GET", "user:1"},
+		{"SET", "user:1", "bob"},
+		{"GET", "user:1"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisPipeline_ExecPartial 测试部分命令失败时成功命令的结果仍然可用, 所有失败命令的错误合并返回
+func TestRedisPipeline_ExecPartial(t *testing.T) {
+	wrongType := errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	client, _ := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "GET":
+			if args[1] == "user:1" {
+				return "alice"
+			}
+			return nil
+		case "HGET", "LLEN":
+			return wrongType
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			GET:  {},
+			HGET: {Params: "{{field}}"},
+			LLEN: {},
+		},
+	}
+	ctx := context.Background()
+
+	pip := client.PipeLine()
+	name := pip.Get(ctx, cmd, map[string]any{"id": 1}).String()
+	pip.HGet(ctx, cmd, map[string]any{"id": 1, "field": "name"}).String()
+	missing := pip.Get(ctx, cmd, map[string]any{"id": 2}).String()
+	pip.LLen(ctx, cmd, map[string]any{"id": 1}).Int()
+	cmds, err := pip.ExecPartial(ctx)
+	if len(cmds) != 4 {
+		t.Fatalf("Expected 4 cmders, got %d", len(cmds))
+	}
+	if name.Val() != "alice" || name.Err() != nil {
+		t.Errorf("Expected alice, got %q %v", name.Val(), name.Err())
+	}
+	if !errors.Is(missing.Err(), redis.Nil) {
+		t.Errorf("Expected redis.Nil for missing key, got %v", missing.Err())
+	}
+	if err == nil {
+		t.Fatalf("Expected joined error")
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("Expected 2 joined errors, got %v", err)
+	}
+	for _, want := range []string{"command 1 (hget)", "command 3 (llen)", "WRONGTYPE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+// TestRedisClient_Pipelined 测试 Pipelined 在回调返回之后统一执行排队的命令
+func TestRedisClient_Pipelined(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "GET":
+			return "alice"
+		case "INCR":
+			return 3
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{GET: {}, INCR: {}},
+	}
+	ctx := context.Background()
+
+	var name *redis.StringCmd
+	var count *redis.IntCmd
+	cmds, err := client.Pipelined(ctx, func(p *PipelineBuilder) error {
+		name = p.Get(ctx, cmd, map[string]any{"id": 1}).String()
+		count = p.Incr(ctx, cmd, map[string]any{"id": 2}).Int()
+		if len(fake.Commands()) != 0 {
+			t.Errorf("Expected commands to be queued, got %v", fake.Commands())
+		}
+		return nil
+	})
+	if err != nil || len(cmds) != 2 {
+		t.Fatalf("Pipelined failed: %d %v", len(cmds), err)
+	}
+	if name.Val() != "alice" || count.Val() != 3 {
+		t.Errorf("Expected alice and 3, got %q %d", name.Val(), count.Val())
+	}
+	want := [][]string{{"GET", "user:1"}, {"INCR", "user:2"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// 回调返回错误时不执行
+	fake.Reset()
+	abort := errors.New("abort")
+	cmds, err = client.Pipelined(ctx, func(p *PipelineBuilder) error {
+		p.Get(ctx, cmd, map[string]any{"id": 1}).String()
+		return abort
+	})
+	if !errors.Is(err, abort) || cmds != nil {
+		t.Errorf("Expected abort error, got %v %v", cmds, err)
+	}
+	if len(fake.Commands()) != 0 {
+		t.Errorf("Expected no commands sent, got %v", fake.Commands())
+	}
+}
+
+// TestRedisPipeline_ExpireCmd 测试 Pipeline 中设置了 Exp 的命令可以拿到 EXPIRE 的结果
+func TestRedisPipeline_ExpireCmd(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "SET":
+			return fakeStatus("OK")
+		case "EXPIRE":
+			return 1
+		case "GET":
+			return "v"
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "session:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {Params: "{{value}}", Exp: func() time.Duration { return time.Minute }},
+			GET: {},
+		},
+	}
+	ctx := context.Background()
+
+	pip := client.PipeLine()
+	set := pip.Set(ctx, cmd, map[string]any{"id": 1, "value": "v"})
+	set.Status()
+	get := pip.Get(ctx, cmd, map[string]any{"id": 1})
+	get.String()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	expireCmd := set.ExpireCmd()
+	if expireCmd == nil {
+		t.Fatalf("Expected expire cmder")
+	}
+	if ok, err := expireCmd.Result(); err != nil || !ok {
+		t.Errorf("Expected expire to succeed, got %v %v", ok, err)
+	}
+	if get.ExpireCmd() != nil {
+		t.Errorf("Expected nil expire cmder without Exp")
+	}
+	want := [][]string{{"SET", "session:1", "v"}, {"EXPIRE", "session:1", "60"}, {"GET", "session:1"}}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_PipeEach 测试 PipeEach 把每组参数构造的命令放在同一个 pipeline 中, 构建失败时不发送任何命令
+func TestRedisClient_PipeEach(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		return 1
+	})
+	ctx := context.Background()
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{HSET: {Params: "name {{name}}", StrictArgs: true, Exp: func() time.Duration { return time.Minute }}},
+	}
+
+	cmders, err := client.PipeEach(ctx, cmd, HSET, []map[string]any{
+		{"id": 1, "name": "tom"},
+		{"id": 2, "name": "amy"},
+	})
+	if err != nil {
+		t.Fatalf("PipeEach failed: %v", err)
+	}
+	if len(cmders) != 2 {
+		t.Fatalf("Expected 2 cmders, got %d", len(cmders))
+	}
+	for _, cmder := range cmders {
+		if n, err := cmder.(*redis.Cmd).Int(); err != nil || n != 1 {
+			t.Errorf("Expected 1, got %d, %v", n, err)
+		}
+	}
+	want := [][]string{
+		{"HSET", "user:1", "name", "tom"},
+		{"EXPIRE", "user:1", "60"},
+		{"HSET", "user:2", "name", "amy"},
+		{"EXPIRE", "user:2", "60"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	fake.Reset()
+	cmders, err = client.PipeEach(ctx, cmd, HSET, []map[string]any{{"id": 3, "name": "bob"}, {"id": 4}})
+	if err == nil || cmders != nil {
+		t.Errorf("Expected build error and no cmders, got %v %v", cmders, err)
+	}
+	if got := fake.Commands(); len(got) != 0 {
+		t.Errorf("Expected no commands, got %v", got)
+	}
+}
@@ -0,0 +1,41 @@
+package rdb
+
+import "context"
+
+// PreparedCmd 预编译的命令, 子命令和切分好的 Params 模板只查找、解析一次, 之后每次 Build 只做参数填充
+// 适合同一种命令被大量重复执行的场景, 构造过程和 Build 相同, 结果一致
+type PreparedCmd struct {
+	cmd     RdCmd
+	cmdName Command
+	subCmd  RdSubCmd
+	tokens  []paramToken
+}
+
+// PrepareCmd 预编译 cmd 中的 cmdName 子命令, 子命令不存在时返回错误
+func PrepareCmd(cmd RdCmd, cmdName Command) (*PreparedCmd, error) {
+	subCmd, ok := cmd.CMD[cmdName]
+	if !ok {
+		return nil, ErrUnknownCommand{Command: cmdName}
+	}
+	return &PreparedCmd{cmd: cmd, cmdName: cmdName, subCmd: subCmd, tokens: tokenizeParams(subCmd.Params)}, nil
+}
+
+// SubCmd 返回预编译的子命令定义
+func (p *PreparedCmd) SubCmd() RdSubCmd {
+	return p.subCmd
+}
+
+// Build 用 args 填充预编译的命令, 返回命令参数和 key, 和 Build 一样构建失败时会 panic
+func (p *PreparedCmd) Build(ctx context.Context, args map[string]any, includeArgs ...any) ([]any, string) {
+	cmdArgs, keyStr, err := p.TryBuild(ctx, args, includeArgs...)
+	if err != nil {
+		panic(err)
+	}
+	return cmdArgs, keyStr
+}
+
+// TryBuild 和 Build 一样, 但是构建失败时返回 error 而不是 panic
+// 和 TryBuild 一样使用 WithDefaultArgs 设置在 ctx 中的默认参数, 并检查 IncludeArity
+func (p *PreparedCmd) TryBuild(ctx context.Context, args map[string]any, includeArgs ...any) ([]any, string, error) {
+	return buildWithTokens(p.cmd, p.cmdName, p.subCmd, p.tokens, mergeContextArgs(ctx, args), includeArgs)
+}
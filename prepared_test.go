@@ -0,0 +1,104 @@
+package rdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestPrepareCmd 测试预编译命令的构造结果和 Build 一致
+func TestPrepareCmd(t *testing.T) {
+	cmd := RdCmd{
+		Key: "user:{{id}}:profile",
+		CMD: map[Command]RdSubCmd{
+			HSET:   {Params: "name {{name}}  age {{age}} tag t-{{tag}}-x"},
+			HMGET:  {Params: "{{fields}}"},
+			SET:    {Params: "{{value}} EX {{ttl}}", DefaultParams: map[string]any{"ttl": 60}},
+			DEL:    {Params: "user:{{id}}:profile user:{{id}}:stats", NoUseKey: true},
+			EXISTS: {Params: "{{unknown}}"},
+			OBJECT: {Params: "ENCODING {{key}}", NoUseKey: true},
+		},
+	}
+	cases := []struct {
+		cmdName Command
+		args    map[string]any
+	}{
+		{HSET, map[string]any{"id": 1, "name": "tom", "age": 18, "tag": "vip"}},
+		{HSET, map[string]any{"id": 2, "name": "amy"}},
+		{HMGET, map[string]any{"id": 3, "fields": []string{"name", "age"}}},
+		{HMGET, map[string]any{"id": 3, "fields": map[string]any{"b": 2, "a": 1}}},
+		{SET, map[string]any{"id": 4, "value": 1.5}},
+		{SET, map[string]any{"id": 4, "value": "v", "ttl": 10}},
+		{DEL, map[string]any{"id": 5}},
+		{EXISTS, map[string]any{"id": 6}},
+		{OBJECT, map[string]any{"id": 7}},
+	}
+	for _, c := range cases {
+		prepared, err := PrepareCmd(cmd, c.cmdName)
+		if err != nil {
+			t.Fatalf("PrepareCmd(%s) failed: %v", c.cmdName, err)
+		}
+		gotArgs, gotKey := prepared.Build(context.Background(), c.args)
+		wantArgs, wantKey, _ := Build(context.Background(), cmd, c.cmdName, c.args)
+		if !reflect.DeepEqual(gotArgs, wantArgs) || gotKey != wantKey {
+			t.Errorf("%s %v: expected %v %q, got %v %q", c.cmdName, c.args, wantArgs, wantKey, gotArgs, gotKey)
+		}
+	}
+
+	if _, err := PrepareCmd(cmd, GET); err == nil {
+		t.Errorf("Expected error for unknown command")
+	}
+}
+
+// TestPrepareCmd_StrictArgs 测试严格模式下缺少参数时 panic
+func TestPrepareCmd_StrictArgs(t *testing.T) {
+	cmd := RdCmd{Key: "k:{{id}}", CMD: map[Command]RdSubCmd{SET: {Params: "{{value}}", StrictArgs: true}}}
+	prepared, err := PrepareCmd(cmd, SET)
+	if err != nil {
+		t.Fatalf("PrepareCmd failed: %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic for unresolved placeholders")
+		}
+	}()
+	prepared.Build(context.Background(), map[string]any{"id": 1})
+}
+
+// TestPrepareCmd_ContextArgs 测试预编译命令和 TryBuild 一样使用 ctx 中的默认参数并检查 IncludeArity
+func TestPrepareCmd_ContextArgs(t *testing.T) {
+	cmd := RdCmd{
+		Key: "{{tenant}}:user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			HSET: {IncludeArity: 2},
+		},
+	}
+	prepared, err := PrepareCmd(cmd, HSET)
+	if err != nil {
+		t.Fatalf("PrepareCmd failed: %v", err)
+	}
+	ctx := WithDefaultArgs(context.Background(), map[string]any{"tenant": "t1"})
+
+	got, key, err := prepared.TryBuild(ctx, map[string]any{"id": 1}, "name", "tom")
+	if want := []any{"HSET", "t1:user:1", "name", "tom"}; err != nil || !reflect.DeepEqual(got, want) || key != "t1:user:1" {
+		t.Errorf("expected %v, got %v %q %v", want, got, key, err)
+	}
+	if _, _, err := prepared.TryBuild(ctx, map[string]any{"id": 1}, "name"); err == nil {
+		t.Errorf("Expected IncludeArity error")
+	}
+}
+
+// BenchmarkPreparedCmd_Build 预编译命令的构造, 和 BenchmarkBuildLoop 使用相同的命令
+func BenchmarkPreparedCmd_Build(b *testing.B) {
+	prepared, err := PrepareCmd(benchmarkBuildCmd, HSET)
+	if err != nil {
+		b.Fatal(err)
+	}
+	argsList := benchmarkArgsList(500)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, args := range argsList {
+			prepared.Build(context.Background(), args)
+		}
+	}
+}
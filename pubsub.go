@@ -0,0 +1,82 @@
+package rdb
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"time"
+)
+
+// listenRetryDelay Listen 接收消息失败之后等待多久再继续, go-redis 会在下一次接收时重新连接并重新订阅
+const listenRetryDelay = 100 * time.Millisecond
+
+// Subscribe SUBSCRIBE channel [channel ...], 返回 go-redis 的 PubSub, 使用完之后需要 Close
+// 订阅命令不经过模板, 连接断开之后 go-redis 会自动重新订阅
+func (rdm *RedisClient) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return rdm.Client.Subscribe(ctx, channels...)
+}
+
+// PSubscribe PSUBSCRIBE pattern [pattern ...], 按模式订阅, 使用完之后需要 Close
+func (rdm *RedisClient) PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub {
+	return rdm.Client.PSubscribe(ctx, patterns...)
+}
+
+// Listen 订阅 channels 并把收到的消息依次交给 handler 处理, 一直阻塞到 ctx 取消, ctx 取消时返回 nil
+// 连接断开时记录日志并自动重新连接和重新订阅, 断开期间发布的消息会丢失(pub/sub 本身不保证送达)
+// 订阅失败(如: 第一次连接失败)时返回错误
+func (rdm *RedisClient) Listen(ctx context.Context, handler func(ctx context.Context, msg *redis.Message), channels ...string) error {
+	return listen(ctx, rdm.Subscribe(ctx, channels...), handler)
+}
+
+// PListen 和 Listen 一样, 但是按模式订阅, msg.Pattern 是匹配的模式
+func (rdm *RedisClient) PListen(ctx context.Context, handler func(ctx context.Context, msg *redis.Message), patterns ...string) error {
+	return listen(ctx, rdm.PSubscribe(ctx, patterns...), handler)
+}
+
+// ListenJSON 和 Listen 一样, 但是先把消息内容按 json 解析成 T 再交给 handler, 解析失败的消息记录日志后跳过
+func ListenJSON[T any](rdm *RedisClient, ctx context.Context, handler func(ctx context.Context, channel string, val T), channels ...string) error {
+	return rdm.Listen(ctx, func(ctx context.Context, msg *redis.Message) {
+		var val T
+		if err := json.Unmarshal([]byte(msg.Payload), &val); err != nil {
+			slog.Warn("rdb: decode pubsub message", "channel", msg.Channel, "error", err)
+			return
+		}
+		handler(ctx, msg.Channel, val)
+	}, channels...)
+}
+
+func listen(ctx context.Context, pubsub *redis.PubSub, handler func(ctx context.Context, msg *redis.Message)) error {
+	// ctx 取消时关闭 PubSub, 让阻塞中的接收返回
+	stop := context.AfterFunc(ctx, func() { _ = pubsub.Close() })
+	defer func() {
+		if stop() {
+			_ = pubsub.Close()
+		}
+	}()
+	// 等待订阅确认, 订阅失败时直接返回错误
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			slog.Warn("rdb: pubsub receive", "error", err)
+			timer := time.NewTimer(listenRetryDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+			continue
+		}
+		handler(ctx, msg)
+	}
+}
@@ -0,0 +1,114 @@
+package rdb
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// waitUntil 等待 cond 成立, 超时时测试失败
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// receiveMessage 从 ch 中读取一条消息, 超时时测试失败
+func receiveMessage[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for message")
+	}
+	var zero T
+	return zero
+}
+
+// TestRedisClient_Listen 测试 Listen 分发消息, 连接断开之后自动重新订阅, ctx 取消时退出
+func TestRedisClient_Listen(t *testing.T) {
+	client, fake := newFakeClient(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs := make(chan *redis.Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Listen(ctx, func(ctx context.Context, msg *redis.Message) { msgs <- msg }, "news", "alerts")
+	}()
+
+	waitUntil(t, "subscription", func() bool { return fake.Subscribers() == 1 })
+	fake.Publish("news", "hello")
+	if msg := receiveMessage(t, msgs); msg.Channel != "news" || msg.Payload != "hello" {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+
+	// 断开连接之后重新订阅
+	fake.DropSubscribers()
+	waitUntil(t, "resubscribe", func() bool { return fake.Subscribers() == 1 })
+	fake.Publish("alerts", "again")
+	if msg := receiveMessage(t, msgs); msg.Channel != "alerts" || msg.Payload != "again" {
+		t.Errorf("Unexpected message after resubscribe: %+v", msg)
+	}
+	var subscribes int
+	for _, args := range fake.Commands() {
+		if args[0] == "SUBSCRIBE" {
+			subscribes++
+			// 重新订阅时 channel 的顺序不确定
+			if channels := slices.Sorted(slices.Values(args[1:])); !reflect.DeepEqual(channels, []string{"alerts", "news"}) {
+				t.Errorf("Unexpected SUBSCRIBE args: %v", args)
+			}
+		}
+	}
+	if subscribes != 2 {
+		t.Errorf("Expected 2 SUBSCRIBE commands, got %d", subscribes)
+	}
+
+	cancel()
+	if err := receiveMessage(t, done); err != nil {
+		t.Errorf("Expected nil after cancel, got %v", err)
+	}
+}
+
+// TestRedisClient_PListen 测试按模式订阅时消息带有匹配的模式
+func TestRedisClient_PListen(t *testing.T) {
+	client, fake := newFakeClient(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs := make(chan *redis.Message, 10)
+	go client.PListen(ctx, func(ctx context.Context, msg *redis.Message) { msgs <- msg }, "user:*")
+
+	waitUntil(t, "subscription", func() bool { return fake.Subscribers() == 1 })
+	fake.Publish("order:1", "ignored")
+	fake.Publish("user:1", "login")
+	if msg := receiveMessage(t, msgs); msg.Pattern != "user:*" || msg.Channel != "user:1" || msg.Payload != "login" {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+}
+
+// TestListenJSON 测试消息按 json 解析后交给 handler, 解析失败的消息被跳过
+func TestListenJSON(t *testing.T) {
+	type event struct {
+		ID   int    `json:"id"`
+		Kind string `json:"kind"`
+	}
+	client, fake := newFakeClient(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan event, 10)
+	go ListenJSON(client, ctx, func(ctx context.Context, channel string, e event) { events <- e }, "events")
+
+	waitUntil(t, "subscription", func() bool { return fake.Subscribers() == 1 })
+	fake.Publish("events", "not json")
+	fake.Publish("events", `{"id":7,"kind":"created"}`)
+	if e := receiveMessage(t, events); e != (event{ID: 7, Kind: "created"}) {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+}
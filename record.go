@@ -0,0 +1,169 @@
+package rdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// recordEntry 录制文件中的一条记录, 每行一个 json
+type recordEntry struct {
+	Args  []string        `json:"args"`
+	Reply json.RawMessage `json:"reply,omitempty"`
+	Err   string          `json:"err,omitempty"`
+}
+
+// RecordTo 录制之后通过这个客户端执行的所有命令和回复, 每条记录以一行 json 写入 w, 可以之后通过 ReplayFrom 回放
+// 录制通过 redis 的 hook 实现, 直接执行和 pipeline 中的命令都会被录制; w 的写入错误只记录日志, 不影响命令执行
+func (rdm *RedisClient) RecordTo(w io.Writer) {
+	rdm.Client.AddHook(&recordHook{enc: json.NewEncoder(w)})
+}
+
+type recordHook struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (h *recordHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *recordHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		// 单个命令的错误在 hook 返回之后才会设置到 cmd 上, 所以使用 next 返回的错误
+		err := next(ctx, cmd)
+		h.record(cmd, err)
+		return err
+	}
+}
+
+func (h *recordHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			h.record(cmd, cmd.Err())
+		}
+		return err
+	}
+}
+
+func (h *recordHook) record(cmd redis.Cmder, err error) {
+	entry := recordEntry{Args: recordArgs(cmd.Args())}
+	if err != nil {
+		entry.Err = err.Error()
+	} else if val := reflect.ValueOf(cmd).MethodByName("Val"); val.IsValid() && val.Type().NumIn() == 0 {
+		reply, err := json.Marshal(val.Call(nil)[0].Interface())
+		if err != nil {
+			slog.Error("record redis reply", "cmd", entry.Args, "error", err.Error())
+			return
+		}
+		entry.Reply = reply
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.enc.Encode(entry); err != nil {
+		slog.Error("record redis reply", "cmd", entry.Args, "error", err.Error())
+	}
+}
+
+// recordArgs 把参数转成字符串用于匹配, 命令名统一大写
+func recordArgs(args []any) []string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			res[i] = v
+		case []byte:
+			res[i] = string(v)
+		default:
+			res[i] = fmt.Sprint(v)
+		}
+	}
+	if len(res) > 0 {
+		res[0] = strings.ToUpper(res[0])
+	}
+	return res
+}
+
+// ReplayFrom 读取 RecordTo 录制的记录, 返回一个不需要 redis 服务的回放客户端
+// 匹配规则: 命令的参数(转成字符串之后)必须和录制时完全一致, 相同的命令按录制的顺序依次返回
+// 记录用完或者没有匹配的记录时返回错误; 回放只对直接执行的命令生效, 不会执行 Exp 设置的过期时间
+// 回复按照结果方法的类型从 json 解析, 如: 录制时用 Int() 回放时也需要用 Int()
+func (rdm *RedisClient) ReplayFrom(r io.Reader) (*RedisClient, error) {
+	rp := &replayer{entries: map[string][]recordEntry{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry recordEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("rdb: invalid record %q: %w", scanner.Text(), err)
+		}
+		key := strings.Join(entry.Args, "\x00")
+		rp.entries[key] = append(rp.entries[key], entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	client := rdm.clone()
+	client.replay = rp
+	return client, nil
+}
+
+type replayer struct {
+	mu      sync.Mutex
+	entries map[string][]recordEntry
+}
+
+// reply 找到和 cmder 参数一致的下一条记录并写入 cmder
+func (rp *replayer) reply(cmder redis.Cmder) error {
+	args := recordArgs(cmder.Args())
+	key := strings.Join(args, "\x00")
+	rp.mu.Lock()
+	entries := rp.entries[key]
+	if len(entries) == 0 {
+		rp.mu.Unlock()
+		err := fmt.Errorf("rdb: no recorded reply for %s", strings.Join(args, " "))
+		cmder.SetErr(err)
+		return err
+	}
+	entry := entries[0]
+	rp.entries[key] = entries[1:]
+	rp.mu.Unlock()
+
+	switch {
+	case entry.Err == redis.Nil.Error():
+		cmder.SetErr(redis.Nil)
+		return redis.Nil
+	case entry.Err != "":
+		err := errors.New(entry.Err)
+		cmder.SetErr(err)
+		return err
+	}
+	setVal := reflect.ValueOf(cmder).MethodByName("SetVal")
+	if !setVal.IsValid() || setVal.Type().NumIn() != 1 {
+		err := fmt.Errorf("rdb: %T does not support SetVal", cmder)
+		cmder.SetErr(err)
+		return err
+	}
+	val := reflect.New(setVal.Type().In(0))
+	if len(entry.Reply) > 0 {
+		if err := json.Unmarshal(entry.Reply, val.Interface()); err != nil {
+			err = fmt.Errorf("rdb: cannot decode recorded reply for %s as %T: %w", strings.Join(args, " "), cmder, err)
+			cmder.SetErr(err)
+			return err
+		}
+	}
+	setVal.Call([]reflect.Value{val.Elem()})
+	return nil
+}
@@ -0,0 +1,82 @@
+package rdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRedisClient_RecordReplay 测试录制一次 SET/GET 会话, 之后不需要 redis 服务回放
+func TestRedisClient_RecordReplay(t *testing.T) {
+	var mu sync.Mutex
+	values := map[string]string{}
+	client, _ := newFakeClient(t, func(args []string) any {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "SET":
+			values[args[1]] = args[2]
+			return fakeStatus("OK")
+		case "GET":
+			if v, ok := values[args[1]]; ok {
+				return v
+			}
+			return nil
+		case "EXPIRE":
+			return 1
+		}
+		return nil
+	})
+	cmd := RdCmd{
+		Key: "session:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET: {Params: "{{value}}", Exp: func() time.Duration { return time.Minute }},
+			GET: {ReturnNilError: true},
+		},
+	}
+	ctx := context.Background()
+	session := func(c *RedisClient) (string, error) {
+		if err := c.Set(ctx, cmd, map[string]any{"id": 1, "value": "alice"}).Status().Err(); err != nil {
+			return "", err
+		}
+		first, err := c.Get(ctx, cmd, map[string]any{"id": 1}).String().Result()
+		if err != nil {
+			return "", err
+		}
+		_, err = c.Get(ctx, cmd, map[string]any{"id": 2}).String().Result()
+		return first, err
+	}
+
+	var buf bytes.Buffer
+	client.RecordTo(&buf)
+	got, err := session(client)
+	if got != "alice" || !errors.Is(err, redis.Nil) {
+		t.Fatalf("unexpected recorded session: %q %v", got, err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Errorf("expected 4 recorded commands (SET, EXPIRE, GET, GET), got %d:\n%s", n, buf.String())
+	}
+
+	replay, err := (&RedisClient{}).ReplayFrom(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReplayFrom failed: %v", err)
+	}
+	got, err = session(replay)
+	if got != "alice" || !errors.Is(err, redis.Nil) {
+		t.Errorf("unexpected replayed session: %q %v", got, err)
+	}
+
+	// 记录已经用完
+	if err := replay.Get(ctx, cmd, map[string]any{"id": 1}).String().Err(); err == nil || !strings.Contains(err.Error(), "no recorded reply") {
+		t.Errorf("expected no recorded reply error, got %v", err)
+	}
+	// 参数不一致时不匹配
+	if err := replay.Set(ctx, cmd, map[string]any{"id": 1, "value": "bob"}).Status().Err(); err == nil {
+		t.Errorf("expected mismatch error")
+	}
+}
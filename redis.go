@@ -2,8 +2,11 @@ package rdb
 
 import (
 	"context"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 	"log/slog"
+	"sync/atomic"
+	"time"
 )
 
 // 普通指令
@@ -22,22 +25,148 @@ type Config struct {
 	MinIdle     int    `json:"minIdle" yaml:"minIdle"`
 	IdleTimeout int    `json:"idleTimeout" yaml:"idleTimeout"`
 	PoolSize    int    `json:"poolSize" yaml:"poolSize"`
+	// CacheVersion 缓存版本, 不为空时 key 会加上 "版本:" 前缀, 升级版本后旧版本的 key 不会再被访问
+	CacheVersion string `json:"cacheVersion" yaml:"cacheVersion"`
 }
 
+// Processor 执行 builder 构造的命令需要的最小接口: Process 发送命令, Expire 设置 RdSubCmd.Exp 的过期时间
+// redis.Cmdable 中没有 Process, 所以这里单独定义; 单机、哨兵、集群的客户端以及 redis.Pipeliner 都实现了这个接口
+type Processor interface {
+	Process(ctx context.Context, cmd redis.Cmder) error
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+}
+
+var (
+	_ Processor = (*redis.Client)(nil)
+	_ Processor = (*redis.ClusterClient)(nil)
+	_ Processor = (*redis.Ring)(nil)
+	_ Processor = (redis.UniversalClient)(nil)
+	_ Processor = (redis.Pipeliner)(nil)
+)
+
 type RedisClient struct {
 	lua
 	builder
-	Config Config
-	Client *redis.Client
+	Config   Config
+	Client   redis.UniversalClient // 单机、哨兵和集群的客户端都实现了这个接口
+	features *featureCache
+	scripts  *scriptCache
+	stubs    map[Command]StubFunc
+	replay   *replayer
+	version  *cacheVersion
+
+	// ArgTransform 可选, 命令参数构造完成之后、发送之前调用, 可以统一改写参数, 如: 改写 key 的前缀
+	// args[0] 是命令名, 返回值作为最终发送的参数; 对之后创建的 pipeline 同样生效
+	ArgTransform func(cmdName Command, args []any) []any
+
+	// Retry 可选, 直接执行的命令遇到临时性错误时按这个策略重试
+	Retry *RetryPolicy
+
+	// KeyPrefix 可选, 所有 key 的命名空间前缀, 如: "tenant1:", 对之后创建的 pipeline 同样生效, 下面这些 key 会加前缀:
+	//   - RdCmd.Key 构造出的 key, 加在 CacheVersion 的前缀之前, Exp 的 EXPIRE 同样使用加了前缀的 key
+	//   - multiKeyCommands 中的命令(DEL/MGET/MSET/BLPOP/SINTERCARD 等)在 key 位置上的每个参数, 包括 NoUseKey 通过 Params 传入的 key
+	//   - ExecScript、EvalCached 的 KEYS, 以及 TryLock、Campaign、ScanMatch、Export 等直接接收 key 的辅助方法
+	// 其他命令通过 Params 传入的参数、Lua 脚本 ARGV 中的 key 不会加前缀
+	KeyPrefix string
 }
 
 func NewRedisClient(config Config) *RedisClient {
-	client := RedisClient{Client: initRedis(config), Config: config}
+	return newRedisClient(initRedis(config), config)
+}
+
+// NewRedisClientWithOptions 使用 go-redis 的单机连接配置创建客户端, 连接失败会 panic
+func NewRedisClientWithOptions(opts *redis.Options) *RedisClient {
+	slog.Info("redisDb connect", "addr", opts.Addr, "db", opts.DB)
+	rdb := redis.NewClient(opts)
+	mustPing(rdb)
+	return newRedisClient(rdb, Config{UserName: opts.Username, Db: opts.DB, PoolSize: opts.PoolSize})
+}
+
+// NewRedisClusterClient 使用 go-redis 的集群连接配置创建客户端, 连接失败会 panic
+// 集群模式下多 key 的命令需要保证所有 key 在同一个 slot 中, 如: 使用 {hash tag}
+func NewRedisClusterClient(opts *redis.ClusterOptions) *RedisClient {
+	slog.Info("redisDb cluster connect", "addrs", opts.Addrs)
+	rdb := redis.NewClusterClient(opts)
+	mustPing(rdb)
+	return newRedisClient(rdb, Config{UserName: opts.Username, PoolSize: opts.PoolSize})
+}
+
+// NewRedisUniversalClient 使用 go-redis 的通用连接配置创建客户端, 连接失败会 panic
+// 设置了 MasterName 时使用哨兵模式, 多个地址时使用集群模式, 否则是单机模式
+func NewRedisUniversalClient(opts *redis.UniversalOptions) *RedisClient {
+	slog.Info("redisDb universal connect", "addrs", opts.Addrs, "master", opts.MasterName)
+	rdb := redis.NewUniversalClient(opts)
+	mustPing(rdb)
+	return newRedisClient(rdb, Config{UserName: opts.Username, Db: opts.DB, PoolSize: opts.PoolSize})
+}
+
+func newRedisClient(rdb redis.UniversalClient, config Config) *RedisClient {
+	client := RedisClient{Client: rdb, Config: config, features: &featureCache{}, scripts: &scriptCache{}, version: &cacheVersion{}}
+	client.version.set(config.CacheVersion)
 	client.builder = client.Handler // Handler 现在返回 *CommandBuilder
 	client.lua = client.ExecScript
 	return &client
 }
 
+// SetCacheVersion 修改缓存版本, 之后构造的命令(包括已经创建的 pipeline 中之后排队的命令)都使用新的版本前缀
+// 版本前缀只加在 RdCmd.Key 构造出的 key 上, NoUseKey 时通过 Params 传入的 key 不会加前缀
+func (rdm *RedisClient) SetCacheVersion(version string) {
+	rdm.version.set(version)
+}
+
+// CacheVersion 返回当前的缓存版本
+func (rdm *RedisClient) CacheVersion() string {
+	return rdm.version.get()
+}
+
+// cacheVersion 运行时可以修改的缓存版本, 在客户端、clone 和 pipeline 之间共享
+type cacheVersion struct {
+	v atomic.Value
+}
+
+func (c *cacheVersion) set(version string) {
+	c.v.Store(version)
+}
+
+func (c *cacheVersion) get() string {
+	if c == nil {
+		return ""
+	}
+	version, _ := c.v.Load().(string)
+	return version
+}
+
+// moveCommands 在 source 和 destination 之间移动元素的命令, source 使用 RdCmd.Key, destination 紧跟在 source 之后
+// destination 和 source 使用同样的命名空间: source 加了版本前缀时 destination 也加, KeyPrefix 两个都加
+var moveCommands = map[Command]bool{
+	SMOVE:      true,
+	RPOPLPUSH:  true,
+	BRPOPLPUSH: true,
+	LMOVE:      true,
+	BLMOVE:     true,
+}
+
+// apply 给构造出的 key 加上版本前缀, 替换 cmdList 中第一个和 key 相同的参数
+// moveCommands 中的命令同时给紧跟在 source 之后的 destination 加上版本前缀
+func (c *cacheVersion) apply(cmdName Command, cmdList []any, key string) ([]any, string) {
+	version := c.get()
+	if version == "" || key == "" {
+		return cmdList, key
+	}
+	versioned := version + ":" + key
+	// key 一般在命令名之后, Params 中使用 {{key}} 时在其他位置
+	for i := 1; i < len(cmdList); i++ {
+		if arg, ok := cmdList[i].(string); ok && arg == key {
+			cmdList[i] = versioned
+			if i == 1 && len(cmdList) > 2 && moveCommands[cmdName] {
+				cmdList[2] = version + ":" + fmt.Sprint(cmdList[2])
+			}
+			break
+		}
+	}
+	return cmdList, versioned
+}
+
 func initRedis(c Config) *redis.Client {
 	slog.Info("redisDb connect", "info", c)
 	addr := c.Host + ":" + c.Port
@@ -49,19 +178,51 @@ func initRedis(c Config) *redis.Client {
 		PoolSize:     c.PoolSize,
 		MaxIdleConns: c.MaxIdle,
 		MinIdleConns: c.MinIdle,
+		// 使用 ctx 的 deadline 作为读写超时, RdSubCmd.Timeout 依赖这个设置
+		ContextTimeoutEnabled: true,
 	}
 	rdb := redis.NewClient(redisOpt)
 	//rdb.AddHook(RKParesHook{})
+	mustPing(rdb)
+	return rdb
+}
+
+func mustPing(rdb redis.UniversalClient) {
 	cmd := rdb.Ping(context.Background())
 	if cmd.Err() != nil {
 		panic("redis connect fail, " + cmd.Err().Error())
 	}
+}
 
-	return rdb
+// Close 关闭底层的客户端, 关闭之后不能再使用
+func (rdm *RedisClient) Close() error {
+	return rdm.Client.Close()
+}
+
+// Ping 发送 PING, 检查连接是否可用
+func (rdm *RedisClient) Ping(ctx context.Context) error {
+	return rdm.Client.Ping(ctx).Err()
+}
+
+// HealthCheck 用于健康检查, 除了 PING 之外再用 ECHO 发送一个随机值, 确认回复和请求是对应的
+// 不会写入数据, 只读副本上也可以使用
+func (rdm *RedisClient) HealthCheck(ctx context.Context) error {
+	if err := rdm.Ping(ctx); err != nil {
+		return fmt.Errorf("rdb: health check ping: %w", err)
+	}
+	token := newToken()
+	echo, err := rdm.Client.Echo(ctx, token).Result()
+	if err != nil {
+		return fmt.Errorf("rdb: health check echo: %w", err)
+	}
+	if echo != token {
+		return fmt.Errorf("rdb: health check echo: got %q, want %q", echo, token)
+	}
+	return nil
 }
 
 func (rdm RedisClient) RedisClose() {
-	err := rdm.Client.Close()
+	err := rdm.Close()
 	if err != nil {
 		slog.Error("close redisDb", "index", rdm.Config.Db, "error", err.Error())
 	} else {
@@ -69,10 +230,18 @@ func (rdm RedisClient) RedisClose() {
 	}
 }
 
-func (rdm RedisClient) Handler(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) *CommandBuilder {
+func (rdm *RedisClient) Handler(ctx context.Context, cmd RdCmd, cmdName Command, args map[string]any, includeArgs ...any) *CommandBuilder {
 	// 返回 CommandBuilder，支持链式调用
 	// CommandBuilder 实现了 redis.Cmder 接口，可以直接作为 redis.Cmder 使用
-	return NewCommandBuilder(&rdm, ctx, cmd, cmdName, args, includeArgs...)
+	return NewCommandBuilder(rdm, ctx, cmd, cmdName, args, includeArgs...)
+}
+
+// clone 复制一份客户端, 共享底层的连接, builder 和 lua 重新绑定到复制出来的客户端上
+func (rdm *RedisClient) clone() *RedisClient {
+	client := *rdm
+	client.builder = client.Handler
+	client.lua = client.ExecScript
+	return &client
 }
 
 func (rdm RedisClient) PipeLine() *RedisPipeline {
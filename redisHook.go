@@ -1,9 +1,19 @@
 package rdb
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type RKParesHook struct{}
@@ -25,3 +35,130 @@ func (RKParesHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.Pro
 		return next(ctx, cmds)
 	}
 }
+
+// CommandHook 命令观察钩子, 在命令发送到 redis 的前后触发, 可以用来记录慢命令或者上报监控指标
+// 直接执行和 pipeline 执行都会触发, pipeline 中的命令 duration 是整个 pipeline 的耗时
+// Before 和 After 都可以为 nil
+type CommandHook struct {
+	Before func(ctx context.Context, cmdName Command, args []any)
+	After  func(ctx context.Context, cmdName Command, args []any, duration time.Duration, err error)
+}
+
+// AddCommandHook 注册命令观察钩子, 钩子挂在底层的 redis 客户端上, 之后从这个客户端创建的 pipeline 也会触发
+func (rdm *RedisClient) AddCommandHook(hook CommandHook) {
+	rdm.Client.AddHook(commandHook{hook: hook})
+}
+
+type commandHook struct {
+	hook CommandHook
+}
+
+func (h commandHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h commandHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		cmdName := Command(strings.ToUpper(cmd.Name()))
+		if h.hook.Before != nil {
+			h.hook.Before(ctx, cmdName, cmd.Args())
+		}
+		start := time.Now()
+		err := next(ctx, cmd)
+		if h.hook.After != nil {
+			h.hook.After(ctx, cmdName, cmd.Args(), time.Since(start), err)
+		}
+		return err
+	}
+}
+
+func (h commandHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if h.hook.Before != nil {
+			for _, cmd := range cmds {
+				h.hook.Before(ctx, Command(strings.ToUpper(cmd.Name())), cmd.Args())
+			}
+		}
+		start := time.Now()
+		err := next(ctx, cmds)
+		if h.hook.After != nil {
+			duration := time.Since(start)
+			for _, cmd := range cmds {
+				h.hook.After(ctx, Command(strings.ToUpper(cmd.Name())), cmd.Args(), duration, cmd.Err())
+			}
+		}
+		return err
+	}
+}
+
+// AddCommandLogger 把之后发送到 redis 的每条命令写入 w, 类似 redis-cli MONITOR, 用于调试模板构造出来的参数
+// 每条命令一行, 格式为: 时间戳 [db] "命令" "参数" ..., pipeline 中的命令带 [pipeline] 标记, 和 MONITOR 一样看不到回复
+// sampleRate 在 (0, 1) 之间时按比例随机采样, 同一个 pipeline 的命令要么全部记录要么全部跳过, 其他值记录所有命令
+// 和 AddCommandHook 一样挂在共享的底层 redis 客户端上, 不是返回新的客户端: 共用这个连接的所有客户端(包括 WithStub、ReplayFrom 得到的)
+// 以及已经创建的 pipeline 都会记录; 调用返回的 stop 之后不再记录, 钩子本身不能从 go-redis 客户端上移除
+// w 的写入错误只记录日志, 不影响命令执行
+func (rdm *RedisClient) AddCommandLogger(w io.Writer, sampleRate float64) (stop func()) {
+	logger := &commandLogger{w: w, db: rdm.Config.Db, sampleRate: sampleRate}
+	rdm.Client.AddHook(logger)
+	return func() { logger.stopped.Store(true) }
+}
+
+type commandLogger struct {
+	mu         sync.Mutex
+	w          io.Writer
+	db         int
+	sampleRate float64
+	stopped    atomic.Bool
+}
+
+func (l *commandLogger) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (l *commandLogger) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if l.sampled() {
+			l.log([]redis.Cmder{cmd}, "")
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (l *commandLogger) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if l.sampled() {
+			l.log(cmds, " [pipeline]")
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// sampled 判断这次是否需要记录
+func (l *commandLogger) sampled() bool {
+	if l.stopped.Load() {
+		return false
+	}
+	if l.sampleRate <= 0 || l.sampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < l.sampleRate
+}
+
+// log 格式化 cmds 之后一次写入 w, 并发的命令不会交错
+func (l *commandLogger) log(cmds []redis.Cmder, tag string) {
+	var buf bytes.Buffer
+	now := time.Now()
+	for _, cmd := range cmds {
+		fmt.Fprintf(&buf, "%d.%06d [%d]%s", now.Unix(), now.Nanosecond()/1000, l.db, tag)
+		for _, arg := range recordArgs(cmd.Args()) {
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.Quote(arg))
+		}
+		buf.WriteByte('\n')
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(buf.Bytes()); err != nil {
+		slog.Error("write redis command log", "error", err.Error())
+	}
+}
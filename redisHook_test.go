@@ -0,0 +1,149 @@
+package rdb
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRedisClient_AddCommandHook 测试命令钩子在直接执行和 pipeline 执行时都会触发
+func TestRedisClient_AddCommandHook(t *testing.T) {
+	client, _ := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "GET":
+			return "hook_value"
+		case "SET":
+			return fakeStatus("OK")
+		}
+		return nil
+	})
+
+	var HookCmd = RdCmd{
+		Key: "hook:{{keyName}}",
+		CMD: map[Command]RdSubCmd{
+			GET: {},
+			SET: {Params: "{{value}}"},
+		},
+	}
+
+	type event struct {
+		name     Command
+		args     []any
+		duration time.Duration
+		err      error
+	}
+	var mu sync.Mutex
+	var before []Command
+	var after []event
+	client.AddCommandHook(CommandHook{
+		Before: func(ctx context.Context, cmdName Command, args []any) {
+			mu.Lock()
+			defer mu.Unlock()
+			before = append(before, cmdName)
+		},
+		After: func(ctx context.Context, cmdName Command, args []any, duration time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			after = append(after, event{name: cmdName, args: args, duration: duration, err: err})
+		},
+	})
+
+	ctx := context.Background()
+	val := client.Get(ctx, HookCmd, map[string]any{"keyName": "a"}).String().Val()
+	if val != "hook_value" {
+		t.Fatalf("Expected hook_value, got %q", val)
+	}
+
+	pip := client.PipeLine()
+	pip.Set(ctx, HookCmd, map[string]any{"keyName": "b", "value": "1"}).String()
+	pip.Get(ctx, HookCmd, map[string]any{"keyName": "b"}).String()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(before, []Command{GET, SET, GET}) {
+		t.Fatalf("unexpected before events: %v", before)
+	}
+	if len(after) != 3 {
+		t.Fatalf("Expected 3 after events, got %d", len(after))
+	}
+	if !reflect.DeepEqual(after[0].args, []any{"GET", "hook:a"}) {
+		t.Errorf("unexpected args: %v", after[0].args)
+	}
+	if !reflect.DeepEqual(after[1].args, []any{"SET", "hook:b", "1"}) {
+		t.Errorf("unexpected args: %v", after[1].args)
+	}
+	for _, e := range after {
+		if e.err != nil || e.duration <= 0 {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	}
+}
+
+// TestRedisClient_AddCommandLogger 测试直接执行和 pipeline 中的命令都会按 MONITOR 的格式写入日志, stop 之后不再记录
+func TestRedisClient_AddCommandLogger(t *testing.T) {
+	client, _ := newFakeClient(t, func(args []string) any {
+		return fakeStatus("OK")
+	})
+	var buf bytes.Buffer
+	stop := client.AddCommandLogger(&buf, 1)
+
+	ctx := context.Background()
+	cmd := RdCmd{Key: "log:{{id}}", CMD: map[Command]RdSubCmd{SET: {Params: "{{value}}"}}}
+	client.Set(ctx, cmd, map[string]any{"id": 1, "value": "a b"}).Status()
+	pip := client.PipeLine()
+	pip.Set(ctx, cmd, map[string]any{"id": 2, "value": 2}).Status()
+	pip.Set(ctx, cmd, map[string]any{"id": 3, "value": 3}).Status()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`[0] "SET" "log:1" "a b"`,
+		`[0] [pipeline] "SET" "log:2" "2"`,
+		`[0] [pipeline] "SET" "log:3" "3"`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d log lines, got %q", len(want), lines)
+	}
+	for i, line := range lines {
+		ts, rest, _ := strings.Cut(line, " ")
+		if _, err := strconv.ParseFloat(ts, 64); err != nil || rest != want[i] {
+			t.Errorf("line %d: expected <timestamp> %s, got %q", i, want[i], line)
+		}
+	}
+
+	stop()
+	buf.Reset()
+	client.Set(ctx, cmd, map[string]any{"id": 4, "value": 4}).Status()
+	if buf.Len() != 0 {
+		t.Errorf("Expected no log after stop, got %q", buf.String())
+	}
+}
+
+// TestCommandLogger_Sampled 测试采样率不在 (0, 1) 之间时记录所有命令
+func TestCommandLogger_Sampled(t *testing.T) {
+	for _, rate := range []float64{0, 1, -1, 2} {
+		if !(&commandLogger{sampleRate: rate}).sampled() {
+			t.Errorf("sampleRate %v: expected every command to be logged", rate)
+		}
+	}
+	logger := &commandLogger{sampleRate: 0.5}
+	var hits int
+	for range 1000 {
+		if logger.sampled() {
+			hits++
+		}
+	}
+	if hits == 0 || hits == 1000 {
+		t.Errorf("sampleRate 0.5: expected some commands to be skipped, got %d/1000", hits)
+	}
+}
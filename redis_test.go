@@ -1,6 +1,21 @@
 package rdb
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"net"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
 	"time"
 )
 
@@ -47,3 +62,449 @@ func InitRedis() *RedisClient {
 	}
 	return NewRedisClient(config)
 }
+
+// fakeStatus 假服务的状态回复, 如 +OK
+type fakeStatus string
+
+// fakeDropConn handler 返回它时假服务不回复, 直接关闭连接, 模拟命令已经发送但是没有收到回复
+type fakeDropConn struct{}
+
+// fakeRedis 测试用的假 redis 服务, 按 RESP2 协议解析命令, 回复由 handler 决定, 不依赖真实的 redis
+// handler 的返回值: nil -> 空回复, fakeStatus -> 状态回复, string/[]byte -> 字符串, int/int64 -> 整数,
+// float64 -> 字符串形式的浮点数, error -> 错误回复, []any/[]string -> 数组
+type fakeRedis struct {
+	ln      net.Listener
+	handler func(args []string) any
+	mu      sync.Mutex
+	cmds    [][]string
+	subs    map[*fakeConn][]string // SUBSCRIBE/PSUBSCRIBE 的连接, 值是 "channel" 或者 "p:pattern"
+}
+
+// fakeConn 假服务的连接, 发布消息和回复命令可能同时写入
+type fakeConn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+func (c *fakeConn) writeReply(replies ...any) error {
+	var buf bytes.Buffer
+	for _, reply := range replies {
+		writeFakeReply(&buf, reply)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.Write(buf.Bytes())
+	return err
+}
+
+// Publish 向订阅了 channel 的连接推送消息, 返回收到消息的连接数
+func (f *fakeRedis) Publish(channel, payload string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for conn, subs := range f.subs {
+		for _, sub := range subs {
+			if pattern, ok := strings.CutPrefix(sub, "p:"); ok {
+				if matched, _ := path.Match(pattern, channel); matched {
+					_ = conn.writeReply([]any{"pmessage", pattern, channel, payload})
+					n++
+				}
+			} else if sub == channel {
+				_ = conn.writeReply([]any{"message", channel, payload})
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// DropSubscribers 断开所有订阅的连接, 模拟网络中断
+func (f *fakeRedis) DropSubscribers() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for conn := range f.subs {
+		_ = conn.Close()
+	}
+	f.subs = nil
+}
+
+// Subscribers 返回当前订阅的连接数
+func (f *fakeRedis) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+// subscribe 记录连接的订阅, 返回每个 channel 的订阅确认
+func (f *fakeRedis) subscribe(conn *fakeConn, args []string) []any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cmds = append(f.cmds, args)
+	if f.subs == nil {
+		f.subs = map[*fakeConn][]string{}
+	}
+	kind, prefix := "subscribe", ""
+	if args[0] == "PSUBSCRIBE" {
+		kind, prefix = "psubscribe", "p:"
+	}
+	replies := make([]any, 0, len(args)-1)
+	for _, channel := range args[1:] {
+		f.subs[conn] = append(f.subs[conn], prefix+channel)
+		replies = append(replies, []any{kind, channel, len(f.subs[conn])})
+	}
+	return replies
+}
+
+// subscribed 判断连接是否处于订阅状态
+func (f *fakeRedis) subscribed(conn *fakeConn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.subs[conn]
+	return ok
+}
+
+func newFakeRedis(t *testing.T, handler func(args []string) any) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("fake redis listen failed: %v", err)
+	}
+	f := &fakeRedis{ln: ln, handler: handler}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+// newFakeClient 创建一个连接到假服务的 RedisClient
+func newFakeClient(t *testing.T, handler func(args []string) any) (*RedisClient, *fakeRedis) {
+	f := newFakeRedis(t, handler)
+	host, port, _ := net.SplitHostPort(f.ln.Addr().String())
+	client := NewRedisClient(Config{Host: host, Port: port, PoolSize: 2})
+	t.Cleanup(client.RedisClose)
+	f.Reset()
+	return client, f
+}
+
+// Commands 返回假服务收到的命令, 不包含连接握手的命令
+func (f *fakeRedis) Commands() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.cmds...)
+}
+
+func (f *fakeRedis) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cmds = nil
+}
+
+func (f *fakeRedis) serve(raw net.Conn) {
+	conn := &fakeConn{Conn: raw}
+	defer func() {
+		f.mu.Lock()
+		delete(f.subs, conn)
+		f.mu.Unlock()
+		conn.Close()
+	}()
+	rd := bufio.NewReader(conn)
+	var queued [][]string // MULTI 之后排队的命令
+	inMulti := false
+	for {
+		args, err := readFakeCommand(rd)
+		if err != nil {
+			return
+		}
+		var reply any
+		switch strings.ToUpper(args[0]) {
+		case "SUBSCRIBE", "PSUBSCRIBE":
+			if err := conn.writeReply(f.subscribe(conn, args)...); err != nil {
+				return
+			}
+			continue
+		case "PING":
+			if !f.subscribed(conn) {
+				reply = f.handle(args)
+				break
+			}
+			// 订阅状态下 PING 的回复是数组
+			payload := ""
+			if len(args) > 1 {
+				payload = args[1]
+			}
+			reply = []any{"pong", payload}
+		case "MULTI":
+			inMulti, queued = true, nil
+			reply = fakeStatus("OK")
+		case "EXEC":
+			replies := make([]any, 0, len(queued))
+			for _, q := range queued {
+				replies = append(replies, f.handle(q))
+			}
+			inMulti, queued = false, nil
+			reply = replies
+		case "HELLO":
+			reply = errors.New("ERR unknown command 'HELLO'")
+		case "CLIENT":
+			if len(args) > 1 && strings.ToUpper(args[1]) == "SETINFO" {
+				reply = fakeStatus("OK")
+				break
+			}
+			fallthrough
+		default:
+			if inMulti {
+				queued = append(queued, args)
+				reply = fakeStatus("QUEUED")
+				break
+			}
+			reply = f.handle(args)
+		}
+		if _, ok := reply.(fakeDropConn); ok {
+			return
+		}
+		if err := conn.writeReply(reply); err != nil {
+			return
+		}
+	}
+}
+
+// handle 记录命令并交给 handler 处理
+func (f *fakeRedis) handle(args []string) any {
+	f.mu.Lock()
+	f.cmds = append(f.cmds, args)
+	f.mu.Unlock()
+	var reply any
+	if f.handler != nil {
+		reply = f.handler(args)
+	}
+	if reply == nil && strings.ToUpper(args[0]) == "PING" {
+		reply = fakeStatus("PONG")
+	}
+	return reply
+}
+
+func readFakeCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		head, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimRight(head, "\r\n")[1:])
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, err
+		}
+		args = append(args, string(data[:size]))
+	}
+	if len(args) > 0 {
+		// 命令名不区分大小写, 统一转换成大写方便 handler 处理
+		args[0] = strings.ToUpper(args[0])
+	}
+	return args, nil
+}
+
+func writeFakeReply(buf *bytes.Buffer, reply any) {
+	switch v := reply.(type) {
+	case nil:
+		buf.WriteString("$-1\r\n")
+	case fakeStatus:
+		buf.WriteString("+" + string(v) + "\r\n")
+	case string:
+		buf.WriteString("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n")
+	case []byte:
+		writeFakeReply(buf, string(v))
+	case int:
+		buf.WriteString(":" + strconv.Itoa(v) + "\r\n")
+	case int64:
+		buf.WriteString(":" + strconv.FormatInt(v, 10) + "\r\n")
+	case float64:
+		writeFakeReply(buf, strconv.FormatFloat(v, 'f', -1, 64))
+	case error:
+		buf.WriteString("-" + v.Error() + "\r\n")
+	case []string:
+		buf.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, item := range v {
+			writeFakeReply(buf, item)
+		}
+	case []any:
+		buf.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, item := range v {
+			writeFakeReply(buf, item)
+		}
+	default:
+		writeFakeReply(buf, fmt.Sprint(v))
+	}
+}
+
+// TestNewRedisClientConstructors 测试单机、通用和集群的构造函数使用同一套 builder 执行命令
+func TestNewRedisClientConstructors(t *testing.T) {
+	var addr string
+	fake := newFakeRedis(t, func(args []string) any {
+		switch args[0] {
+		case "SELECT":
+			return fakeStatus("OK")
+		case "CLUSTER":
+			host, port, _ := net.SplitHostPort(addr)
+			p, _ := strconv.Atoi(port)
+			return []any{[]any{0, 16383, []any{host, p, "node-1"}}}
+		case "GET":
+			return "v:" + args[1]
+		}
+		return nil
+	})
+	addr = fake.ln.Addr().String()
+	cmd := RdCmd{
+		Key: "k:{{id}}",
+		CMD: map[Command]RdSubCmd{GET: {}},
+	}
+	ctx := context.Background()
+
+	clients := map[string]*RedisClient{
+		"options":   NewRedisClientWithOptions(&redis.Options{Addr: addr, DB: 2}),
+		"universal": NewRedisUniversalClient(&redis.UniversalOptions{Addrs: []string{addr}}),
+		"cluster":   NewRedisClusterClient(&redis.ClusterOptions{Addrs: []string{addr}}),
+	}
+	for name, client := range clients {
+		got, err := client.Get(ctx, cmd, map[string]any{"id": name}).String().Result()
+		if err != nil || got != "v:k:"+name {
+			t.Errorf("%s: unexpected GET result %q %v", name, got, err)
+		}
+		client.RedisClose()
+	}
+	if clients["options"].Config.Db != 2 {
+		t.Errorf("expected Config.Db to be copied from options, got %d", clients["options"].Config.Db)
+	}
+	if _, ok := clients["cluster"].Client.(*redis.ClusterClient); !ok {
+		t.Errorf("expected cluster client, got %T", clients["cluster"].Client)
+	}
+}
+
+// TestRedisClient_CacheVersion 测试修改缓存版本之后解析出的 key 随之改变
+func TestRedisClient_CacheVersion(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "EXPIRE" {
+			return 1
+		}
+		return fakeStatus("OK")
+	})
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			GET: {},
+			SET: {Params: "{{value}}", Exp: func() time.Duration { return time.Minute }},
+		},
+	}
+	ctx := context.Background()
+	args := map[string]any{"id": 1, "value": "a"}
+
+	client.Get(ctx, cmd, args).Err()
+	client.SetCacheVersion("v3")
+	client.Set(ctx, cmd, args).Status()
+	pip := client.PipeLine()
+	client.SetCacheVersion("v4")
+	pip.Get(ctx, cmd, args).String()
+	if _, err := pip.Exec(ctx); err != nil {
+		t.Fatalf("pipeline Exec failed: %v", err)
+	}
+	if got := client.Get(ctx, cmd, args).Args(); !reflect.DeepEqual(got, []any{"GET", "v4:user:1"}) {
+		t.Errorf("Expected versioned Args, got %v", got)
+	}
+	if client.CacheVersion() != "v4" {
+		t.Errorf("Expected version v4, got %q", client.CacheVersion())
+	}
+
+	want := [][]string{
+		{"GET", "user:1"},
+		{"SET", "v3:user:1", "a"},
+		{"EXPIRE", "v3:user:1", "60"},
+		{"GET", "v4:user:1"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_CacheVersionKeyPlaceholder 测试 key 通过 {{key}} 放在其他位置时版本前缀加在 key 上, 过期时间也设置在带版本的 key 上
+func TestRedisClient_CacheVersionKeyPlaceholder(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		return 1
+	})
+	cmd := RdCmd{
+		Key: "list:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			LINSERT: {Params: "{{key}} BEFORE {{pivot}} {{element}}", NoUseKey: true, Exp: func() time.Duration { return time.Minute }},
+		},
+	}
+	client.SetCacheVersion("v2")
+	if err := client.LInsert(context.Background(), cmd, map[string]any{"id": 1, "pivot": "b", "element": "a"}).Int().Err(); err != nil {
+		t.Fatalf("LInsert failed: %v", err)
+	}
+	want := [][]string{
+		{"LINSERT", "v2:list:1", "BEFORE", "b", "a"},
+		{"EXPIRE", "v2:list:1", "60"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestRedisClient_HealthCheck 测试 Ping/HealthCheck 的结果, ECHO 回复不匹配时失败, Close 之后 Ping 返回错误
+func TestRedisClient_HealthCheck(t *testing.T) {
+	var mismatch atomic.Bool
+	client, fake := newFakeClient(t, func(args []string) any {
+		switch args[0] {
+		case "PING":
+			return fakeStatus("PONG")
+		case "ECHO":
+			if mismatch.Load() {
+				return "stale"
+			}
+			return args[1]
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := client.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if got := fake.Commands(); len(got) != 3 || got[2][0] != "ECHO" || len(got[2][1]) != 32 {
+		t.Errorf("Expected PING, PING, ECHO <token>, got %v", got)
+	}
+	mismatch.Store(true)
+	if err := client.HealthCheck(ctx); err == nil {
+		t.Errorf("Expected error for mismatched ECHO")
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := client.Ping(ctx); err == nil {
+		t.Errorf("Expected error after Close")
+	}
+}
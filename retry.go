@@ -0,0 +1,127 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// RetryPolicy 命令的重试策略, 只用于直接执行的命令, Pipeline 中的命令不受影响
+// 只重试 Retryable 判定为临时性的错误, redis.Nil 和业务错误(如: WRONGTYPE)不会重试
+// 命令可能已经发送之后的网络错误(如: 读超时、连接断开)只重试 readOnlyCommands 中的只读命令, INCR/LPUSH/XADD 等写命令重新发送可能执行两次;
+// 确定没有执行的错误(LOADING/READONLY/MOVED 等服务端错误和建立连接失败)所有命令都会重试; 写命令是幂等的时候可以设置 RetryWrites
+// Exp 设置的过期时间在重试结束之后只执行一次; AtomicExpire 的命令在事务中执行, 不会重试
+// 这里的重试在 go-redis 自己的重试(redis.Options.MaxRetries)之外, 每次重试内部 go-redis 还会按 MaxRetries 重试, 两者的次数是相乘的
+type RetryPolicy struct {
+	MaxRetries  int                             // 最多重试次数, 不包括第一次执行
+	Backoff     func(attempt int) time.Duration // 第 attempt 次重试之前等待的时间, attempt 从 1 开始; 为空时不等待
+	Retryable   func(err error) bool            // 为空时使用 IsRetryableError
+	RetryWrites bool                            // 为 true 时写命令在可能已经发送之后的网络错误也重试, 只在写命令幂等时使用
+}
+
+// ExponentialBackoff 返回指数退避函数, 第 n 次重试等待 base*2^(n-1), 最多等待 max
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+// ErrCircuitOpen 熔断器打开时命令不再发送到 redis, 熔断器(如: go-redis 的 hook)应该返回这个错误或者包装了这个错误的错误
+// 设置了 RdSubCmd.Fallback 的命令遇到这个错误时使用降级的结果
+var ErrCircuitOpen = errors.New("rdb: circuit breaker is open")
+
+// shouldFallback 判断命令的错误是否应该使用 Fallback 的结果: 熔断或者超时
+func shouldFallback(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryableErrPrefixes 表示 redis 暂时不可用的错误前缀, 稍后重试可能成功
+var retryableErrPrefixes = []string{"LOADING ", "READONLY ", "MASTERDOWN ", "CLUSTERDOWN ", "TRYAGAIN ", "MOVED ", "ASK "}
+
+// IsRetryableError 判断 err 是否是临时性的错误: 网络错误、连接断开和集群迁移/主从切换中的错误
+// context 取消和超时不会重试
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	var rErr redis.Error
+	if errors.As(err, &rErr) {
+		msg := rErr.Error()
+		for _, prefix := range retryableErrPrefixes {
+			if strings.HasPrefix(msg, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isUnsentError 判断 err 是否说明命令没有被 redis 执行: 服务端拒绝执行的临时性错误或者建立连接失败
+// 其他网络错误发生时命令可能已经写出去并执行, 只是没有收到回复
+func isUnsentError(err error) bool {
+	var rErr redis.Error
+	if errors.As(err, &rErr) {
+		return IsRetryableError(err)
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// processWithRetry 执行 process, 失败时按 policy 重试, 返回最后一次的错误
+// 每次重试之前清除 cmder 上一次的错误; 不是只读命令时只重试 isUnsentError 的错误, 除非设置了 RetryWrites
+func processWithRetry(ctx context.Context, policy *RetryPolicy, cmdName Command, cmder redis.Cmder, process func() error) error {
+	err := process()
+	if policy == nil {
+		return err
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryableError
+	}
+	for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+		if err == nil {
+			err = cmder.Err()
+		}
+		if !retryable(err) {
+			break
+		}
+		if !policy.RetryWrites && !readOnlyCommands[cmdName] && !isUnsentError(err) {
+			break
+		}
+		if policy.Backoff != nil {
+			timer := time.NewTimer(policy.Backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+		cmder.SetErr(nil)
+		err = process()
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestIsRetryableError 测试临时性错误的判定
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{redis.Nil, false},
+		{context.DeadlineExceeded, false},
+		{io.EOF, true},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{redis.ErrClosed, false},
+		{testRedisError("TRYAGAIN Multiple keys request during rehashing of slot"), true},
+		{testRedisError("MOVED 3999 127.0.0.1:6381"), true},
+		{testRedisError("LOADING Redis is loading the dataset in memory"), true},
+		{testRedisError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{errors.New("TRYAGAIN not a redis error"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryableError(tt.err); got != tt.want {
+			t.Errorf("IsRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// testRedisError 模拟 redis 返回的错误
+type testRedisError string
+
+func (e testRedisError) Error() string { return string(e) }
+
+func (testRedisError) RedisError() {}
+
+// TestExecuteCmd_Retry 测试临时性错误会重试, 且过期时间只设置一次
+func TestExecuteCmd_Retry(t *testing.T) {
+	failures := 0
+	fake := newFakeRedis(t, func(args []string) any {
+		switch args[0] {
+		case "SET":
+			if failures < 2 {
+				failures++
+				return errors.New("TRYAGAIN Multiple keys request during rehashing of slot")
+			}
+			return fakeStatus("OK")
+		case "HSET":
+			return errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+		case "EXPIRE":
+			return 1
+		}
+		return nil
+	})
+	// 关闭 go-redis 自己的重试, 只测试 RetryPolicy
+	client := NewRedisClientWithOptions(&redis.Options{Addr: fake.ln.Addr().String(), MaxRetries: -1})
+	t.Cleanup(client.RedisClose)
+	fake.Reset()
+	var attempts []int
+	client.Retry = &RetryPolicy{
+		MaxRetries: 3,
+		Backoff: func(attempt int) time.Duration {
+			attempts = append(attempts, attempt)
+			return time.Millisecond
+		},
+	}
+	cmd := RdCmd{
+		Key: "retry:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			SET:  {Params: "{{value}}", Exp: func() time.Duration { return time.Minute }},
+			HSET: {Params: "{{field}} {{value}}"},
+			GET:  {ReturnNilError: true},
+		},
+	}
+	ctx := context.Background()
+
+	if err := client.Set(ctx, cmd, map[string]any{"id": 1, "value": "a"}).Err(); err != nil {
+		t.Fatalf("Set failed after retry: %v", err)
+	}
+	if !reflect.DeepEqual(attempts, []int{1, 2}) {
+		t.Errorf("Expected backoff attempts [1 2], got %v", attempts)
+	}
+	want := [][]string{
+		{"SET", "retry:1", "a"},
+		{"SET", "retry:1", "a"},
+		{"SET", "retry:1", "a"},
+		{"EXPIRE", "retry:1", "60"},
+	}
+	if got := fake.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// 业务错误和 redis.Nil 不重试
+	fake.Reset()
+	if err := client.HSet(ctx, cmd, map[string]any{"id": 1, "field": "f", "value": "v"}).Err(); err == nil {
+		t.Errorf("Expected WRONGTYPE error")
+	}
+	if err := client.Get(ctx, cmd, map[string]any{"id": 1}).Err(); !errors.Is(err, redis.Nil) {
+		t.Errorf("Expected redis.Nil, got %v", err)
+	}
+	if got := len(fake.Commands()); got != 2 {
+		t.Errorf("Expected 2 commands without retry, got %v", fake.Commands())
+	}
+}
+
+// TestExecuteCmd_RetryWrites 测试命令可能已经发送之后断开连接时只重试只读命令, 写命令需要设置 RetryWrites
+func TestExecuteCmd_RetryWrites(t *testing.T) {
+	fake := newFakeRedis(t, func(args []string) any {
+		switch args[0] {
+		case "INCR", "GET":
+			return fakeDropConn{}
+		}
+		return nil
+	})
+	// 关闭 go-redis 自己的重试, 只测试 RetryPolicy
+	client := NewRedisClientWithOptions(&redis.Options{Addr: fake.ln.Addr().String(), MaxRetries: -1})
+	t.Cleanup(client.RedisClose)
+	client.Retry = &RetryPolicy{MaxRetries: 2}
+	cmd := RdCmd{
+		Key: "counter:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			INCR: {},
+			GET:  {},
+		},
+	}
+	ctx := context.Background()
+	count := func(name string) int {
+		n := 0
+		for _, c := range fake.Commands() {
+			if c[0] == name {
+				n++
+			}
+		}
+		return n
+	}
+
+	if err := client.Incr(ctx, cmd, map[string]any{"id": 1}).Int().Err(); err == nil {
+		t.Fatalf("Expected INCR to fail")
+	}
+	if n := count("INCR"); n != 1 {
+		t.Errorf("Expected INCR to be sent once, got %d", n)
+	}
+	if err := client.Get(ctx, cmd, map[string]any{"id": 1}).String().Err(); err == nil {
+		t.Fatalf("Expected GET to fail")
+	}
+	if n := count("GET"); n != 3 {
+		t.Errorf("Expected GET to be retried twice, got %d attempts", n)
+	}
+
+	fake.Reset()
+	client.Retry.RetryWrites = true
+	client.Incr(ctx, cmd, map[string]any{"id": 1}).Int()
+	if n := count("INCR"); n != 3 {
+		t.Errorf("Expected INCR to be retried twice with RetryWrites, got %d attempts", n)
+	}
+}
+
+// TestExponentialBackoff 测试指数退避的等待时间
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}
+	for i, w := range want {
+		if got := backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+// breakerHook 模拟熔断器, open 时命令直接返回 ErrCircuitOpen
+type breakerHook struct {
+	open bool
+}
+
+func (h *breakerHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if h.open {
+			cmd.SetErr(ErrCircuitOpen)
+			return ErrCircuitOpen
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestExecuteCmd_Fallback 测试熔断和超时时使用 Fallback 的结果, 正常时执行真实的命令
+func TestExecuteCmd_Fallback(t *testing.T) {
+	client, fake := newFakeClient(t, func(args []string) any {
+		if args[0] == "GET" {
+			if args[1] == "user:slow" {
+				time.Sleep(200 * time.Millisecond)
+			}
+			return "fresh"
+		}
+		return nil
+	})
+	breaker := &breakerHook{}
+	client.Client.AddHook(breaker)
+	var fallbackKeys []string
+	cmd := RdCmd{
+		Key: "user:{{id}}",
+		CMD: map[Command]RdSubCmd{
+			GET: {
+				Timeout: 50 * time.Millisecond,
+				Fallback: func(ctx context.Context, key string) (any, error) {
+					fallbackKeys = append(fallbackKeys, key)
+					return "stale", nil
+				},
+			},
+			HGET: {Params: "{{field}}"},
+		},
+	}
+	ctx := context.Background()
+
+	if val, err := client.Get(ctx, cmd, map[string]any{"id": 1}).String().Result(); err != nil || val != "fresh" {
+		t.Errorf("Expected fresh value, got %v %v", val, err)
+	}
+	if val, err := client.Get(ctx, cmd, map[string]any{"id": "slow"}).String().Result(); err != nil || val != "stale" {
+		t.Errorf("Expected stale value on timeout, got %v %v", val, err)
+	}
+
+	breaker.open = true
+	fake.Reset()
+	if val, err := client.Get(ctx, cmd, map[string]any{"id": 1}).String().Result(); err != nil || val != "stale" {
+		t.Errorf("Expected stale value while breaker is open, got %v %v", val, err)
+	}
+	// 没有设置 Fallback 的命令仍然返回错误
+	if err := client.HGet(ctx, cmd, map[string]any{"id": 1, "field": "name"}).Err(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if len(fake.Commands()) != 0 {
+		t.Errorf("Expected no commands sent while breaker is open, got %v", fake.Commands())
+	}
+	if !reflect.DeepEqual(fallbackKeys, []string{"user:slow", "user:1"}) {
+		t.Errorf("Unexpected fallback keys: %v", fallbackKeys)
+	}
+}
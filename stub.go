@@ -0,0 +1,75 @@
+package rdb
+
+import (
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+)
+
+// StubFunc 根据构造好的命令参数返回预设的结果, args[0] 是命令名
+// 返回 nil 值表示 redis 返回了 nil, 和真实执行一样受 ReturnNilError 控制
+type StubFunc func(args []any) (any, error)
+
+// WithStub 返回一个带有预设结果的客户端, 用于单元测试
+// 命令有对应的 stub 时, 直接把 stub 的结果写入对应的 cmder, 不会调用 Process, 也不会执行 Exp 设置的过期时间
+// 没有 stub 的命令仍然通过底层的 Client 执行; 只对直接执行生效, Pipeline 中的命令不受影响
+// 如:
+//
+//	client := (&RedisClient{}).WithStub(map[Command]func(args []any) (any, error){
+//		GET: func(args []any) (any, error) { return "alice", nil },
+//	})
+func (rdm *RedisClient) WithStub(stubs map[Command]func(args []any) (any, error)) *RedisClient {
+	client := rdm.clone()
+	client.stubs = make(map[Command]StubFunc, len(rdm.stubs)+len(stubs))
+	for name, stub := range rdm.stubs {
+		client.stubs[name] = stub
+	}
+	for name, stub := range stubs {
+		client.stubs[name] = stub
+	}
+	return client
+}
+
+// runStub 执行 stub 并把结果写入 cmder
+func runStub(cmder redis.Cmder, stub StubFunc, args []any) error {
+	val, err := stub(args)
+	if err != nil {
+		cmder.SetErr(err)
+		return err
+	}
+	return setCmderVal(cmder, val)
+}
+
+// setCmderVal 通过 cmder 的 SetVal 方法设置结果, 数字类型之间以及 []byte 到 string 会自动转换
+// val 为 nil 时设置 redis.Nil
+func setCmderVal(cmder redis.Cmder, val any) error {
+	if val == nil {
+		cmder.SetErr(redis.Nil)
+		return redis.Nil
+	}
+	setVal := reflect.ValueOf(cmder).MethodByName("SetVal")
+	if !setVal.IsValid() || setVal.Type().NumIn() != 1 {
+		err := fmt.Errorf("rdb: %T does not support SetVal", cmder)
+		cmder.SetErr(err)
+		return err
+	}
+	want := setVal.Type().In(0)
+	v := reflect.ValueOf(val)
+	switch {
+	case v.Type().AssignableTo(want):
+	case want.Kind() == reflect.String && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v = reflect.ValueOf(string(v.Bytes())).Convert(want)
+	case isNumberKind(v.Kind()) && isNumberKind(want.Kind()):
+		v = v.Convert(want)
+	default:
+		err := fmt.Errorf("rdb: cannot use %T as %s result for %T", val, want, cmder)
+		cmder.SetErr(err)
+		return err
+	}
+	setVal.Call([]reflect.Value{v})
+	return nil
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
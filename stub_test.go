@@ -0,0 +1,69 @@
+package rdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// loadUserName 模拟业务代码, 通过 GET 读取用户名
+func loadUserName(ctx context.Context, client *RedisClient, id int) (string, error) {
+	return client.Get(ctx, StubUserCmd, map[string]any{"id": id}).String().Result()
+}
+
+var StubUserCmd = RdCmd{
+	Key: "user:{{id}}",
+	CMD: map[Command]RdSubCmd{
+		GET:  {},
+		INCR: {},
+	},
+}
+
+// TestRedisClient_WithStub 测试通过 stub 返回预设的结果, 不需要 redis 服务
+func TestRedisClient_WithStub(t *testing.T) {
+	ctx := context.Background()
+	var gotArgs []any
+	client := (&RedisClient{}).WithStub(map[Command]func(args []any) (any, error){
+		GET: func(args []any) (any, error) {
+			gotArgs = args
+			return "alice", nil
+		},
+		INCR: func(args []any) (any, error) {
+			return 42, nil
+		},
+	})
+
+	name, err := loadUserName(ctx, client, 7)
+	if err != nil || name != "alice" {
+		t.Fatalf("Expected alice, got %q %v", name, err)
+	}
+	if len(gotArgs) != 2 || gotArgs[0] != "GET" || gotArgs[1] != "user:7" {
+		t.Errorf("unexpected stub args: %v", gotArgs)
+	}
+	if n, err := client.Incr(ctx, StubUserCmd, map[string]any{"id": 7}).Int().Result(); err != nil || n != 42 {
+		t.Errorf("Expected 42, got %d %v", n, err)
+	}
+	if _, err := client.Incr(ctx, StubUserCmd, map[string]any{"id": 7}).Bool().Result(); err == nil {
+		t.Errorf("Expected type mismatch error")
+	}
+
+	// 后添加的 stub 覆盖原来的, 原来的客户端不受影响
+	boom := errors.New("boom")
+	failing := client.WithStub(map[Command]func(args []any) (any, error){
+		GET: func(args []any) (any, error) { return nil, boom },
+	})
+	if _, err := loadUserName(ctx, failing, 7); !errors.Is(err, boom) {
+		t.Errorf("Expected boom, got %v", err)
+	}
+	if name, _ := loadUserName(ctx, client, 7); name != "alice" {
+		t.Errorf("original client should keep its stub, got %q", name)
+	}
+
+	// stub 返回 nil 值时和 redis 返回 nil 一样
+	missing := client.WithStub(map[Command]func(args []any) (any, error){
+		GET: func(args []any) (any, error) { return nil, nil },
+	})
+	if name, err := loadUserName(ctx, missing, 7); err != nil || name != "" {
+		t.Errorf("Expected empty result without error, got %q %v", name, err)
+	}
+}